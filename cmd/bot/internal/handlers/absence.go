@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+)
+
+const absenceDateLayout = "2 Jan 2006"
+
+// Away marks a participant as absent for a period of days, e.g.
+// "@bob 2026-11-03..2026-11-14", or a single day. Absent participants are
+// not mentioned in reminds.
+func (b *Bot) Away(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Away")
+	defer span.End()
+
+	fields := strings.Fields(m.Payload)
+	if len(fields) != 2 {
+		b.reply(m, `Set the participant and the days of absence, e.g. "@bob 2026-11-03..2026-11-14"`)
+		return
+	}
+	name := fields[0]
+
+	from, until, err := b.parseAbsence(fields[1])
+	if err != nil {
+		b.reply(m, err.Error())
+		return
+	}
+
+	if !until.After(b.clock.Now()) {
+		b.reply(m, "The absence is already over")
+		return
+	}
+
+	p, err := b.findParticipant(ctx, name)
+	switch {
+	case err == participant.ErrNotFound:
+		b.reply(m, fmt.Sprintf("%s is not a participant", name))
+		return
+	case err != nil:
+		log.Println("handlers.Bot.Away : error :", err)
+		return
+	}
+	name = p.Name
+
+	if err := b.storage.Participant.SetAbsence(ctx, name, &from, &until, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving absence")
+		log.Println("handlers.Bot.Away : error :", err)
+		return
+	}
+
+	b.reply(m, fmt.Sprintf("%s is away %s", name, b.formatAbsence(from, until)))
+}
+
+// Back ends the absence of a participant.
+func (b *Bot) Back(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Back")
+	defer span.End()
+
+	name := strings.TrimSpace(m.Payload)
+	if name == "" {
+		b.reply(m, `Set the participant who is back, e.g. "@bob"`)
+		return
+	}
+
+	p, err := b.findParticipant(ctx, name)
+	switch {
+	case err == participant.ErrNotFound:
+		b.reply(m, fmt.Sprintf("%s is not a participant", name))
+		return
+	case err != nil:
+		log.Println("handlers.Bot.Back : error :", err)
+		return
+	}
+	name = p.Name
+
+	if err := b.storage.Participant.SetAbsence(ctx, name, nil, nil, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error clearing absence")
+		log.Println("handlers.Bot.Back : error :", err)
+		return
+	}
+
+	b.reply(m, fmt.Sprintf("Welcome back, %s", name))
+}
+
+// parseAbsence reads the days of absence in format "2006-01-02..2006-01-02"
+// or "2006-01-02" in the location of the reminder. It returns the start of
+// the first day and the start of the day after the last one.
+func (b *Bot) parseAbsence(raw string) (time.Time, time.Time, error) {
+	rawFrom, rawTo := raw, raw
+	if i := strings.Index(raw, ".."); i >= 0 {
+		rawFrom, rawTo = raw[:i], raw[i+2:]
+	}
+
+	from, err := time.ParseInLocation(pauseDateLayout, rawFrom, b.location())
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.Errorf("invalid date %q, expected format 2006-01-02", rawFrom)
+	}
+
+	to, err := time.ParseInLocation(pauseDateLayout, rawTo, b.location())
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.Errorf("invalid date %q, expected format 2006-01-02", rawTo)
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, errors.New("The absence ends before it starts")
+	}
+
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// formatAbsence describes the days of absence from the start of the first
+// one until the start of the day after the last one.
+func (b *Bot) formatAbsence(from, until time.Time) string {
+	loc := b.location()
+	last := until.In(loc).AddDate(0, 0, -1)
+
+	return fmt.Sprintf("from %s until %s", from.In(loc).Format(absenceDateLayout), last.Format(absenceDateLayout))
+}
+
+// absences describes the current and the upcoming absences of participants.
+func (b *Bot) absences(participants []participant.Participant) []string {
+	now := b.clock.Now()
+
+	var lines []string
+	for _, p := range participants {
+		if p.AwayUntil == nil || !p.AwayUntil.After(now) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", p.Name, b.formatAbsence(*p.AwayFrom, *p.AwayUntil)))
+	}
+
+	return lines
+}
@@ -0,0 +1,81 @@
+package handlers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestAbsence validates a participant away is left out of the reminds of the
+// days of absence only, and is mentioned again once back.
+func TestAbsence(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	// remind moves the clock on to the remind of the day and returns it.
+	remind := func(d int) string {
+		t.Helper()
+
+		n := len(e.sentTo("-100"))
+		if !e.advanceTo(t, day(d, 10, 0)) {
+			t.Fatalf("\t%s\tShould remind on %d March.", tests.Failed, d)
+		}
+		return strings.TrimSuffix(e.sentTo("-100")[n], "\nFill in project server, please!")
+	}
+
+	t.Log("Given a participant away from Tuesday to Wednesday.")
+	{
+		startReminding(t, e)
+		e.command(t, "/addparticipant @carol")
+
+		for _, tc := range []struct {
+			cmd    string
+			answer string
+		}{
+			{"/away @bob", `Set the participant and the days of absence, e.g. "@bob 2026-11-03..2026-11-14"`},
+			{"/away @bob 2020-03-03..friday", `invalid date "friday", expected format 2006-01-02`},
+			{"/away @bob 2020-03-04..2020-03-03", "The absence ends before it starts"},
+			{"/away @bob 2020-02-24..2020-03-01", "The absence is already over"},
+			{"/away @erin 2020-03-03", "@erin is not a participant"},
+		} {
+			if got := e.command(t, tc.cmd); got != tc.answer {
+				t.Fatalf("\t%s\tShould refuse %q : got %q, want %q.", tests.Failed, tc.cmd, got, tc.answer)
+			}
+		}
+		t.Logf("\t%s\tShould refuse invalid absences.", tests.Success)
+
+		if got := e.command(t, "/away bob 2020-03-03..2020-03-04"); got != "@bob is away from 3 Mar 2020 until 4 Mar 2020" {
+			t.Fatalf("\t%s\tShould mark the participant away : got %q.", tests.Failed, got)
+		}
+		if info := e.command(t, "/info"); !strings.Contains(info, "Away: @bob from 3 Mar 2020 until 4 Mar 2020\n") {
+			t.Fatalf("\t%s\tShould show the absence in the info : got %q.", tests.Failed, info)
+		}
+		t.Logf("\t%s\tShould show the absence in the info.", tests.Success)
+
+		if got := remind(2); got != "@bob, @carol" {
+			t.Fatalf("\t%s\tShould mention the participant before the absence : got %q.", tests.Failed, got)
+		}
+		for _, d := range []int{3, 4} {
+			if got := remind(d); got != "@carol" {
+				t.Fatalf("\t%s\tShould leave the participant out on %d March : got %q.", tests.Failed, d, got)
+			}
+		}
+		if got := remind(5); got != "@bob, @carol" {
+			t.Fatalf("\t%s\tShould mention the participant after the absence : got %q.", tests.Failed, got)
+		}
+		if info := e.command(t, "/info"); strings.Contains(info, "@bob from") {
+			t.Fatalf("\t%s\tShould no longer show a past absence in the info : got %q.", tests.Failed, info)
+		}
+		t.Logf("\t%s\tShould leave the participant out during the absence only.", tests.Success)
+
+		e.command(t, "/away @bob 2020-03-06")
+		if got := e.command(t, "/back @bob"); got != "Welcome back, @bob" {
+			t.Fatalf("\t%s\tShould end the absence : got %q.", tests.Failed, got)
+		}
+		if got := remind(6); got != "@bob, @carol" {
+			t.Fatalf("\t%s\tShould mention the participant back : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould end the absence on /back.", tests.Success)
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/mid"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/web"
+)
+
+// API returns the handler of the HTTP API managing the bot, every route but
+// the health check requires the token. The API is described in
+// cmd/bot/openapi.yaml.
+func (b *Bot) API(log *log.Logger, token string) http.Handler {
+	app := web.NewApp(mid.Logger(log), mid.Errors(log), mid.Panics(log))
+
+	app.Handle(http.MethodGet, "/v1/health", b.health)
+
+	auth := mid.Authenticate(token)
+
+	r := reminderAPI{bot: b}
+	app.Handle(http.MethodGet, "/v1/reminders", r.List, auth)
+	app.Handle(http.MethodPost, "/v1/reminders", r.Create, auth)
+	app.Handle(http.MethodGet, "/v1/reminders/:id", r.Retrieve, auth)
+	app.Handle(http.MethodPut, "/v1/reminders/:id", r.Update, auth)
+	app.Handle(http.MethodDelete, "/v1/reminders/:id", r.Delete, auth)
+	app.Handle(http.MethodPost, "/v1/reminders/:id/notify", r.Notify, auth)
+
+	p := participantAPI{bot: b}
+	app.Handle(http.MethodGet, "/v1/participants", p.List, auth)
+	app.Handle(http.MethodPost, "/v1/participants", p.Create, auth)
+	app.Handle(http.MethodGet, "/v1/participants/:name", p.Retrieve, auth)
+	app.Handle(http.MethodPut, "/v1/participants/:name", p.Update, auth)
+	app.Handle(http.MethodDelete, "/v1/participants/:name", p.Delete, auth)
+
+	m := messageAPI{bot: b}
+	app.Handle(http.MethodPost, "/v1/messages", m.Send, auth)
+
+	return app
+}
+
+// health reports that the bot is up, e.g. to a load balancer.
+func (b *Bot) health(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	status := struct {
+		Status string `json:"status"`
+	}{
+		Status: "ok",
+	}
+
+	return web.Respond(ctx, w, status, http.StatusOK)
+}
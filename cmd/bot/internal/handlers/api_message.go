@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/web"
+)
+
+// messageAPI sends messages to the team chat through the HTTP API, e.g. from
+// CI.
+type messageAPI struct {
+	bot *Bot
+}
+
+// NewMessage is an ad-hoc message to the team chat.
+type NewMessage struct {
+	Text string `json:"text"`
+}
+
+// Send queues the message for delivery to the team chat, it is delivered
+// the same way as the reminds.
+func (h *messageAPI) Send(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.messageAPI.Send")
+	defer span.End()
+
+	var nm NewMessage
+	if err := web.Decode(r, &nm); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(nm.Text) == "" {
+		return web.NewRequestError(errors.New("text is required"), http.StatusBadRequest)
+	}
+
+	msg := outbox.NewMessage{
+		ChatID: h.bot.chat.id,
+		Text:   nm.Text,
+	}
+
+	if err := h.bot.queue.Push(ctx, msg); err != nil {
+		return errors.Wrap(err, "queueing message")
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusAccepted)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/web"
+	"github.com/tmowka/telegram-reminder-bot/internal/storage"
+)
+
+// participantAPI manages the participants through the HTTP API. Participants
+// are addressed by name, matched the same way as in the chat commands.
+type participantAPI struct {
+	bot *Bot
+}
+
+func (h *participantAPI) List(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.participantAPI.List")
+	defer span.End()
+
+	participants, err := h.bot.storage.Participant.List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting participants")
+	}
+	if participants == nil {
+		participants = []participant.Participant{}
+	}
+
+	return web.Respond(ctx, w, participants, http.StatusOK)
+}
+
+func (h *participantAPI) Retrieve(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.participantAPI.Retrieve")
+	defer span.End()
+
+	p, err := h.get(ctx, params["name"])
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, p, http.StatusOK)
+}
+
+func (h *participantAPI) Create(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.participantAPI.Create")
+	defer span.End()
+
+	var np participant.NewParticipant
+	if err := web.Decode(r, &np); err != nil {
+		return err
+	}
+
+	np.Name = strings.TrimSpace(np.Name)
+	if np.Name == "" {
+		return web.NewRequestError(errors.New("name is required"), http.StatusBadRequest)
+	}
+
+	switch _, err := h.bot.findParticipant(ctx, np.Name); {
+	case err == nil:
+		return web.NewRequestError(errors.Errorf("%s is already a participant", np.Name), http.StatusConflict)
+	case err != participant.ErrNotFound:
+		return err
+	}
+
+	p, err := h.bot.storage.Participant.CreateOrUpdate(ctx, np, h.bot.clock.Now())
+	if err != nil {
+		return errors.Wrapf(err, "adding participant %s", np.Name)
+	}
+
+	return web.Respond(ctx, w, p, http.StatusCreated)
+}
+
+// Update changes the given fields of a participant all at once.
+func (h *participantAPI) Update(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.participantAPI.Update")
+	defer span.End()
+
+	var up participant.UpdateParticipant
+	if err := web.Decode(r, &up); err != nil {
+		return err
+	}
+
+	p, err := h.get(ctx, params["name"])
+	if err != nil {
+		return err
+	}
+
+	var groups []string
+	if up.Groups != nil {
+		if groups, err = participant.ParseGroups(*up.Groups); err != nil {
+			return web.NewRequestError(err, http.StatusBadRequest)
+		}
+	}
+
+	if a := up.Away; a != nil && (a.From == nil) != (a.Until == nil) {
+		return web.NewRequestError(errors.New("away needs both from and until, or neither to clear it"), http.StatusBadRequest)
+	}
+	if a := up.Away; a != nil && a.From != nil && !a.Until.After(*a.From) {
+		return web.NewRequestError(errors.New("away until must be after from"), http.StatusBadRequest)
+	}
+
+	if up.Timezone != nil {
+		if _, err := time.LoadLocation(*up.Timezone); err != nil {
+			return web.NewRequestError(errors.Errorf("unknown time zone %q", *up.Timezone), http.StatusBadRequest)
+		}
+	}
+
+	var lead string
+	if up.Lead != nil && *up.Lead != "" {
+		l, err := h.bot.findParticipant(ctx, *up.Lead)
+		switch {
+		case err == participant.ErrNotFound:
+			return web.NewRequestError(errors.Errorf("lead %s is not a participant", *up.Lead), http.StatusBadRequest)
+		case err != nil:
+			return err
+		}
+		if l.ID == p.ID {
+			return web.NewRequestError(errors.New("a participant can't lead themselves"), http.StatusBadRequest)
+		}
+		lead = l.ID
+	}
+
+	now := h.bot.clock.Now()
+	err = h.bot.storage.WithinTx(ctx, func(tx *storage.Storage) error {
+		if up.Groups != nil {
+			if err := tx.Participant.SetGroups(ctx, p.Name, groups, now); err != nil {
+				return err
+			}
+		}
+		if up.Away != nil {
+			if err := tx.Participant.SetAbsence(ctx, p.Name, up.Away.From, up.Away.Until, now); err != nil {
+				return err
+			}
+		}
+		if up.Timezone != nil {
+			if err := tx.Participant.SetTimezone(ctx, p.Name, *up.Timezone, now); err != nil {
+				return err
+			}
+		}
+		if up.Lead != nil {
+			if err := tx.Participant.SetLead(ctx, p.Name, lead, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "updating participant %s", p.Name)
+	}
+
+	if p, err = h.get(ctx, p.Name); err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, p, http.StatusOK)
+}
+
+func (h *participantAPI) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.participantAPI.Delete")
+	defer span.End()
+
+	p, err := h.get(ctx, params["name"])
+	if err != nil {
+		return err
+	}
+
+	if err := h.bot.storage.Participant.DeleteByName(ctx, p.Name); err != nil {
+		return errors.Wrapf(err, "removing participant %s", p.Name)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// get returns the participant with the given name.
+func (h *participantAPI) get(ctx context.Context, name string) (*participant.Participant, error) {
+	p, err := h.bot.findParticipant(ctx, name)
+	switch {
+	case err == participant.ErrNotFound:
+		return nil, web.NewRequestError(errors.Errorf("%s is not a participant", name), http.StatusNotFound)
+	case err != nil:
+		return nil, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/web"
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+)
+
+// reminderAPI manages the reminders through the HTTP API. The team reminder,
+// the one managed by the chat commands, has the ID reminder.DefaultID.
+type reminderAPI struct {
+	bot *Bot
+}
+
+func (h *reminderAPI) List(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.reminderAPI.List")
+	defer span.End()
+
+	schedules, err := h.bot.storage.Reminder.List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting reminders")
+	}
+	if schedules == nil {
+		schedules = []reminder.Schedule{}
+	}
+
+	return web.Respond(ctx, w, schedules, http.StatusOK)
+}
+
+func (h *reminderAPI) Retrieve(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.reminderAPI.Retrieve")
+	defer span.End()
+
+	sch, err := h.get(ctx, params["id"])
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, sch, http.StatusOK)
+}
+
+func (h *reminderAPI) Create(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.reminderAPI.Create")
+	defer span.End()
+
+	var nr reminder.NewReminder
+	if err := web.Decode(r, &nr); err != nil {
+		return err
+	}
+
+	sch := reminder.Schedule{
+		ID:             uuid.New().String(),
+		RemindTime:     nr.RemindTime,
+		WeekdaysToSkip: nr.WeekdaysToSkip,
+		Message:        nr.Message,
+		Groups:         nr.Groups,
+		Escalation:     nr.Escalation,
+	}
+
+	if err := h.save(ctx, &sch); err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, sch, http.StatusCreated)
+}
+
+func (h *reminderAPI) Update(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.reminderAPI.Update")
+	defer span.End()
+
+	var ur reminder.UpdateReminder
+	if err := web.Decode(r, &ur); err != nil {
+		return err
+	}
+
+	sch, err := h.get(ctx, params["id"])
+	if err != nil {
+		return err
+	}
+
+	if ur.RemindTime != nil {
+		sch.RemindTime = *ur.RemindTime
+	}
+	if ur.WeekdaysToSkip != nil {
+		sch.WeekdaysToSkip = *ur.WeekdaysToSkip
+	}
+	if ur.Message != nil {
+		sch.Message = *ur.Message
+	}
+	if ur.Groups != nil {
+		sch.Groups = *ur.Groups
+	}
+	if ur.Escalation != nil {
+		sch.Escalation = *ur.Escalation
+	}
+
+	if err := h.save(ctx, sch); err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, sch, http.StatusOK)
+}
+
+// Delete removes a reminder. The team reminder can't be deleted, it is
+// stopped in the chat instead.
+func (h *reminderAPI) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.reminderAPI.Delete")
+	defer span.End()
+
+	id := params["id"]
+	if id == reminder.DefaultID {
+		return web.NewRequestError(errors.New("The team reminder can't be deleted, stop it with /stop"), http.StatusBadRequest)
+	}
+
+	if _, err := h.get(ctx, id); err != nil {
+		return err
+	}
+
+	if err := h.bot.storage.Reminder.Delete(ctx, id); err != nil {
+		return errors.Wrapf(err, "deleting reminder %s", id)
+	}
+
+	h.sync(ctx)
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Notify records a remind of the reminder due right away. Like a scheduled
+// one, it is delivered and escalated by the leader, whichever replica
+// serves the request.
+func (h *reminderAPI) Notify(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.reminderAPI.Notify")
+	defer span.End()
+
+	sch, err := h.get(ctx, params["id"])
+	if err != nil {
+		return err
+	}
+
+	now := h.bot.clock.Now()
+	if err := h.bot.storage.Occurrence.Create(ctx, sch.ID, now, now); err != nil {
+		return errors.Wrapf(err, "recording remind of reminder %s", sch.ID)
+	}
+
+	// Delivered right away if this replica leads, within resumeInterval
+	// otherwise.
+	h.bot.wakeResume()
+
+	return web.Respond(ctx, w, nil, http.StatusAccepted)
+}
+
+// get returns the reminder with the given ID, the team one exists even if it
+// was never configured.
+func (h *reminderAPI) get(ctx context.Context, id string) (*reminder.Schedule, error) {
+	sch, err := h.bot.scheduleOf(ctx, id)
+	switch {
+	case err == reminder.ErrNotFound:
+		return nil, web.NewRequestError(err, http.StatusNotFound)
+	case err != nil:
+		return nil, errors.Wrapf(err, "getting reminder %s", id)
+	}
+
+	return sch, nil
+}
+
+// save validates and stores the reminder, and applies it right away.
+func (h *reminderAPI) save(ctx context.Context, sch *reminder.Schedule) error {
+	if err := validateSchedule(sch); err != nil {
+		return web.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	if err := h.bot.storage.Reminder.Save(ctx, *sch, h.bot.clock.Now()); err != nil {
+		return errors.Wrapf(err, "saving reminder %s", sch.ID)
+	}
+
+	saved, err := h.bot.storage.Reminder.Get(ctx, sch.ID)
+	if err != nil {
+		return errors.Wrapf(err, "getting reminder %s", sch.ID)
+	}
+	*sch = *saved
+
+	h.sync(ctx)
+
+	return nil
+}
+
+// sync applies the change to the reminders of this replica, the other ones
+// pick it up within syncInterval.
+func (h *reminderAPI) sync(ctx context.Context) {
+	if err := h.bot.sync(ctx); err != nil {
+		log.Println("handlers.reminderAPI.sync : error :", err)
+	}
+}
+
+// validateSchedule checks the fields of a schedule set through the API and
+// normalizes the groups.
+func validateSchedule(sch *reminder.Schedule) error {
+	if _, _, err := reminder.ParseRemindTime(sch.RemindTime); err != nil {
+		return err
+	}
+	if _, err := reminder.ParseWeekdays(sch.WeekdaysToSkip); err != nil {
+		return err
+	}
+	if _, err := reminder.ParseEscalation(sch.Escalation); err != nil {
+		return err
+	}
+
+	groups, err := participant.ParseGroups(sch.Groups)
+	if err != nil {
+		return err
+	}
+	sch.Groups = participant.FormatGroups(groups)
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestNotify validates a remind triggered through the API is recorded and
+// then delivered by the leader like a scheduled one, once however often it
+// is triggered.
+func TestNotify(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	srv := httptest.NewServer(e.bot.API(log.New(ioutil.Discard, "", 0), "secret"))
+	defer srv.Close()
+
+	post := func(path string) int {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+path, nil)
+		if err != nil {
+			t.Fatalf("creating request: %s", err)
+		}
+		req.Header.Set("Authorization", "Bearer secret")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to call %s : %s.", tests.Failed, path, err)
+		}
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	t.Log("Given a remind of the team reminder triggered through the API.")
+	{
+		e.command(t, "/addparticipant @bob")
+		n := len(e.tg.Sent())
+
+		if code := post("/v1/reminders/00000000-0000-0000-0000-000000000000/notify"); code != http.StatusNotFound {
+			t.Fatalf("\t%s\tShould not find an unknown reminder : got %d.", tests.Failed, code)
+		}
+		t.Logf("\t%s\tShould not find an unknown reminder.", tests.Success)
+
+		// The clock stands still, so both calls notify the remind due at the
+		// same moment.
+		for i := 0; i < 2; i++ {
+			if code := post("/v1/reminders/" + reminder.DefaultID + "/notify"); code != http.StatusAccepted {
+				t.Fatalf("\t%s\tShould accept the remind : got %d.", tests.Failed, code)
+			}
+		}
+		t.Logf("\t%s\tShould accept the remind.", tests.Success)
+
+		sent := e.waitSent(t, n+1)
+		if !strings.Contains(sent[n], "@bob") {
+			t.Fatalf("\t%s\tShould deliver the remind to the participants : got %q.", tests.Failed, sent[n])
+		}
+		time.Sleep(100 * time.Millisecond)
+		if sent := e.tg.Sent(); len(sent) != n+1 {
+			t.Fatalf("\t%s\tShould deliver the remind once : got %q.", tests.Failed, sent[n:])
+		}
+		t.Logf("\t%s\tShould deliver the remind once.", tests.Success)
+	}
+}
@@ -4,57 +4,227 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"go.opencensus.io/trace"
 	tb "gopkg.in/tucnak/telebot.v2"
 
+	"github.com/tmowka/telegram-reminder-bot/internal/completion"
 	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
 	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
 	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/scheduler"
+	"github.com/tmowka/telegram-reminder-bot/internal/storage"
+	"github.com/tmowka/telegram-reminder-bot/internal/webhook"
 )
 
 const DATE_TIME_LAYOUT = "2 Jan 2006 15:04"
 
+const defaultRemindMessage = "Fill in project server, please!"
+
 type Bot struct {
-	db       *sqlx.DB
-	chat     *chat
-	telebot  *tb.Bot
-	reminder *reminder.Reminder
+	storage   *storage.Storage
+	clock     clock.Clock
+	chat      *chat
+	fanout    []string // Addresses reminds are sent to besides the chat.
+	telebot   *tb.Bot
+	queue     *outbox.Queue
+	webhooks  *webhook.Dispatcher
+	scheduler *scheduler.Scheduler
+	reminder  *reminder.Reminder
+	checker   completion.Checker
+
+	othersMu sync.Mutex
+	others   map[string]*reminder.Reminder // Running reminders other than the team one, by ID.
+
+	resumeWake chan struct{} // Wakes resumeLoop up, e.g. for a remind recorded through the API.
+
+	settings []Setting
+	imports  imports
+
+	shutdown context.CancelFunc // Stops the election and the sync loop.
+	wg       sync.WaitGroup     // Background goroutines running until shutdown.
+}
+
+// Shutdown stops the reminders and the background work of the bot, and
+// waits for it to return. The lease of a leading replica is released, so
+// another one takes over right away. Commands should no longer be served.
+func (b *Bot) Shutdown() {
+	b.shutdown()
+	b.wg.Wait()
+
+	if err := b.reminder.Stop(); err != nil {
+		log.Println("handlers.Bot.Shutdown : error :", err)
+	}
+
+	b.othersMu.Lock()
+	defer b.othersMu.Unlock()
+
+	for id, r := range b.others {
+		if err := r.Stop(); err != nil {
+			log.Printf("handlers.Bot.Shutdown : error : reminder %s : %v", id, err)
+		}
+		delete(b.others, id)
+	}
 }
 
 type chat struct {
 	id string
 }
 
-func (c *chat) Recipient() string {
-	return c.id
+func (b *Bot) send(msg string) {
+	b.sendTo(b.chat.id, msg)
 }
 
-func (b *Bot) send(msg string) {
-	if _, err := b.telebot.Send(b.chat, msg); err != nil {
-		err = errors.Wrap(err, "error sending telebot message")
+// sendTo queues a message to any chat, e.g. a direct one with a user.
+func (b *Bot) sendTo(chatID string, msg string) {
+	nm := outbox.NewMessage{
+		ChatID: chatID,
+		Text:   msg,
+	}
+
+	if err := b.queue.Push(context.Background(), nm); err != nil {
+		err = errors.Wrap(err, "error queueing telebot message")
 		log.Println("handlers.Bot.send : error :", err)
 		return
 	}
 
-	log.Println("handlers.Bot.send : msg :", msg)
+	log.Println("handlers.Bot.send : queued :", msg)
+}
+
+// schedule returns the team reminder schedule, or an empty one if it was
+// never configured.
+func (b *Bot) schedule(ctx context.Context) (*reminder.Schedule, error) {
+	sch, err := b.storage.Reminder.Get(ctx, reminder.DefaultID)
+	if err == reminder.ErrNotFound {
+		return &reminder.Schedule{ID: reminder.DefaultID}, nil
+	}
+
+	return sch, err
+}
+
+// reload applies the stored schedule to the reminder if it is running.
+func (b *Bot) reload(ctx context.Context) error {
+	if !b.reminder.Snapshot().Started {
+		return nil
+	}
+
+	sch, err := b.schedule(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting reminder schedule")
+	}
+
+	if err := b.reminder.SetWeekdaysToSkip(sch.WeekdaysToSkip); err != nil {
+		return errors.Wrap(err, "setting weekdays to skip")
+	}
+
+	switch err := b.reminder.Reschedule(sch.RemindTime); err {
+	case nil:
+	case reminder.ErrNotStarted:
+		// Stopped meanwhile, the schedule is applied on the next start.
+		return nil
+	default:
+		return errors.Wrap(err, "rescheduling reminder")
+	}
+
+	applyPause(b.reminder, sch)
+
+	return nil
+}
+
+// reply answers an interactive command right away in the chat it came
+// from. Unlike send it bypasses the outbox, as a late answer to a command or
+// a button press is of no use.
+func (b *Bot) reply(to *tb.Message, what interface{}, options ...interface{}) *tb.Message {
+	msg, err := b.telebot.Send(to.Chat, what, options...)
+	if err != nil {
+		err = errors.Wrap(err, "error replying")
+		log.Println("handlers.Bot.reply : error :", err)
+		return nil
+	}
+
+	return msg
+}
+
+// location returns the time zone of the reminder.
+func (b *Bot) location() *time.Location {
+	return b.reminder.Snapshot().Location
+}
+
+// isAdmin reports whether user administers the team chat.
+func (b *Bot) isAdmin(user *tb.User) bool {
+	if user == nil {
+		return false
+	}
+
+	id, err := strconv.ParseInt(b.chat.id, 10, 64)
+	if err != nil {
+		log.Println("handlers.Bot.isAdmin : error : chat id is not numeric :", b.chat.id)
+		return false
+	}
+
+	admins, err := b.telebot.AdminsOf(&tb.Chat{ID: id})
+	if err != nil {
+		err = errors.Wrap(err, "error getting chat admins")
+		log.Println("handlers.Bot.isAdmin : error :", err)
+		return false
+	}
+
+	for _, a := range admins {
+		if a.User != nil && a.User.ID == user.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// startReminder starts the reminder at the remind time of sch, or moves it
+// there if it is already running.
+func (b *Bot) startReminder(sch *reminder.Schedule) error {
+	remindChan, err := b.reminder.Start(context.Background(), sch.RemindTime)
+	switch {
+	case err == reminder.ErrStarted:
+		return b.reminder.Reschedule(sch.RemindTime)
+	case err != nil:
+		return err
+	}
+
+	go b.remind(reminder.DefaultID, remindChan)
+
+	return nil
+}
+
+// remind notifies the participants of every remind of the reminder until it
+// is stopped and closes remindChan.
+func (b *Bot) remind(reminderID string, remindChan <-chan time.Time) {
+	for dueAt := range remindChan {
+		b.notify(context.Background(), reminderID, dueAt)
+	}
 }
 
-func (b *Bot) notify(ctx context.Context) {
+// remindText returns the message of the remind of the reminder due at dueAt
+// addressed to the participants of the groups the reminder targets, except
+// for the absent ones and the ones who already completed it, and those
+// participants. The message is empty when everybody completed it.
+func (b *Bot) remindText(ctx context.Context, reminderID string, dueAt time.Time) (string, []participant.Participant) {
 	pCh := make(chan []participant.Participant)
-	rmCh := make(chan string)
+	schCh := make(chan *reminder.Schedule)
 
 	go func() {
 		defer close(pCh)
 
-		participants, err := participant.List(ctx, b.db)
+		participants, err := b.storage.Participant.List(ctx)
 		if err != nil {
 			err = errors.Wrap(err, "error getting participants")
-			log.Println("handlers.Bot.notify : error :", err)
+			log.Println("handlers.Bot.remindText : error :", err)
 
 			participants = []participant.Participant{}
 		}
@@ -63,24 +233,49 @@ func (b *Bot) notify(ctx context.Context) {
 	}()
 
 	go func() {
-		defer close(rmCh)
+		defer close(schCh)
 
-		remindMessage, err := config.GetByName(ctx, b.db, config.RemindMessage)
+		sch, err := b.scheduleOf(ctx, reminderID)
 		if err != nil {
 			err = errors.Wrap(err, "error getting remind message")
-			log.Println("handlers.Bot.notify : error :", err)
+			log.Println("handlers.Bot.remindText : error :", err)
 
-			remindMessage = "Fill in project server, please!"
+			sch = &reminder.Schedule{ID: reminderID}
 		}
 
-		rmCh <- remindMessage.(string)
+		schCh <- sch
 	}()
 
-	participants, remindMessage := <-pCh, <-rmCh
+	participants, sch := <-pCh, <-schCh
+
+	remindMessage := defaultRemindMessage
+	if sch.Message != "" {
+		remindMessage = sch.Message
+	}
+
+	groups, err := participant.ParseGroups(sch.Groups)
+	if err != nil {
+		err = errors.Wrap(err, "error getting remind groups")
+		log.Println("handlers.Bot.remindText : error :", err)
+	}
+
+	now := b.clock.Now()
+
+	var targeted []participant.Participant
+	for _, p := range participants {
+		if p.InAnyGroup(groups) && !p.Away(now) {
+			targeted = append(targeted, p)
+		}
+	}
+
+	mentioned := b.incomplete(ctx, targeted, dueAt)
+	if len(targeted) > 0 && len(mentioned) == 0 {
+		return "", nil
+	}
 
-	pNames := make([]string, len(participants))
-	for i, p := range participants {
-		pNames[i] = p.Name
+	var pNames []string
+	for _, p := range mentioned {
+		pNames = append(pNames, p.Name)
 	}
 
 	var pMessage string
@@ -88,7 +283,7 @@ func (b *Bot) notify(ctx context.Context) {
 		pMessage = strings.Join(pNames, ", ")
 	}
 
-	b.send(fmt.Sprintf("%s\n%s", pMessage, remindMessage))
+	return fmt.Sprintf("%s\n%s", pMessage, remindMessage), mentioned
 }
 
 func (b *Bot) Hello(m *tb.Message) {
@@ -102,118 +297,195 @@ func (b *Bot) Start(m *tb.Message) {
 	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Start")
 	defer span.End()
 
-	rtCh := make(chan string)
-	wdtsCh := make(chan string)
-
-	go func() {
-		defer close(rtCh)
-
-		remindTime, err := config.GetByName(ctx, b.db, config.RemindTime)
-		if err != nil {
-			err = errors.Wrap(err, "error getting remind time")
-			log.Println("handlers.Bot.Start : error :", err)
-		}
-
-		rtCh <- remindTime.(string)
-	}()
-
-	go func() {
-		defer close(wdtsCh)
-
-		weekdaysToSkip, err := config.GetByName(ctx, b.db, config.WeekdaysToSkip)
-		if err != nil {
-			err = errors.Wrap(err, "error getting weekdays to skip")
-			log.Println("handlers.Bot.Start : error :", err)
-		}
-
-		wdtsCh <- weekdaysToSkip.(string)
-	}()
-
-	remindTime, weekdaysToSkip := <-rtCh, <-wdtsCh
-
-	if err := b.reminder.SetWeekdaysToSkip(weekdaysToSkip); err != nil {
-		err = errors.Wrap(err, "error setting weekdays to skip")
+	sch, err := b.schedule(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting reminder schedule")
 		log.Println("handlers.Bot.Start : error :", err)
+		return
 	}
 
-	remindChan, err := b.reminder.Start(remindTime)
-	if err != nil {
-		err = errors.Wrap(err, "error starting reminder")
+	// Saved first, so that a sync running meanwhile doesn't undo the start.
+	if err := b.storage.Config.Save(ctx, config.BotStarted, true, b.clock.Now()); err != nil {
 		log.Println("handlers.Bot.Start : error :", err)
 		return
 	}
 
-	go func() {
-		for range remindChan {
-			b.notify(ctx)
-		}
-	}()
+	if err := b.reminder.SetWeekdaysToSkip(sch.WeekdaysToSkip); err != nil {
+		err = errors.Wrap(err, "error setting weekdays to skip")
+		log.Println("handlers.Bot.Start : error :", err)
+	}
 
-	if err := config.Save(ctx, b.db, config.BotStarted, true, time.Now()); err != nil {
+	if err := b.startReminder(sch); err != nil {
+		err = errors.Wrap(err, "error starting reminder")
 		log.Println("handlers.Bot.Start : error :", err)
 		return
 	}
+
+	applyPause(b.reminder, sch)
 }
 
 func (b *Bot) Stop(m *tb.Message) {
 	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Stop")
 	defer span.End()
 
-	if err := b.reminder.Stop(); err != nil {
-		err = errors.Wrap(err, "error stopping reminder")
+	// Saved first, so that a sync running meanwhile doesn't undo the stop.
+	if err := b.storage.Config.Save(ctx, config.BotStarted, false, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving config")
 		log.Println("handlers.Bot.Stop : error :", err)
 		return
 	}
 
-	if err := config.Save(ctx, b.db, config.BotStarted, false, time.Now()); err != nil {
-		err = errors.Wrap(err, "error saving config")
+	if err := b.reminder.Stop(); err != nil {
+		err = errors.Wrap(err, "error stopping reminder")
 		log.Println("handlers.Bot.Stop : error :", err)
 		return
 	}
 }
 
+// AddParticipant adds participants separated by commas, e.g. "alice, bob",
+// or mentions separated by spaces, e.g. "@alice @bob". Names are matched
+// case-insensitively and with or without "@", so nobody is added twice.
 func (b *Bot) AddParticipant(m *tb.Message) {
 	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.AddParticipant")
 	defer span.End()
 
-	p := participant.NewParticipant{
-		Name: strings.TrimSpace(m.Payload),
+	if !b.canChangeParticipants(ctx, m) {
+		return
+	}
+
+	names := participant.SplitNames(m.Payload)
+	if len(names) == 0 {
+		b.reply(m, `Set the participants to add, e.g. "@alice, @bob"`)
+		return
 	}
 
-	if _, err := participant.CreateOrUpdate(ctx, b.db, p, time.Now()); err != nil {
-		err = errors.Wrap(err, "error adding participants")
+	current, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting participants")
 		log.Println("handlers.Bot.AddParticipant : error :", err)
 		return
 	}
+
+	var added, existing []string
+	for _, name := range names {
+		if p, ok := participant.Find(current, name); ok {
+			existing = append(existing, p.Name)
+			continue
+		}
+
+		np := participant.NewParticipant{
+			Name: name,
+		}
+
+		if _, err := b.storage.Participant.CreateOrUpdate(ctx, np, b.clock.Now()); err != nil {
+			err = errors.Wrap(err, "error adding participants")
+			log.Println("handlers.Bot.AddParticipant : error :", err)
+			return
+		}
+		added = append(added, name)
+	}
+
+	b.reply(m, joinLines(
+		listLine("Added", added),
+		listLine("Already participants", existing),
+	))
 }
 
+// RemoveParticipant removes participants listed the same way as for
+// AddParticipant.
 func (b *Bot) RemoveParticipant(m *tb.Message) {
 	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.RemoveParticipant")
 	defer span.End()
 
-	if err := participant.DeleteByName(ctx, b.db, m.Payload); err != nil {
-		err = errors.Wrap(err, "error removing participants")
+	if !b.canChangeParticipants(ctx, m) {
+		return
+	}
+
+	names := participant.SplitNames(m.Payload)
+	if len(names) == 0 {
+		b.reply(m, `Set the participants to remove, e.g. "@alice, @bob"`)
+		return
+	}
+
+	current, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting participants")
 		log.Println("handlers.Bot.RemoveParticipant : error :", err)
 		return
 	}
+
+	var removed, unknown []string
+	for _, name := range names {
+		p, ok := participant.Find(current, name)
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+
+		if err := b.storage.Participant.DeleteByName(ctx, p.Name); err != nil {
+			err = errors.Wrap(err, "error removing participants")
+			log.Println("handlers.Bot.RemoveParticipant : error :", err)
+			return
+		}
+		removed = append(removed, p.Name)
+	}
+
+	b.reply(m, joinLines(
+		listLine("Removed", removed),
+		listLine("Not participants", unknown),
+	))
 }
 
 func (b *Bot) SetRemindTime(m *tb.Message) {
 	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetRemindTime")
 	defer span.End()
 
-	if err := config.Save(ctx, b.db, config.RemindTime, m.Payload, time.Now()); err != nil {
+	rawRemindTime := strings.TrimSpace(m.Payload)
+
+	hour, min, err := reminder.ParseRemindTime(rawRemindTime)
+	if err != nil {
+		b.reply(m, fmt.Sprintf(`Can't set the remind time %q: %v, expected e.g. "10:00"`, rawRemindTime, err))
+		return
+	}
+
+	sch, err := b.schedule(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting reminder schedule")
+		log.Println("handlers.Bot.SetRemindTime : error :", err)
+		return
+	}
+
+	sch.RemindTime = fmt.Sprintf("%02d:%02d", hour, min)
+
+	if err := b.storage.Reminder.Save(ctx, *sch, b.clock.Now()); err != nil {
 		err = errors.Wrap(err, "error saving remind time")
 		log.Println("handlers.Bot.SetRemindTime : error :", err)
 		return
 	}
+
+	if err := b.reload(ctx); err != nil {
+		err = errors.Wrap(err, "error reloading reminder")
+		log.Println("handlers.Bot.SetRemindTime : error :", err)
+		return
+	}
+
+	b.reply(m, "Remind time is set to "+sch.RemindTime)
 }
 
 func (b *Bot) SetRemindMessage(m *tb.Message) {
 	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetRemindMessage")
 	defer span.End()
 
-	if err := config.Save(ctx, b.db, config.RemindMessage, m.Payload, time.Now()); err != nil {
+	sch, err := b.schedule(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting reminder schedule")
+		log.Println("handlers.Bot.SetRemindMessage : error :", err)
+		return
+	}
+
+	sch.Message = m.Payload
+
+	if err := b.storage.Reminder.Save(ctx, *sch, b.clock.Now()); err != nil {
 		err = errors.Wrap(err, "error saving remind message")
 		log.Println("handlers.Bot.SetRemindMessage : error :", err)
 		return
@@ -224,29 +496,77 @@ func (b *Bot) SetWeekdaysToSkip(m *tb.Message) {
 	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetWeekdaysToSkip")
 	defer span.End()
 
-	if err := config.Save(ctx, b.db, config.WeekdaysToSkip, m.Payload, time.Now()); err != nil {
+	days, err := reminder.ParseWeekdays(m.Payload)
+	if err != nil {
+		b.reply(m, err.Error())
+		return
+	}
+
+	b.setWeekdaysToSkip(ctx, m, days)
+}
+
+// Workdays sets the weekdays to remind on, the rest are skipped.
+func (b *Bot) Workdays(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Workdays")
+	defer span.End()
+
+	days, err := reminder.ParseWeekdays(m.Payload)
+	if err == nil && len(days) == 0 {
+		err = errors.New(`set weekdays to remind on, e.g. "mon-fri"`)
+	}
+	if err != nil {
+		b.reply(m, err.Error())
+		return
+	}
+
+	b.setWeekdaysToSkip(ctx, m, reminder.InvertWeekdays(days))
+}
+
+func (b *Bot) setWeekdaysToSkip(ctx context.Context, m *tb.Message, days map[time.Weekday]struct{}) {
+	sch, err := b.schedule(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting reminder schedule")
+		log.Println("handlers.Bot.SetWeekdaysToSkip : error :", err)
+		return
+	}
+
+	sch.WeekdaysToSkip = reminder.FormatWeekdays(days)
+
+	if err := b.storage.Reminder.Save(ctx, *sch, b.clock.Now()); err != nil {
 		err = errors.Wrap(err, "error saving weekdays to skip")
 		log.Println("handlers.Bot.SetWeekdaysToSkip : error :", err)
 		return
 	}
 
-	if err := b.reminder.SetWeekdaysToSkip(m.Payload); err != nil {
+	if err := b.reminder.SetWeekdaysToSkip(sch.WeekdaysToSkip); err != nil {
 		err = errors.Wrap(err, "error setting weekdays to skip")
 		log.Println("handlers.Bot.SetWeekdaysToSkip : error :", err)
 		return
 	}
+
+	if len(days) == 0 {
+		b.reply(m, "No weekdays are skipped")
+		return
+	}
+
+	b.reply(m, "Weekdays to skip: "+strings.Join(reminder.WeekdayNames(days), ", "))
 }
 
 func (b *Bot) Info(m *tb.Message) {
 	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Info")
 	defer span.End()
 
-	var weekdaysToSkip []string
-	for key := range b.reminder.WeekdaysToSkip {
-		weekdaysToSkip = append(weekdaysToSkip, key.String())
+	snap := b.reminder.Snapshot()
+
+	weekdaysToSkip := reminder.WeekdayNames(snap.WeekdaysToSkip)
+
+	var holidays []string
+	for date := range snap.Holidays {
+		holidays = append(holidays, date)
 	}
+	sort.Strings(holidays)
 
-	participantList, err := participant.List(ctx, b.db)
+	participantList, err := b.storage.Participant.List(ctx)
 	if err != nil {
 		err = errors.Wrap(err, "error getting participant list")
 		log.Println("handlers.Bot.Info : error :", err)
@@ -258,18 +578,42 @@ func (b *Bot) Info(m *tb.Message) {
 		participants[i] = p.Name
 	}
 
+	groups := formatGroupMembers(groupMembers(participantList))
+
+	var remindGroupList []string
+	var escalation []reminder.EscalationStep
+	if sch, err := b.schedule(ctx); err != nil {
+		err = errors.Wrap(err, "error getting reminder schedule")
+		log.Println("handlers.Bot.Info : error :", err)
+	} else {
+		remindGroupList, _ = participant.ParseGroups(sch.Groups)
+		escalation, _ = reminder.ParseEscalation(sch.Escalation)
+	}
+
 	msg := fmt.Sprintf(`
 Server time: %s
-Remind time: %s
+Next reminds: %s
 Weekdays to skip: %s
+Holidays: %s
 Participants: %s
+Groups: %s
+Away: %s
+Reminder mentions: %s
+Escalation: %s
 Reminder started: %v
+Paused: %s
 `,
-		time.Now().In(b.reminder.Location).Format(DATE_TIME_LAYOUT),
-		b.reminder.RemindTime.In(b.reminder.Location).Format(DATE_TIME_LAYOUT),
+		b.clock.Now().In(snap.Location).Format(DATE_TIME_LAYOUT),
+		b.upcoming(3),
 		strings.Join(weekdaysToSkip, ", "),
+		strings.Join(holidays, ", "),
 		strings.Join(participants, ", "),
-		b.reminder.Started,
+		strings.Join(groups, "; "),
+		strings.Join(b.absences(participantList), "; "),
+		remindGroups(remindGroupList),
+		formatEscalation(escalation),
+		snap.Started,
+		b.pauseState(snap),
 	)
 
 	b.send(msg)
@@ -284,12 +628,44 @@ func (b *Bot) Help(m *tb.Message) {
 /help - Print list of available commands
 /start - Start reminder with pre-configured remind time
 /stop - Stop reminder
-/addparticipant - Add participant to remind
-/removeparticipant - Remove participant
+/addparticipant - Add participants to remind, e.g. "@alice, @bob"
+/removeparticipant - Remove participants, e.g. "@alice, @bob"
+/participants - Print participants and when they were added
 /setremindtime - Set time of the next remind in format "HH:MM" (default interval is 24h)
 /setremindmessage - Set remind message
-/setweekdaystoskip - Set weekdays to skip in format "0,1,2" (0 - is Sunday)
+/setweekdaystoskip - Set weekdays to skip, e.g. "sat,sun" or "0,6" (0 - is Sunday)
+/workdays - Set weekdays to remind on, e.g. "mon-fri", the rest are skipped
 /info - Print bot configuration and state
+/settimezone - Set time zone of the remind time, e.g. "Europe/Warsaw"
+/config - Print effective settings and where they come from
+/export - Send bot state as a JSON document
+/import - Reply to an exported document to restore it (chat admins only)
+/setup - Configure the reminder step by step with buttons
+/skipnext - Skip the next remind
+/pause - Pause reminds until a date, e.g. "until 2026-11-01"
+/snooze - Delay the next remind, e.g. "2h"
+/resume - Cancel the pause, skip and snooze
+/next - Print the next reminds, 5 unless another number is given
+/setholidays - Set dates to skip in format "2026-12-25,2027-01-01"
+/addtogroup - Add participants to a group, e.g. "qa @alice @bob"
+/removefromgroup - Remove participants from a group, e.g. "qa @alice"
+/groups - Print participant groups and their members
+/setremindgroups - Set groups to mention, e.g. "backend, qa", or everyone if none
+/away - Mark a participant as absent, e.g. "@bob 2026-11-03..2026-11-14"
+/back - End the absence of a participant, e.g. "@bob"
+/autosync - Offer new chat members to join and remove leavers, "on" or "off" (chat admins only)
+/joinme - Join the reminder yourself
+/leaveme - Leave the reminder yourself
+/mystatus - Print how the reminder treats you
+/mytimezone - Set your time zone for /mystatus and /remindme, e.g. "Europe/Warsaw", or print it
+/lockparticipants - Let only chat admins change participants, "on" or "off" (chat admins only)
+/done - Confirm you did what the reminder asked for
+/escalation - Set steps taken for who doesn't confirm, e.g. "2h dm, 4h lead, 8h chat -1001234", or "off"
+/setlead - Set whom reminds of a participant are escalated to, e.g. "@bob @alice"
+/webhookfailures - List the latest failed webhook deliveries (chat admins only)
+/remindme - Remind you once, e.g. "in 2h check the deploy" or "tomorrow 10:00 call vendor"
+/myreminders - Print your reminders
+/cancel - Cancel one of your reminders by its ID, e.g. "3f2a9c1d"
 `
 
 	b.send(msg)
@@ -0,0 +1,398 @@
+package handlers_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/cmd/bot/internal/handlers"
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/bot"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/storage"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// chatID is the team chat of the bot under test.
+const chatID = -100
+
+// env is a bot running against the fake Telegram server and a fake clock.
+type env struct {
+	tg      *tests.Telegram
+	clk     *clock.Fake
+	st      *storage.Storage
+	bot     *handlers.Bot
+	telebot *tb.Bot
+	chat    *tb.Chat
+	user    *tb.User
+}
+
+// newEnv starts a bot on the in-memory storage at Monday 2 March 2020 09:00
+// UTC and returns it with a function that stops it.
+func newEnv(t *testing.T) (*env, func()) {
+	t.Helper()
+
+	return newEnvOn(t, storage.Memory)
+}
+
+// newEnvOn starts a bot like newEnv, on an empty storage of the backend.
+func newEnvOn(t *testing.T, backend string) (*env, func()) {
+	t.Helper()
+
+	st, stTeardown := tests.NewStorage(t, backend)
+
+	tg := tests.NewTelegram()
+
+	telebot, err := bot.Create(bot.Config{Token: tests.Token, URL: tg.URL()})
+	if err != nil {
+		tg.Close()
+		stTeardown()
+		t.Fatalf("creating telebot: %s", err)
+	}
+
+	clk := clock.NewFake(time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC))
+
+	b, err := handlers.Telebot(handlers.Config{
+		Storage:  st,
+		Telebot:  telebot,
+		Clock:    clk,
+		ChatID:   "-100",
+		Location: "UTC",
+	})
+	if err != nil {
+		tg.Close()
+		stTeardown()
+		t.Fatalf("creating bot: %s", err)
+	}
+
+	go telebot.Start()
+
+	e := env{
+		tg:      tg,
+		clk:     clk,
+		st:      st,
+		bot:     b,
+		telebot: telebot,
+		chat:    &tb.Chat{ID: chatID, Type: tb.ChatGroup},
+		user:    &tb.User{ID: 7, Username: "alice", FirstName: "Alice"},
+	}
+
+	teardown := func() {
+		telebot.Stop()
+		b.Shutdown()
+		tg.Close()
+		stTeardown()
+	}
+
+	return &e, teardown
+}
+
+// replica starts another bot on the storage, clock and Telegram server of
+// the env, as a second replica would, and returns it with a function that
+// stops it. It doesn't poll for updates, so commands go to the env's bot.
+func (e *env) replica(t *testing.T) (*handlers.Bot, func()) {
+	t.Helper()
+
+	telebot, err := bot.Create(bot.Config{Token: tests.Token, URL: e.tg.URL()})
+	if err != nil {
+		t.Fatalf("creating telebot: %s", err)
+	}
+
+	b, err := handlers.Telebot(handlers.Config{
+		Storage:  e.st,
+		Telebot:  telebot,
+		Clock:    e.clk,
+		ChatID:   "-100",
+		Location: "UTC",
+	})
+	if err != nil {
+		t.Fatalf("creating bot: %s", err)
+	}
+
+	return b, b.Shutdown
+}
+
+// onBackends runs the test against a bot on every storage backend.
+func onBackends(t *testing.T, test func(t *testing.T, backend string)) {
+	for _, backend := range tests.Backends {
+		backend := backend
+		t.Run(backend, func(t *testing.T) {
+			test(t, backend)
+		})
+	}
+}
+
+// command sends a command from the user to the team chat and returns the
+// message the bot answered with. Messages sent through the outbox are rate
+// limited per chat, so the clock is moved on until the answer comes.
+func (e *env) command(t *testing.T, text string) string {
+	t.Helper()
+
+	return e.reply(t, 0, text)
+}
+
+// reply sends a text from the user to the team chat in reply to a message
+// and returns the message the bot answered with.
+func (e *env) reply(t *testing.T, replyTo int, text string) string {
+	t.Helper()
+
+	n := len(e.tg.Sent())
+	e.tg.Reply(e.chat, e.user, replyTo, text)
+
+	var sent []string
+	answered := tests.Wait(2*time.Second, func() bool {
+		if sent = e.tg.Sent(); len(sent) > n {
+			return true
+		}
+		e.clk.Advance(100 * time.Millisecond)
+		return false
+	})
+	if !answered {
+		t.Fatalf("\t%s\tShould answer %q.", tests.Failed, text)
+	}
+
+	return sent[n]
+}
+
+// waitSent waits until n messages are sent, moving the clock on for the
+// rate limit of the outbox, and returns them.
+func (e *env) waitSent(t *testing.T, n int) []string {
+	t.Helper()
+
+	var sent []string
+	if !tests.Wait(2*time.Second, func() bool {
+		if sent = e.tg.Sent(); len(sent) >= n {
+			return true
+		}
+		e.clk.Advance(100 * time.Millisecond)
+		return false
+	}) {
+		t.Fatalf("\t%s\tShould send %d messages : got %q.", tests.Failed, n, sent)
+	}
+
+	return sent
+}
+
+// TestCommands validates the basic commands change the stored state and
+// answer in the chat.
+func TestCommands(t *testing.T) {
+	onBackends(t, testCommands)
+}
+
+func testCommands(t *testing.T, backend string) {
+	e, teardown := newEnvOn(t, backend)
+	defer teardown()
+
+	ctx := context.Background()
+
+	t.Log("Given a bot serving a team chat.")
+	{
+		if got := e.command(t, "/addparticipant @bob, @carol"); !strings.Contains(got, "bob") || !strings.Contains(got, "carol") {
+			t.Fatalf("\t%s\tShould confirm the added participants : got %q.", tests.Failed, got)
+		}
+		ps, err := e.st.Participant.List(ctx)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list participants : %s.", tests.Failed, err)
+		}
+		if len(ps) != 2 {
+			t.Fatalf("\t%s\tShould store the participants : got %d.", tests.Failed, len(ps))
+		}
+		t.Logf("\t%s\tShould add participants.", tests.Success)
+
+		if got := e.command(t, "/setremindtime 25:00"); !strings.Contains(got, "Can't set the remind time") {
+			t.Fatalf("\t%s\tShould reject an invalid remind time : got %q.", tests.Failed, got)
+		}
+		if _, err := e.st.Reminder.Get(ctx, reminder.DefaultID); err != reminder.ErrNotFound {
+			t.Fatalf("\t%s\tShould not store an invalid remind time : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould reject an invalid remind time.", tests.Success)
+
+		if got := e.command(t, "/setremindtime 10:30"); got != "Remind time is set to 10:30" {
+			t.Fatalf("\t%s\tShould confirm the remind time : got %q.", tests.Failed, got)
+		}
+		sch, err := e.st.Reminder.Get(ctx, reminder.DefaultID)
+		if err != nil {
+			t.Fatalf("\t%s\tShould store the schedule : %s.", tests.Failed, err)
+		}
+		if sch.RemindTime != "10:30" {
+			t.Fatalf("\t%s\tShould store the remind time : got %q.", tests.Failed, sch.RemindTime)
+		}
+		t.Logf("\t%s\tShould set the remind time.", tests.Success)
+
+		e.tg.Command(e.chat, e.user, "/start")
+		started := func() bool {
+			v, err := e.st.Config.GetByName(ctx, config.BotStarted)
+			return err == nil && v.(bool)
+		}
+		if !tests.Wait(time.Second, started) {
+			t.Fatalf("\t%s\tShould store that the reminder is started.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould start the reminder.", tests.Success)
+
+		info := e.command(t, "/info")
+		for _, want := range []string{
+			"Next reminds: Mon 2 Mar 2020 10:30",
+			"Participants: @bob, @carol",
+			"Reminder started: true",
+		} {
+			if !strings.Contains(info, want) {
+				t.Fatalf("\t%s\tShould show %q in the info : got %q.", tests.Failed, want, info)
+			}
+		}
+		t.Logf("\t%s\tShould show the reminder in the info.", tests.Success)
+
+		n := len(e.tg.Sent())
+		tests.Advance(e.clk, 90*time.Minute, time.Minute)
+		sent := e.tg.WaitSent(n+1, time.Second)
+		if len(sent) <= n || !strings.Contains(sent[n], "@bob") || !strings.Contains(sent[n], "@carol") {
+			t.Fatalf("\t%s\tShould remind the participants at the remind time : got %q.", tests.Failed, sent[n:])
+		}
+		t.Logf("\t%s\tShould remind the participants at the remind time.", tests.Success)
+	}
+}
+
+// TestRestart validates starting the reminder again, or stopping and starting
+// it, doesn't send a remind twice.
+func TestRestart(t *testing.T) {
+	onBackends(t, testRestart)
+}
+
+func testRestart(t *testing.T, backend string) {
+	e, teardown := newEnvOn(t, backend)
+	defer teardown()
+
+	ctx := context.Background()
+
+	started := func(want bool) func() bool {
+		return func() bool {
+			v, err := e.st.Config.GetByName(ctx, config.BotStarted)
+			return err == nil && v.(bool) == want
+		}
+	}
+
+	t.Log("Given a reminder started twice, stopped and started again.")
+	{
+		e.command(t, "/addparticipant @bob")
+		e.command(t, "/setremindtime 10:30")
+
+		for _, cmd := range []struct {
+			text string
+			want bool
+		}{
+			{"/start", true},
+			{"/start", true},
+			{"/stop", false},
+			{"/start", true},
+		} {
+			e.tg.Command(e.chat, e.user, cmd.text)
+			if !tests.Wait(time.Second, started(cmd.want)) {
+				t.Fatalf("\t%s\tShould handle %s.", tests.Failed, cmd.text)
+			}
+			// The config is saved before the reminder is started or stopped.
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		n := len(e.tg.Sent())
+		tests.Advance(e.clk, 90*time.Minute, time.Minute)
+		e.tg.WaitSent(n+1, time.Second)
+		time.Sleep(200 * time.Millisecond)
+
+		sent := e.tg.Sent()[n:]
+		if len(sent) != 1 || !strings.Contains(sent[0], "@bob") {
+			t.Fatalf("\t%s\tShould remind the participants once : got %q.", tests.Failed, sent)
+		}
+		t.Logf("\t%s\tShould remind the participants once.", tests.Success)
+	}
+}
+
+// TestShutdown validates a leading bot gives its lease up on shutdown, so
+// another replica doesn't wait for it to expire.
+func TestShutdown(t *testing.T) {
+	onBackends(t, testShutdown)
+}
+
+func testShutdown(t *testing.T, backend string) {
+	e, teardown := newEnvOn(t, backend)
+	defer teardown()
+
+	ctx := context.Background()
+
+	t.Log("Given a bot leading the replicas.")
+	{
+		// Answers are delivered by the leader only.
+		e.command(t, "/addparticipant @bob")
+
+		now := e.clk.Now()
+		held, err := e.st.Lease.Acquire(ctx, "scheduler", "other", now.Add(time.Minute), now)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to acquire the lease : %s.", tests.Failed, err)
+		}
+		if held {
+			t.Fatalf("\t%s\tShould hold the lease while running.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould hold the lease while running.", tests.Success)
+
+		e.bot.Shutdown()
+
+		held, err = e.st.Lease.Acquire(ctx, "scheduler", "other", now.Add(time.Minute), now)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to acquire the lease : %s.", tests.Failed, err)
+		}
+		if !held {
+			t.Fatalf("\t%s\tShould release the lease on shutdown.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould release the lease on shutdown.", tests.Success)
+	}
+}
+
+// TestSetWeekdaysToSkip validates the weekdays to skip replace the ones set
+// before rather than add to them.
+func TestSetWeekdaysToSkip(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	ctx := context.Background()
+
+	stored := func() string {
+		sch, err := e.st.Reminder.Get(ctx, reminder.DefaultID)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to get the schedule : %s.", tests.Failed, err)
+		}
+		return sch.WeekdaysToSkip
+	}
+
+	t.Log("Given weekdays to skip set more than once.")
+	{
+		for _, tc := range []struct {
+			cmd    string
+			answer string
+			stored string
+		}{
+			{"/setweekdaystoskip sat,sun", "Weekdays to skip: Saturday, Sunday", "6,0"},
+			{"/setweekdaystoskip fri-mon", "Weekdays to skip: Monday, Friday, Saturday, Sunday", "1,5,6,0"},
+			{"/setweekdaystoskip wed", "Weekdays to skip: Wednesday", "3"},
+			{"/workdays mon-fri", "Weekdays to skip: Saturday, Sunday", "6,0"},
+			{"/setweekdaystoskip", "No weekdays are skipped", ""},
+		} {
+			if got := e.command(t, tc.cmd); got != tc.answer {
+				t.Fatalf("\t%s\tShould answer %q : got %q, want %q.", tests.Failed, tc.cmd, got, tc.answer)
+			}
+			if got := stored(); got != tc.stored {
+				t.Fatalf("\t%s\tShould store the weekdays of %q only : got %q, want %q.", tests.Failed, tc.cmd, got, tc.stored)
+			}
+			t.Logf("\t%s\tShould replace the weekdays to skip on %q.", tests.Success, tc.cmd)
+		}
+
+		if got := e.command(t, "/setweekdaystoskip funday"); !strings.HasPrefix(got, `invalid weekday "funday"`) {
+			t.Fatalf("\t%s\tShould refuse an invalid weekday : got %q.", tests.Failed, got)
+		}
+		if got := stored(); got != "" {
+			t.Fatalf("\t%s\tShould keep the weekdays on an invalid one : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould keep the weekdays on an invalid one.", tests.Success)
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+)
+
+const (
+	defaultUpcoming = 5
+	maxUpcoming     = 30
+)
+
+// Next prints the next moments the reminder fires at, 5 unless another
+// number is given.
+func (b *Bot) Next(m *tb.Message) {
+	_, span := trace.StartSpan(context.Background(), "handlers.Bot.Next")
+	defer span.End()
+
+	n := defaultUpcoming
+	if raw := strings.TrimSpace(m.Payload); raw != "" {
+		var err error
+		n, err = strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxUpcoming {
+			b.reply(m, fmt.Sprintf("Set the number of reminds to show from 1 to %d", maxUpcoming))
+			return
+		}
+	}
+
+	times, err := b.reminder.Upcoming(n)
+	if err != nil {
+		b.reply(m, fmt.Sprintf("No reminds ahead: %v", err))
+		return
+	}
+
+	lines := make([]string, len(times))
+	for i, t := range times {
+		lines[i] = b.formatRemind(t)
+	}
+
+	b.reply(m, "Next reminds:\n"+strings.Join(lines, "\n"))
+}
+
+// SetHolidays replaces the dates on which the reminder doesn't fire.
+func (b *Bot) SetHolidays(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetHolidays")
+	defer span.End()
+
+	holidays := strings.TrimSpace(m.Payload)
+
+	if err := config.Validate(config.Holidays, holidays); err != nil {
+		b.reply(m, err.Error())
+		return
+	}
+
+	if err := b.storage.Config.Save(ctx, config.Holidays, holidays, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving holidays")
+		log.Println("handlers.Bot.SetHolidays : error :", err)
+		return
+	}
+
+	if err := b.reminder.SetHolidays(holidays); err != nil {
+		err = errors.Wrap(err, "error setting holidays")
+		log.Println("handlers.Bot.SetHolidays : error :", err)
+		return
+	}
+
+	if holidays == "" {
+		b.reply(m, "Holidays are cleared")
+		return
+	}
+
+	b.reply(m, "Holidays are set to "+holidays)
+}
+
+// upcoming describes the next few reminds for /info.
+func (b *Bot) upcoming(n int) string {
+	times, err := b.reminder.Upcoming(n)
+	if err != nil {
+		return err.Error()
+	}
+
+	s := make([]string, len(times))
+	for i, t := range times {
+		s[i] = b.formatRemind(t)
+	}
+
+	return strings.Join(s, ", ")
+}
+
+func (b *Bot) formatRemind(t time.Time) string {
+	return formatRemindIn(t, b.location())
+}
+
+// formatRemindIn formats the time of a remind in another time zone, e.g. the
+// one of a participant.
+func formatRemindIn(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("Mon " + DATE_TIME_LAYOUT)
+}
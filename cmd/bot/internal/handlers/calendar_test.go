@@ -0,0 +1,77 @@
+package handlers_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestCalendar validates /next lists the upcoming reminds, and holidays set
+// with /setholidays are left out of them and of /info.
+func TestCalendar(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	ctx := context.Background()
+
+	t.Log("Given a reminder reminding at 10:00 every day.")
+	{
+		startReminding(t, e)
+
+		want := "Next reminds:\nMon 2 Mar 2020 10:00\nTue 3 Mar 2020 10:00\nWed 4 Mar 2020 10:00\nThu 5 Mar 2020 10:00\nFri 6 Mar 2020 10:00"
+		if got := e.command(t, "/next"); got != want {
+			t.Fatalf("\t%s\tShould list the next 5 reminds : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould list the next 5 reminds by default.", tests.Success)
+
+		got := e.command(t, "/next 30")
+		if lines := strings.Split(got, "\n"); len(lines) != 31 || lines[30] != "Tue 31 Mar 2020 10:00" {
+			t.Fatalf("\t%s\tShould list up to 30 reminds : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould list up to 30 reminds.", tests.Success)
+
+		for _, n := range []string{"0", "31", "many"} {
+			if got := e.command(t, "/next "+n); got != "Set the number of reminds to show from 1 to 30" {
+				t.Fatalf("\t%s\tShould refuse to list %s reminds : got %q.", tests.Failed, n, got)
+			}
+		}
+		t.Logf("\t%s\tShould refuse to list fewer than 1 or more than 30 reminds.", tests.Success)
+
+		if got := e.command(t, "/setholidays 2020-03-03, 2020-03-04"); got != "Holidays are set to 2020-03-03, 2020-03-04" {
+			t.Fatalf("\t%s\tShould set the holidays : got %q.", tests.Failed, got)
+		}
+		if got := e.command(t, "/next 3"); got != "Next reminds:\nMon 2 Mar 2020 10:00\nThu 5 Mar 2020 10:00\nFri 6 Mar 2020 10:00" {
+			t.Fatalf("\t%s\tShould leave the holidays out : got %q.", tests.Failed, got)
+		}
+		info := e.command(t, "/info")
+		for _, want := range []string{
+			"Next reminds: Mon 2 Mar 2020 10:00, Thu 5 Mar 2020 10:00, Fri 6 Mar 2020 10:00",
+			"Holidays: 2020-03-03, 2020-03-04",
+		} {
+			if !strings.Contains(info, want) {
+				t.Fatalf("\t%s\tShould show %q in the info : got %q.", tests.Failed, want, info)
+			}
+		}
+		t.Logf("\t%s\tShould leave the holidays out of the upcoming reminds.", tests.Success)
+
+		if got := e.command(t, "/setholidays 2020-03-05, tomorrow"); !strings.Contains(got, `invalid date "tomorrow"`) {
+			t.Fatalf("\t%s\tShould refuse an invalid date : got %q.", tests.Failed, got)
+		}
+		v, err := e.st.Config.GetByName(ctx, config.Holidays)
+		if err != nil || v.(string) != "2020-03-03, 2020-03-04" {
+			t.Fatalf("\t%s\tShould keep the holidays on an invalid date : got %v, %v.", tests.Failed, v, err)
+		}
+		t.Logf("\t%s\tShould refuse an invalid date.", tests.Success)
+
+		if got := e.command(t, "/setholidays"); got != "Holidays are cleared" {
+			t.Fatalf("\t%s\tShould clear the holidays : got %q.", tests.Failed, got)
+		}
+		if got := e.command(t, "/next 2"); got != "Next reminds:\nMon 2 Mar 2020 10:00\nTue 3 Mar 2020 10:00" {
+			t.Fatalf("\t%s\tShould remind on the former holidays : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould clear the holidays.", tests.Success)
+	}
+}
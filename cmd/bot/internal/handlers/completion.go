@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+)
+
+// incomplete returns the participants who didn't complete the task of the
+// remind due at dueAt according to the completion checker, all of them if
+// there is no checker. Participants the checker fails on are kept, missing
+// a remind is worse than getting a needless one.
+func (b *Bot) incomplete(ctx context.Context, participants []participant.Participant, dueAt time.Time) []participant.Participant {
+	if b.checker == nil || len(participants) == 0 {
+		return participants
+	}
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Bot.incomplete")
+	defer span.End()
+
+	completed := make([]bool, len(participants))
+
+	var wg sync.WaitGroup
+	for i, p := range participants {
+		wg.Add(1)
+		go func(i int, p participant.Participant) {
+			defer wg.Done()
+			completed[i] = b.completed(ctx, p, dueAt)
+		}(i, p)
+	}
+	wg.Wait()
+
+	var left []participant.Participant
+	for i, p := range participants {
+		if !completed[i] {
+			left = append(left, p)
+		}
+	}
+
+	return left
+}
+
+// completed reports whether the checker confirms p completed the task of
+// the remind due at dueAt.
+func (b *Bot) completed(ctx context.Context, p participant.Participant, dueAt time.Time) bool {
+	if b.checker == nil {
+		return false
+	}
+
+	done, err := b.checker.Completed(ctx, p, dueAt.In(b.location()))
+	if err != nil {
+		err = errors.Wrap(err, "error checking completion")
+		log.Println("handlers.Bot.completed : error :", err)
+		return false
+	}
+
+	return done
+}
@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/webhook"
+)
+
+// maxEscalationAge is how long after a remind was due its escalation is
+// still resumed by a new leader.
+const maxEscalationAge = 7 * 24 * time.Hour
+
+// Done acknowledges the reminds of every reminder the sender was mentioned
+// in, which stops their escalation.
+func (b *Bot) Done(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Done")
+	defer span.End()
+
+	p, err := b.self(ctx, m.Sender)
+	switch {
+	case err == participant.ErrNotFound:
+		b.reply(m, "You are not a participant, join with /joinme")
+		return
+	case err != nil:
+		log.Println("handlers.Bot.Done : error :", err)
+		return
+	}
+
+	schedules, err := b.storage.Reminder.List(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting reminders")
+		log.Println("handlers.Bot.Done : error :", err)
+		return
+	}
+
+	ids := []string{reminder.DefaultID}
+	for _, sch := range schedules {
+		if sch.ID != reminder.DefaultID {
+			ids = append(ids, sch.ID)
+		}
+	}
+
+	var n int
+	for _, id := range ids {
+		acked, err := b.storage.Ack.Acknowledge(ctx, id, p.ID, b.clock.Now())
+		if err != nil {
+			err = errors.Wrap(err, "error acknowledging remind")
+			log.Println("handlers.Bot.Done : error :", err)
+			return
+		}
+		if acked == 0 {
+			continue
+		}
+		n += acked
+
+		b.emit(ctx, webhook.EventAcknowledged, acknowledgedData{
+			ReminderID:     id,
+			Participant:    p.Name,
+			AcknowledgedAt: b.clock.Now().UTC(),
+			By:             acknowledgedByDone,
+		})
+	}
+
+	if n == 0 {
+		b.reply(m, "Nothing to confirm")
+		return
+	}
+
+	b.reply(m, fmt.Sprintf("Thanks, %s!", p.Name))
+}
+
+// Escalation sets the steps taken for participants who don't acknowledge a
+// remind, e.g. "2h dm, 4h lead, 8h chat -1001234", or turns the escalation
+// off. Without steps it prints the current ones.
+func (b *Bot) Escalation(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Escalation")
+	defer span.End()
+
+	sch, err := b.schedule(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting reminder schedule")
+		log.Println("handlers.Bot.Escalation : error :", err)
+		return
+	}
+
+	raw := strings.TrimSpace(m.Payload)
+	if raw == "" {
+		steps, _ := reminder.ParseEscalation(sch.Escalation)
+		b.reply(m, "Escalation: "+formatEscalation(steps))
+		return
+	}
+	if strings.EqualFold(raw, "off") {
+		raw = ""
+	}
+
+	steps, err := reminder.ParseEscalation(raw)
+	if err != nil {
+		b.reply(m, err.Error())
+		return
+	}
+
+	sch.Escalation = reminder.FormatEscalation(steps)
+
+	if err := b.storage.Reminder.Save(ctx, *sch, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving escalation")
+		log.Println("handlers.Bot.Escalation : error :", err)
+		return
+	}
+
+	b.reply(m, "Escalation: "+formatEscalation(steps))
+}
+
+// SetLead sets the participant reminds of another one are escalated to,
+// e.g. "@bob @alice" for Alice leading Bob. Without a lead it clears it.
+func (b *Bot) SetLead(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetLead")
+	defer span.End()
+
+	fields := strings.Fields(m.Payload)
+	if len(fields) < 1 || len(fields) > 2 {
+		b.reply(m, `Set the participant and the lead, e.g. "@bob @alice"`)
+		return
+	}
+
+	participants, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting participants")
+		log.Println("handlers.Bot.SetLead : error :", err)
+		return
+	}
+
+	p, ok := participant.Find(participants, fields[0])
+	if !ok {
+		b.reply(m, fmt.Sprintf("%s is not a participant", fields[0]))
+		return
+	}
+
+	var lead participant.Participant
+	if len(fields) == 2 {
+		if lead, ok = participant.Find(participants, fields[1]); !ok {
+			b.reply(m, fmt.Sprintf("%s is not a participant", fields[1]))
+			return
+		}
+		if lead.ID == p.ID {
+			b.reply(m, "A participant can't lead themselves")
+			return
+		}
+	}
+
+	if err := b.storage.Participant.SetLead(ctx, p.Name, lead.ID, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving lead")
+		log.Println("handlers.Bot.SetLead : error :", err)
+		return
+	}
+
+	if lead.Name == "" {
+		b.reply(m, fmt.Sprintf("%s has no lead", p.Name))
+		return
+	}
+
+	b.reply(m, fmt.Sprintf("Reminds of %s are escalated to %s", p.Name, lead.Name))
+}
+
+// resumeEscalations schedules the escalation of the reminds that are not
+// acknowledged yet, e.g. after another replica took the lead.
+func (b *Bot) resumeEscalations(ctx context.Context) {
+	acks, err := b.storage.Ack.ListOpen(ctx, b.clock.Now().Add(-maxEscalationAge))
+	if err != nil {
+		err = errors.Wrap(err, "error getting open acknowledgements")
+		log.Println("handlers.Bot.resumeEscalations : error :", err)
+		return
+	}
+
+	for _, a := range acks {
+		b.scheduler.Set(escalationJobID(a.ReminderID, a.DueAt), b.clock.Now(), b.escalationJob(a.ReminderID, a.DueAt))
+	}
+}
+
+// scheduleEscalation sets the scheduler job escalating the remind due at
+// dueAt at the moment of its first step.
+func (b *Bot) scheduleEscalation(ctx context.Context, reminderID string, dueAt time.Time) {
+	sch, err := b.scheduleOf(ctx, reminderID)
+	if err != nil {
+		err = errors.Wrap(err, "error getting reminder schedule")
+		log.Println("handlers.Bot.scheduleEscalation : error :", err)
+		return
+	}
+
+	steps, err := reminder.ParseEscalation(sch.Escalation)
+	if err != nil || len(steps) == 0 {
+		return
+	}
+
+	b.scheduler.Set(escalationJobID(reminderID, dueAt), dueAt.Add(steps[0].Delay), b.escalationJob(reminderID, dueAt))
+}
+
+func (b *Bot) escalationJob(reminderID string, dueAt time.Time) func(time.Time) {
+	return func(now time.Time) {
+		b.escalate(context.Background(), reminderID, dueAt, now)
+	}
+}
+
+// escalate takes the escalation steps that are due for the participants who
+// didn't acknowledge the remind due at dueAt, nor completed it according to
+// the completion checker, and schedules itself for the next step if there
+// is one. The steps taken are recorded, so none is taken twice.
+func (b *Bot) escalate(ctx context.Context, reminderID string, dueAt time.Time, now time.Time) {
+	ctx, span := trace.StartSpan(ctx, "handlers.Bot.escalate")
+	defer span.End()
+
+	sch, err := b.scheduleOf(ctx, reminderID)
+	if err != nil {
+		err = errors.Wrap(err, "error getting reminder schedule")
+		log.Println("handlers.Bot.escalate : error :", err)
+		return
+	}
+
+	steps, err := reminder.ParseEscalation(sch.Escalation)
+	if err != nil {
+		err = errors.Wrap(err, "error getting escalation")
+		log.Println("handlers.Bot.escalate : error :", err)
+		return
+	}
+
+	acks, err := b.storage.Ack.ListOpen(ctx, dueAt)
+	if err != nil {
+		err = errors.Wrap(err, "error getting open acknowledgements")
+		log.Println("handlers.Bot.escalate : error :", err)
+		return
+	}
+
+	participants, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting participants")
+		log.Println("handlers.Bot.escalate : error :", err)
+		return
+	}
+
+	byID := make(map[string]participant.Participant, len(participants))
+	for _, p := range participants {
+		byID[p.ID] = p
+	}
+
+	var next time.Time
+	for _, a := range acks {
+		if a.ReminderID != reminderID || !a.DueAt.Equal(dueAt) {
+			continue
+		}
+
+		p, ok := byID[a.ParticipantID]
+		if !ok {
+			// Removed from participants meanwhile.
+			continue
+		}
+
+		taken := a.Escalated
+		if taken < len(steps) && !dueAt.Add(steps[taken].Delay).After(now) && b.completed(ctx, p, dueAt) {
+			// Completed without confirming it.
+			if _, err := b.storage.Ack.Acknowledge(ctx, reminderID, p.ID, b.clock.Now()); err != nil {
+				err = errors.Wrap(err, "error acknowledging remind")
+				log.Println("handlers.Bot.escalate : error :", err)
+				continue
+			}
+			b.emit(ctx, webhook.EventAcknowledged, acknowledgedData{
+				ReminderID:     reminderID,
+				Participant:    p.Name,
+				AcknowledgedAt: b.clock.Now().UTC(),
+				By:             acknowledgedByCheck,
+			})
+			continue
+		}
+
+		for taken < len(steps) && !dueAt.Add(steps[taken].Delay).After(now) {
+			step := steps[taken]
+			b.escalateStep(p, participants, step, dueAt)
+			taken++
+
+			data := escalatedData{
+				ReminderID:  reminderID,
+				DueAt:       dueAt.UTC(),
+				Participant: p.Name,
+				Step:        taken,
+				Delay:       int(step.Delay / time.Minute),
+				Action:      step.Action,
+				ChatID:      step.ChatID,
+			}
+			if step.Action == reminder.EscalateLead {
+				if lead, ok := byID[p.LeadID]; ok {
+					data.Lead = lead.Name
+				}
+			}
+			b.emit(ctx, webhook.EventEscalated, data)
+		}
+
+		if taken != a.Escalated {
+			if err := b.storage.Ack.SetEscalated(ctx, reminderID, dueAt, a.ParticipantID, taken, b.clock.Now()); err != nil {
+				err = errors.Wrap(err, "error recording escalation")
+				log.Println("handlers.Bot.escalate : error :", err)
+			}
+		}
+
+		if taken < len(steps) {
+			at := dueAt.Add(steps[taken].Delay)
+			if next.IsZero() || at.Before(next) {
+				next = at
+			}
+		}
+	}
+
+	if !next.IsZero() {
+		b.scheduler.Set(escalationJobID(reminderID, dueAt), next, b.escalationJob(reminderID, dueAt))
+	}
+}
+
+// escalateStep takes a single escalation step for p, who didn't acknowledge
+// the remind due at dueAt.
+func (b *Bot) escalateStep(p participant.Participant, participants []participant.Participant, step reminder.EscalationStep, dueAt time.Time) {
+	remind := b.formatRemind(dueAt)
+
+	switch step.Action {
+	case reminder.EscalateDM:
+		if p.UserID == 0 {
+			// The bot can't message people it doesn't know the account of.
+			b.send(fmt.Sprintf("%s, please confirm the remind of %s with /done", p.Name, remind))
+			return
+		}
+		b.sendTo(strconv.FormatInt(p.UserID, 10), fmt.Sprintf("You haven't confirmed the remind of %s yet, reply /done in the team chat once you did", remind))
+
+	case reminder.EscalateLead:
+		lead, ok := participant.FindByID(participants, p.LeadID)
+		if p.LeadID == "" || !ok {
+			log.Printf("handlers.Bot.escalateStep : error : %s has no lead to escalate to", p.Name)
+			return
+		}
+
+		msg := fmt.Sprintf("%s hasn't confirmed the remind of %s", p.Name, remind)
+		if lead.UserID == 0 {
+			b.send(fmt.Sprintf("%s, %s", lead.Name, msg))
+			return
+		}
+		b.sendTo(strconv.FormatInt(lead.UserID, 10), msg)
+
+	case reminder.EscalateChat:
+		b.sendTo(step.ChatID, fmt.Sprintf("%s hasn't confirmed the remind of %s", p.Name, remind))
+	}
+}
+
+func escalationJobID(reminderID string, dueAt time.Time) string {
+	return "escalation:" + reminderID + ":" + dueAt.UTC().Format(time.RFC3339)
+}
+
+// formatEscalation describes the escalation steps.
+func formatEscalation(steps []reminder.EscalationStep) string {
+	if len(steps) == 0 {
+		return "off"
+	}
+
+	return reminder.FormatEscalation(steps)
+}
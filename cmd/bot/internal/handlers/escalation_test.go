@@ -0,0 +1,210 @@
+package handlers_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// Users of the escalation tests, besides alice who configures the bot.
+var (
+	bob   = &tb.User{ID: 9, Username: "bob", FirstName: "Bob"}
+	carol = &tb.User{ID: 11, Username: "carol", FirstName: "Carol"}
+)
+
+// sentTo returns the texts of the messages sent to the chat.
+func (e *env) sentTo(chatID string) []string {
+	var texts []string
+	for _, r := range e.tg.Requests("sendMessage") {
+		if r.Params["chat_id"] == chatID {
+			texts = append(texts, r.Params["text"])
+		}
+	}
+
+	return texts
+}
+
+// waitSentTo waits until n messages are sent to the chat, moving the clock
+// on for the rate limit of the outbox, and returns them.
+func (e *env) waitSentTo(t *testing.T, chatID string, n int) []string {
+	t.Helper()
+
+	var sent []string
+	if !tests.Wait(2*time.Second, func() bool {
+		if sent = e.sentTo(chatID); len(sent) >= n {
+			return true
+		}
+		e.clk.Advance(100 * time.Millisecond)
+		return false
+	}) {
+		t.Fatalf("\t%s\tShould send %d messages to %s : got %q.", tests.Failed, n, chatID, sent)
+	}
+
+	return sent
+}
+
+// as sends a command from another user to the team chat and returns the
+// message the bot answered with.
+func (e *env) as(t *testing.T, user *tb.User, text string) string {
+	t.Helper()
+
+	n := len(e.tg.Sent())
+	e.tg.Command(e.chat, user, text)
+
+	return e.waitSent(t, n+1)[n]
+}
+
+// startEscalating adds @bob led by @carol and @dave, who has no lead nor a
+// linked account, sets the escalation steps and starts the reminder at
+// 10:00.
+func startEscalating(t *testing.T, e *env, steps string) {
+	t.Helper()
+
+	ctx := context.Background()
+	now := e.clk.Now()
+
+	for _, np := range []participant.NewParticipant{{Name: "@bob"}, {Name: "@carol"}, {Name: "@dave"}} {
+		if _, err := e.st.Participant.CreateOrUpdate(ctx, np, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to add a participant : %s.", tests.Failed, err)
+		}
+	}
+	for _, u := range []*tb.User{bob, carol} {
+		if err := e.st.Participant.Link(ctx, "@"+u.Username, int64(u.ID), "@"+u.Username, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to link a participant : %s.", tests.Failed, err)
+		}
+	}
+
+	if got := e.command(t, "/setlead @bob @carol"); got != "Reminds of @bob are escalated to @carol" {
+		t.Fatalf("\t%s\tShould set the lead : got %q.", tests.Failed, got)
+	}
+	if got := e.command(t, "/escalation "+steps); got != "Escalation: "+steps {
+		t.Fatalf("\t%s\tShould set the escalation : got %q.", tests.Failed, got)
+	}
+	e.command(t, "/setremindtime 10:00")
+
+	e.tg.Command(e.chat, e.user, "/start")
+	started := func() bool {
+		v, err := e.st.Config.GetByName(ctx, config.BotStarted)
+		return err == nil && v.(bool)
+	}
+	if !tests.Wait(time.Second, started) {
+		t.Fatalf("\t%s\tShould start the reminder.", tests.Failed)
+	}
+}
+
+// TestEscalation validates the escalation steps are taken at their delays
+// for the participants who didn't confirm the remind, and stop once they do.
+func TestEscalation(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	ten := time.Date(2020, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	t.Log("Given a remind escalated to the participant, their lead and another chat.")
+	{
+		startEscalating(t, e, "30m dm, 1h lead, 2h chat -200")
+
+		n := len(e.sentTo("-100"))
+		tests.Advance(e.clk, ten.Sub(e.clk.Now()), time.Minute)
+		if sent := e.waitSentTo(t, "-100", n+1); !strings.Contains(sent[n], "@bob, @carol, @dave") {
+			t.Fatalf("\t%s\tShould remind the participants : got %q.", tests.Failed, sent[n])
+		}
+		// The escalation is scheduled after the remind is queued.
+		time.Sleep(50 * time.Millisecond)
+
+		if got := e.as(t, carol, "/done"); got != "Thanks, @carol!" {
+			t.Fatalf("\t%s\tShould confirm the remind : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould confirm the remind on /done.", tests.Success)
+
+		n = len(e.sentTo("-100"))
+		tests.Advance(e.clk, ten.Add(30*time.Minute).Sub(e.clk.Now()), time.Minute)
+		if dm := e.waitSentTo(t, "9", 1); !strings.HasPrefix(dm[0], "You haven't confirmed the remind of Mon 2 Mar 2020 10:00") {
+			t.Fatalf("\t%s\tShould message the participant : got %q.", tests.Failed, dm[0])
+		}
+		t.Logf("\t%s\tShould message the participant after the first delay.", tests.Success)
+
+		if sent := e.waitSentTo(t, "-100", n+1); sent[n] != "@dave, please confirm the remind of Mon 2 Mar 2020 10:00 with /done" {
+			t.Fatalf("\t%s\tShould mention the participant without an account in the chat : got %q.", tests.Failed, sent[n])
+		}
+		t.Logf("\t%s\tShould fall back to the chat for a participant without an account.", tests.Success)
+
+		if dm := e.sentTo("11"); len(dm) != 0 {
+			t.Fatalf("\t%s\tShould not escalate a confirmed remind : got %q.", tests.Failed, dm)
+		}
+		t.Logf("\t%s\tShould not escalate a confirmed remind.", tests.Success)
+
+		n = len(e.sentTo("-100"))
+		tests.Advance(e.clk, ten.Add(time.Hour).Sub(e.clk.Now()), time.Minute)
+		if dm := e.waitSentTo(t, "11", 1); dm[0] != "@bob hasn't confirmed the remind of Mon 2 Mar 2020 10:00" {
+			t.Fatalf("\t%s\tShould message the lead : got %q.", tests.Failed, dm[0])
+		}
+		t.Logf("\t%s\tShould message the lead after the second delay.", tests.Success)
+
+		time.Sleep(100 * time.Millisecond)
+		if sent := e.sentTo("-100"); len(sent) != n {
+			t.Fatalf("\t%s\tShould skip the lead step for a participant without a lead : got %q.", tests.Failed, sent[n:])
+		}
+		t.Logf("\t%s\tShould skip the lead step for a participant without a lead.", tests.Success)
+
+		if got := e.as(t, bob, "/done"); got != "Thanks, @bob!" {
+			t.Fatalf("\t%s\tShould confirm the remind : got %q.", tests.Failed, got)
+		}
+
+		tests.Advance(e.clk, ten.Add(2*time.Hour).Sub(e.clk.Now()), time.Minute)
+		e.waitSentTo(t, "-200", 1)
+		time.Sleep(100 * time.Millisecond)
+		if sent := e.sentTo("-200"); len(sent) != 1 || sent[0] != "@dave hasn't confirmed the remind of Mon 2 Mar 2020 10:00" {
+			t.Fatalf("\t%s\tShould post only the participant who didn't confirm : got %q.", tests.Failed, sent)
+		}
+		if dm := e.sentTo("9"); len(dm) != 1 {
+			t.Fatalf("\t%s\tShould stop escalating after /done : got %q.", tests.Failed, dm)
+		}
+		t.Logf("\t%s\tShould stop escalating a remind confirmed with /done.", tests.Success)
+	}
+}
+
+// TestEscalationResume validates a replica taking the lead over takes the
+// escalation steps that became due while nobody led.
+func TestEscalationResume(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	ten := time.Date(2020, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	t.Log("Given a leader shut down before escalating a remind.")
+	{
+		startEscalating(t, e, "30m dm")
+
+		n := len(e.sentTo("-100"))
+		tests.Advance(e.clk, ten.Sub(e.clk.Now()), time.Minute)
+		e.waitSentTo(t, "-100", n+1)
+		time.Sleep(50 * time.Millisecond)
+
+		e.bot.Shutdown()
+		tests.Advance(e.clk, ten.Add(45*time.Minute).Sub(e.clk.Now()), time.Minute)
+		time.Sleep(50 * time.Millisecond)
+		if dm := e.sentTo("9"); len(dm) != 0 {
+			t.Fatalf("\t%s\tShould not escalate without a leader : got %q.", tests.Failed, dm)
+		}
+
+		_, stop := e.replica(t)
+		defer stop()
+
+		if dm := e.waitSentTo(t, "9", 1); !strings.HasPrefix(dm[0], "You haven't confirmed the remind of Mon 2 Mar 2020 10:00") {
+			t.Fatalf("\t%s\tShould message the participant : got %q.", tests.Failed, dm[0])
+		}
+		time.Sleep(100 * time.Millisecond)
+		if dm := e.sentTo("9"); len(dm) != 1 {
+			t.Fatalf("\t%s\tShould take the step once : got %q.", tests.Failed, dm)
+		}
+		t.Logf("\t%s\tShould take the due step on taking the lead over.", tests.Success)
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+)
+
+// AddToGroup adds participants to a group, e.g. "qa @alice @bob".
+func (b *Bot) AddToGroup(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.AddToGroup")
+	defer span.End()
+
+	b.changeGroup(ctx, m, "Added to %s", func(groups []string, group string) []string {
+		for _, g := range groups {
+			if g == group {
+				return groups
+			}
+		}
+		return append(groups, group)
+	})
+}
+
+// RemoveFromGroup removes participants from a group, e.g. "qa @alice".
+func (b *Bot) RemoveFromGroup(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.RemoveFromGroup")
+	defer span.End()
+
+	b.changeGroup(ctx, m, "Removed from %s", func(groups []string, group string) []string {
+		var kept []string
+		for _, g := range groups {
+			if g != group {
+				kept = append(kept, g)
+			}
+		}
+		return kept
+	})
+}
+
+// changeGroup applies change to the groups of every participant named in
+// the payload after the group name, and replies with done formatted with the
+// group, followed by the changed participants.
+func (b *Bot) changeGroup(ctx context.Context, m *tb.Message, done string, change func(groups []string, group string) []string) {
+	fields := strings.Fields(m.Payload)
+	if len(fields) < 2 {
+		b.reply(m, `Set the group and the participants, e.g. "qa @alice @bob"`)
+		return
+	}
+
+	groups, err := participant.ParseGroups(fields[0])
+	if err != nil || len(groups) != 1 {
+		b.reply(m, fmt.Sprintf("Invalid group %q, use letters, digits, - and _", fields[0]))
+		return
+	}
+	group := groups[0]
+
+	participants, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting participants")
+		log.Println("handlers.Bot.changeGroup : error :", err)
+		return
+	}
+
+	var changed, unknown []string
+	for _, name := range participant.SplitNames(strings.Join(fields[1:], " ")) {
+		p, ok := participant.Find(participants, name)
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+
+		groups := change(p.GroupList(), group)
+		sort.Strings(groups)
+
+		if err := b.storage.Participant.SetGroups(ctx, p.Name, groups, b.clock.Now()); err != nil {
+			err = errors.Wrap(err, "error setting participant groups")
+			log.Println("handlers.Bot.changeGroup : error :", err)
+			return
+		}
+		changed = append(changed, p.Name)
+	}
+
+	b.reply(m, joinLines(
+		listLine(fmt.Sprintf(done, group), changed),
+		listLine("Not participants", unknown),
+	))
+}
+
+// Groups prints the participant groups and their members.
+func (b *Bot) Groups(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Groups")
+	defer span.End()
+
+	participants, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting participants")
+		log.Println("handlers.Bot.Groups : error :", err)
+		return
+	}
+
+	members := groupMembers(participants)
+	if len(members) == 0 {
+		b.reply(m, "No groups yet, add participants to one with /addtogroup")
+		return
+	}
+
+	b.reply(m, "Groups:\n"+strings.Join(formatGroupMembers(members), "\n"))
+}
+
+// SetRemindGroups sets the participant groups the reminder mentions, e.g.
+// "backend, qa". Without groups it mentions everyone.
+func (b *Bot) SetRemindGroups(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetRemindGroups")
+	defer span.End()
+
+	groups, err := participant.ParseGroups(m.Payload)
+	if err != nil {
+		b.reply(m, err.Error())
+		return
+	}
+
+	sch, err := b.schedule(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting reminder schedule")
+		log.Println("handlers.Bot.SetRemindGroups : error :", err)
+		return
+	}
+
+	sch.Groups = participant.FormatGroups(groups)
+
+	if err := b.storage.Reminder.Save(ctx, *sch, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving remind groups")
+		log.Println("handlers.Bot.SetRemindGroups : error :", err)
+		return
+	}
+
+	b.reply(m, "Reminder mentions "+remindGroups(groups))
+}
+
+// groupMembers returns the names of the participants of every group.
+func groupMembers(participants []participant.Participant) map[string][]string {
+	members := make(map[string][]string)
+	for _, p := range participants {
+		for _, g := range p.GroupList() {
+			members[g] = append(members[g], p.Name)
+		}
+	}
+
+	return members
+}
+
+// formatGroupMembers describes every group as "qa: @alice, @bob", sorted by
+// the group name.
+func formatGroupMembers(members map[string][]string) []string {
+	groups := make([]string, 0, len(members))
+	for g := range members {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	lines := make([]string, len(groups))
+	for i, g := range groups {
+		lines[i] = fmt.Sprintf("%s: %s", g, strings.Join(members[g], ", "))
+	}
+
+	return lines
+}
+
+// remindGroups describes whom a reminder targeting groups mentions.
+func remindGroups(groups []string) string {
+	if len(groups) == 0 {
+		return "everyone"
+	}
+
+	return "groups " + strings.Join(groups, ", ")
+}
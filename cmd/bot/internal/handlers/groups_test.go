@@ -0,0 +1,83 @@
+package handlers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestGroups validates participants are put into groups and taken out of
+// them, and a reminder targeting a group mentions its members only.
+func TestGroups(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	t.Log("Given participants in a QA and a backend group.")
+	{
+		startReminding(t, e)
+		e.command(t, "/addparticipant @carol, @dave")
+
+		if got := e.command(t, "/groups"); got != "No groups yet, add participants to one with /addtogroup" {
+			t.Fatalf("\t%s\tShould have no groups : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould have no groups at first.", tests.Success)
+
+		if got := e.command(t, "/addtogroup qa @bob @carol @erin"); got != "Added to qa: @bob, @carol\nNot participants: @erin" {
+			t.Fatalf("\t%s\tShould add the participants to the group : got %q.", tests.Failed, got)
+		}
+		e.command(t, "/addtogroup qa @bob")
+		e.command(t, "/addtogroup backend @dave @carol")
+		if got := e.command(t, "/addtogroup q&a @bob"); got != `Invalid group "q&a", use letters, digits, - and _` {
+			t.Fatalf("\t%s\tShould refuse an invalid group : got %q.", tests.Failed, got)
+		}
+
+		want := "Groups:\nbackend: @carol, @dave\nqa: @bob, @carol"
+		if got := e.command(t, "/groups"); got != want {
+			t.Fatalf("\t%s\tShould list the groups and their members : got %q, want %q.", tests.Failed, got, want)
+		}
+		t.Logf("\t%s\tShould list the groups and their members.", tests.Success)
+
+		if got := e.command(t, "/setremindgroups qa"); got != "Reminder mentions groups qa" {
+			t.Fatalf("\t%s\tShould target the group : got %q.", tests.Failed, got)
+		}
+		info := e.command(t, "/info")
+		for _, want := range []string{"Groups: backend: @carol, @dave; qa: @bob, @carol", "Reminder mentions: groups qa"} {
+			if !strings.Contains(info, want) {
+				t.Fatalf("\t%s\tShould show %q in the info : got %q.", tests.Failed, want, info)
+			}
+		}
+		t.Logf("\t%s\tShould show the groups in the info.", tests.Success)
+
+		n := len(e.sentTo("-100"))
+		if !e.advanceTo(t, day(2, 10, 0)) {
+			t.Fatalf("\t%s\tShould remind the group.", tests.Failed)
+		}
+		if got := e.sentTo("-100")[n]; got != "@bob, @carol\nFill in project server, please!" {
+			t.Fatalf("\t%s\tShould mention the members of the group only : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould mention the members of the group only.", tests.Success)
+
+		if got := e.command(t, "/removefromgroup qa @carol"); got != "Removed from qa: @carol" {
+			t.Fatalf("\t%s\tShould remove the participant from the group : got %q.", tests.Failed, got)
+		}
+		n = len(e.sentTo("-100"))
+		if !e.advanceTo(t, day(3, 10, 0)) {
+			t.Fatalf("\t%s\tShould remind the group.", tests.Failed)
+		}
+		if got := e.sentTo("-100")[n]; got != "@bob\nFill in project server, please!" {
+			t.Fatalf("\t%s\tShould no longer mention a removed member : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould no longer mention a removed member.", tests.Success)
+
+		e.command(t, "/setremindgroups")
+		n = len(e.sentTo("-100"))
+		if !e.advanceTo(t, day(4, 10, 0)) {
+			t.Fatalf("\t%s\tShould remind everyone.", tests.Failed)
+		}
+		if got := e.sentTo("-100")[n]; got != "@bob, @carol, @dave\nFill in project server, please!" {
+			t.Fatalf("\t%s\tShould mention everyone without groups : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould mention everyone once the groups are cleared.", tests.Success)
+	}
+}
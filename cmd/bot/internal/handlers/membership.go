@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+)
+
+var (
+	enrollJoinBtn    = tb.InlineButton{Unique: "enroll_join", Text: "Remind me"}
+	enrollDeclineBtn = tb.InlineButton{Unique: "enroll_decline", Text: "No, thanks"}
+)
+
+// AutoSync turns the sync of participants with the chat membership on or
+// off. Chat admins only.
+func (b *Bot) AutoSync(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.AutoSync")
+	defer span.End()
+
+	if !b.isAdmin(m.Sender) {
+		b.reply(m, "Only chat admins can change the participant sync")
+		return
+	}
+
+	on, ok := parseSwitch(m.Payload)
+	if !ok {
+		b.reply(m, `Set "on" or "off"`)
+		return
+	}
+
+	if err := b.storage.Config.Save(ctx, config.AutoSync, on, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving config")
+		log.Println("handlers.Bot.AutoSync : error :", err)
+		return
+	}
+
+	if on {
+		b.reply(m, "New chat members are offered to join the reminder, and people leaving the chat are removed from participants")
+		return
+	}
+
+	b.reply(m, "Participants are no longer synced with the chat members")
+}
+
+// UserJoined offers the new members of the team chat to join the reminder
+// when the sync is on.
+func (b *Bot) UserJoined(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.UserJoined")
+	defer span.End()
+
+	if !b.teamChat(m.Chat) || !b.autoSync(ctx) {
+		return
+	}
+
+	// Telebot handles a message only once even if several users joined at
+	// once, so all of them are offered here.
+	users := m.UsersJoined
+	if len(users) == 0 && m.UserJoined != nil {
+		users = []tb.User{*m.UserJoined}
+	}
+
+	participants, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting participants")
+		log.Println("handlers.Bot.UserJoined : error :", err)
+		return
+	}
+
+	for _, u := range users {
+		if u.IsBot {
+			continue
+		}
+
+		name := participantName(&u)
+		if _, ok := participant.Find(participants, name); ok {
+			continue
+		}
+		if linked(participants, u.ID) {
+			continue
+		}
+
+		join, decline := enrollJoinBtn, enrollDeclineBtn
+		join.Data = strconv.Itoa(u.ID)
+		decline.Data = join.Data
+
+		text := fmt.Sprintf("Welcome, %s! Should the bot remind you with the rest of the team?", name)
+		b.reply(m, text, &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{join, decline}}})
+	}
+}
+
+// EnrollJoin adds the new member who pressed the button to participants.
+func (b *Bot) EnrollJoin(c *tb.Callback) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.EnrollJoin")
+	defer span.End()
+
+	if !b.enrollee(c) {
+		return
+	}
+
+	p, _, err := b.join(ctx, c.Sender)
+	switch {
+	case err == errNameTaken:
+		b.respond(c, "Somebody else is a participant under your name, ask a chat admin")
+		return
+	case err != nil:
+		log.Println("handlers.Bot.EnrollJoin : error :", err)
+		b.respond(c, "Can't add you, try again")
+		return
+	}
+
+	b.respond(c, "")
+	b.edit(c.Message, fmt.Sprintf("%s joined the reminder", p.Name))
+}
+
+// EnrollDecline closes the offer to join the reminder.
+func (b *Bot) EnrollDecline(c *tb.Callback) {
+	_, span := trace.StartSpan(context.Background(), "handlers.Bot.EnrollDecline")
+	defer span.End()
+
+	if !b.enrollee(c) {
+		return
+	}
+
+	b.respond(c, "")
+	b.edit(c.Message, fmt.Sprintf("%s won't be reminded, use /joinme to join later", participantName(c.Sender)))
+}
+
+// UserLeft removes the member who left the team chat from participants when
+// the sync is on.
+func (b *Bot) UserLeft(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.UserLeft")
+	defer span.End()
+
+	if !b.teamChat(m.Chat) || m.UserLeft == nil || !b.autoSync(ctx) {
+		return
+	}
+
+	p, err := b.self(ctx, m.UserLeft)
+	switch {
+	case err == participant.ErrNotFound:
+		return
+	case err != nil:
+		log.Println("handlers.Bot.UserLeft : error :", err)
+		return
+	}
+
+	if err := b.storage.Participant.DeleteByName(ctx, p.Name); err != nil {
+		err = errors.Wrap(err, "error removing participant")
+		log.Println("handlers.Bot.UserLeft : error :", err)
+		return
+	}
+
+	b.reply(m, fmt.Sprintf("%s left the chat and is no longer reminded", p.Name))
+}
+
+// enrollee reports whether the enrollment button was pressed by the member
+// it was offered to, and answers anybody else.
+func (b *Bot) enrollee(c *tb.Callback) bool {
+	if c.Sender == nil || strconv.Itoa(c.Sender.ID) != c.Data {
+		b.respond(c, "This offer is for somebody else")
+		return false
+	}
+
+	return true
+}
+
+// autoSync reports whether participants are synced with the chat members.
+func (b *Bot) autoSync(ctx context.Context) bool {
+	on, err := b.storage.Config.GetByName(ctx, config.AutoSync)
+	switch {
+	case err == config.ErrNotFound:
+		return false
+	case err != nil:
+		err = errors.Wrap(err, "error getting config")
+		log.Println("handlers.Bot.autoSync : error :", err)
+		return false
+	}
+
+	return on.(bool)
+}
+
+// teamChat reports whether chat is the one the bot reminds.
+func (b *Bot) teamChat(chat *tb.Chat) bool {
+	return chat != nil && strconv.FormatInt(chat.ID, 10) == b.chat.id
+}
+
+// participantName returns the name a Telegram user is listed under: the
+// mention if the user has a username, the full name otherwise.
+func participantName(u *tb.User) string {
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// linked reports whether one of participants is linked to the user ID.
+func linked(participants []participant.Participant, userID int) bool {
+	for _, p := range participants {
+		if p.UserID == int64(userID) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,152 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestMembership validates that with the sync on, new members of the team
+// chat are offered to join and members leaving it are removed from
+// participants, and that nothing happens with the sync off.
+func TestMembership(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	ctx := context.Background()
+
+	dave := &tb.User{ID: 13, Username: "dave", FirstName: "Dave"}
+	erin := &tb.User{ID: 15, Username: "erin", FirstName: "Erin"}
+	robot := &tb.User{ID: 99, Username: "robot", FirstName: "Robot", IsBot: true}
+
+	find := func(name string) (participant.Participant, bool) {
+		ps, err := e.st.Participant.List(ctx)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list participants : %s.", tests.Failed, err)
+		}
+		return participant.Find(ps, name)
+	}
+
+	// quiet reports whether the bot sent nothing for a moment.
+	quiet := func() bool {
+		n := len(e.tg.Sent())
+		time.Sleep(100 * time.Millisecond)
+		return len(e.tg.Sent()) == n
+	}
+
+	// edited waits for the nth edit of a message and returns its text.
+	edited := func(n int) string {
+		var edits []tests.Request
+		if !tests.Wait(time.Second, func() bool {
+			edits = e.tg.Requests("editMessageText")
+			return len(edits) >= n
+		}) {
+			t.Fatalf("\t%s\tShould edit the offer.", tests.Failed)
+		}
+		return edits[n-1].Params["text"]
+	}
+
+	// offer waits for the offer to join sent to the new member.
+	offer := func(user *tb.User) *tb.Message {
+		sent := e.waitSent(t, len(e.tg.Sent())+1)
+		if want := "Welcome, @" + user.Username + "! Should the bot remind you with the rest of the team?"; sent[len(sent)-1] != want {
+			t.Fatalf("\t%s\tShould offer %s to join : got %q.", tests.Failed, user.Username, sent[len(sent)-1])
+		}
+		requests := e.tg.Requests("sendMessage")
+		return &tb.Message{ID: requests[len(requests)-1].MessageID, Chat: e.chat}
+	}
+
+	t.Log("Given the sync of participants turned off.")
+	{
+		e.command(t, "/addparticipant @bob")
+		if err := e.st.Participant.Link(ctx, "@bob", int64(bob.ID), "@bob", e.clk.Now()); err != nil {
+			t.Fatalf("\t%s\tShould be able to link a participant : %s.", tests.Failed, err)
+		}
+
+		e.tg.Join(e.chat, dave)
+		e.tg.Leave(e.chat, bob)
+		if !quiet() {
+			t.Fatalf("\t%s\tShould not offer to join with the sync off : got %q.", tests.Failed, e.tg.Sent())
+		}
+		if _, ok := find("@bob"); !ok {
+			t.Fatalf("\t%s\tShould not remove a member leaving with the sync off.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould leave participants as they are.", tests.Success)
+
+		if got := e.command(t, "/autosync on"); got != "Only chat admins can change the participant sync" {
+			t.Fatalf("\t%s\tShould refuse a member who is not an admin : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould let chat admins only turn the sync on.", tests.Success)
+	}
+
+	t.Log("Given the sync of participants turned on.")
+	{
+		e.tg.SetAdmins(chatID, e.user)
+		if got := e.command(t, "/autosync maybe"); got != `Set "on" or "off"` {
+			t.Fatalf("\t%s\tShould refuse an invalid switch : got %q.", tests.Failed, got)
+		}
+		e.command(t, "/autosync on")
+
+		e.tg.Join(e.chat, bob, robot)
+		if !quiet() {
+			t.Fatalf("\t%s\tShould not offer participants and bots to join : got %q.", tests.Failed, e.tg.Sent())
+		}
+		t.Logf("\t%s\tShould not offer participants and bots to join.", tests.Success)
+
+		e.tg.Join(e.chat, dave)
+		msg := offer(dave)
+		t.Logf("\t%s\tShould offer a new member to join.", tests.Success)
+
+		e.tg.Press(msg, bob, "enroll_join", "13")
+		if got := answer(t, e.tg, 1); got != "This offer is for somebody else" {
+			t.Fatalf("\t%s\tShould refuse a press by somebody else : got %q.", tests.Failed, got)
+		}
+		if _, ok := find("@dave"); ok {
+			t.Fatalf("\t%s\tShould not add the new member on a press by somebody else.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould let only the new member accept the offer.", tests.Success)
+
+		e.tg.Press(msg, dave, "enroll_join", "13")
+		answer(t, e.tg, 2)
+		if got := edited(1); got != "@dave joined the reminder" {
+			t.Fatalf("\t%s\tShould confirm the new member joined : got %q.", tests.Failed, got)
+		}
+		if p, ok := find("@dave"); !ok || p.UserID != int64(dave.ID) {
+			t.Fatalf("\t%s\tShould add the new member linked to the account : got %+v.", tests.Failed, p)
+		}
+		t.Logf("\t%s\tShould add the new member who accepts the offer.", tests.Success)
+
+		e.tg.Join(e.chat, erin)
+		msg = offer(erin)
+		e.tg.Press(msg, erin, "enroll_decline", "15")
+		answer(t, e.tg, 3)
+		if got := edited(2); got != "@erin won't be reminded, use /joinme to join later" {
+			t.Fatalf("\t%s\tShould close the declined offer : got %q.", tests.Failed, got)
+		}
+		if _, ok := find("@erin"); ok {
+			t.Fatalf("\t%s\tShould not add a new member who declines.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould not add a new member who declines the offer.", tests.Success)
+
+		n := len(e.tg.Sent())
+		e.tg.Leave(e.chat, dave)
+		if got := e.waitSent(t, n+1)[n]; got != "@dave left the chat and is no longer reminded" {
+			t.Fatalf("\t%s\tShould tell the member left : got %q.", tests.Failed, got)
+		}
+		if _, ok := find("@dave"); ok {
+			t.Fatalf("\t%s\tShould remove the member who left.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould remove a member leaving the chat.", tests.Success)
+
+		e.tg.Leave(e.chat, erin)
+		if !quiet() {
+			t.Fatalf("\t%s\tShould ignore a member leaving who is not a participant : got %q.", tests.Failed, e.tg.Sent())
+		}
+		t.Logf("\t%s\tShould ignore a member leaving who is not a participant.", tests.Success)
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+	"github.com/tmowka/telegram-reminder-bot/internal/storage"
+	"github.com/tmowka/telegram-reminder-bot/internal/webhook"
+)
+
+const (
+	// resumeInterval is how often the leader retries the reminds that were
+	// fired but not queued for delivery.
+	resumeInterval = time.Minute
+
+	// maxOccurrenceAttempts is the number of failed attempts after which a
+	// remind is given up.
+	maxOccurrenceAttempts = 5
+
+	// occurrenceExpiry is how long a remind is worth delivering after it was
+	// due, a late one would only confuse the team.
+	occurrenceExpiry = 12 * time.Hour
+)
+
+// notify records the remind of the reminder due at dueAt in the ledger and
+// queues it for delivery. A remind that was already queued, e.g. by the
+// replica that led before, is not queued again.
+func (b *Bot) notify(ctx context.Context, reminderID string, dueAt time.Time) {
+	ctx, span := trace.StartSpan(ctx, "handlers.Bot.notify")
+	defer span.End()
+
+	if err := b.storage.Occurrence.Create(ctx, reminderID, dueAt, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error recording remind")
+		log.Println("handlers.Bot.notify : error :", err)
+		return
+	}
+
+	if err := b.queueOccurrence(ctx, reminderID, dueAt); err != nil {
+		log.Println("handlers.Bot.notify : error :", err)
+		b.retryOccurrence(ctx, reminderID, dueAt, err)
+	}
+}
+
+// resumeLoop queues the pending reminds and schedules the pending personal
+// reminders now, every resumeInterval and whenever it is woken up until ctx
+// is done.
+func (b *Bot) resumeLoop(ctx context.Context) {
+	ticker := b.clock.NewTicker(resumeInterval)
+	defer ticker.Stop()
+
+	for {
+		b.resume(ctx)
+		b.resumePersonal(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		case <-b.resumeWake:
+		}
+	}
+}
+
+// wakeResume makes resumeLoop queue the pending reminds right away, if it
+// runs on this replica.
+func (b *Bot) wakeResume() {
+	select {
+	case b.resumeWake <- struct{}{}:
+	default:
+	}
+}
+
+// resume queues the reminds that were recorded but not queued, because the
+// bot stopped or the storage failed in between. The ones that failed too
+// often or are too late are given up.
+func (b *Bot) resume(ctx context.Context) {
+	ctx, span := trace.StartSpan(ctx, "handlers.Bot.resume")
+	defer span.End()
+
+	occurrences, err := b.storage.Occurrence.ListPending(ctx, 100)
+	if err != nil {
+		err = errors.Wrap(err, "error getting pending reminds")
+		log.Println("handlers.Bot.resume : error :", err)
+		return
+	}
+
+	for _, o := range occurrences {
+		now := b.clock.Now()
+
+		var reason string
+		switch {
+		case o.Attempts >= maxOccurrenceAttempts:
+			reason = o.LastError
+		case now.Sub(o.DueAt) > occurrenceExpiry:
+			reason = "expired"
+		}
+
+		if reason != "" {
+			log.Printf("handlers.Bot.resume : error : giving up remind of %s due at %s : %s", o.ReminderID, o.DueAt, reason)
+			if err := b.storage.Occurrence.Fail(ctx, o.ReminderID, o.DueAt, reason, now); err != nil {
+				log.Println("handlers.Bot.resume : error :", err)
+			}
+			continue
+		}
+
+		if err := b.queueOccurrence(ctx, o.ReminderID, o.DueAt); err != nil {
+			log.Println("handlers.Bot.resume : error :", err)
+			b.retryOccurrence(ctx, o.ReminderID, o.DueAt, err)
+		}
+	}
+}
+
+// queueOccurrence marks a pending remind as sent and puts its message to the
+// chat and the fanout addresses into the outbox within one transaction, so
+// the remind is queued exactly once.
+// The mentioned participants are expected to acknowledge it. Nothing is
+// posted when everybody already completed the task.
+func (b *Bot) queueOccurrence(ctx context.Context, reminderID string, dueAt time.Time) error {
+	text, mentioned := b.remindText(ctx, reminderID, dueAt)
+
+	ids := make([]string, len(mentioned))
+	names := make([]string, len(mentioned))
+	for i, p := range mentioned {
+		ids[i] = p.ID
+		names[i] = p.Name
+	}
+
+	queued := false
+	err := b.storage.WithinTx(ctx, func(tx *storage.Storage) error {
+		pending, err := tx.Occurrence.MarkSent(ctx, reminderID, dueAt, b.clock.Now())
+		if err != nil {
+			return err
+		}
+		if !pending {
+			return nil
+		}
+		if text == "" {
+			log.Println("handlers.Bot.notify : skipped : everybody completed the remind due at", dueAt)
+			return nil
+		}
+
+		for _, address := range append([]string{b.chat.id}, b.fanout...) {
+			nm := outbox.NewMessage{
+				ChatID: address,
+				Text:   text,
+			}
+			if _, err := tx.Outbox.Enqueue(ctx, nm, b.clock.Now()); err != nil {
+				return errors.Wrapf(err, "queueing remind to %s", address)
+			}
+		}
+		if err := tx.Ack.Expect(ctx, reminderID, dueAt, ids, b.clock.Now()); err != nil {
+			return errors.Wrap(err, "expecting acknowledgements")
+		}
+
+		queued = true
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error queueing remind due at %s", dueAt)
+	}
+
+	if queued {
+		b.queue.Wake()
+		log.Println("handlers.Bot.notify : queued :", text)
+		b.scheduleEscalation(ctx, reminderID, dueAt)
+		b.emit(ctx, webhook.EventFired, firedData{
+			ReminderID:   reminderID,
+			DueAt:        dueAt.UTC(),
+			Text:         text,
+			Participants: names,
+		})
+	}
+
+	return nil
+}
+
+func (b *Bot) retryOccurrence(ctx context.Context, reminderID string, dueAt time.Time, cause error) {
+	if err := b.storage.Occurrence.Retry(ctx, reminderID, dueAt, cause.Error(), b.clock.Now()); err != nil {
+		log.Println("handlers.Bot.notify : error :", err)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestResume validates a remind recorded by a leader that stopped before
+// queueing it is delivered by the next leader, and only once however often
+// it is notified.
+func TestResume(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	ctx := context.Background()
+
+	t.Log("Given a remind recorded by a leader that stopped before queueing it.")
+	{
+		e.command(t, "/addparticipant @bob")
+		e.bot.Shutdown()
+
+		dueAt := e.clk.Now()
+		for i := 0; i < 2; i++ {
+			if err := e.st.Occurrence.Create(ctx, reminder.DefaultID, dueAt, dueAt); err != nil {
+				t.Fatalf("\t%s\tShould be able to record the remind : %s.", tests.Failed, err)
+			}
+		}
+		n := len(e.tg.Sent())
+
+		_, stop := e.replica(t)
+		defer stop()
+
+		sent := e.waitSent(t, n+1)
+		if !strings.Contains(sent[n], "@bob") {
+			t.Fatalf("\t%s\tShould deliver the pending remind : got %q.", tests.Failed, sent[n])
+		}
+		t.Logf("\t%s\tShould deliver the pending remind on taking the lead over.", tests.Success)
+
+		if err := e.st.Occurrence.Create(ctx, reminder.DefaultID, dueAt, e.clk.Now()); err != nil {
+			t.Fatalf("\t%s\tShould be able to record the remind : %s.", tests.Failed, err)
+		}
+		tests.Advance(e.clk, 2*time.Minute, time.Second)
+		time.Sleep(100 * time.Millisecond)
+		if sent := e.tg.Sent(); len(sent) != n+1 {
+			t.Fatalf("\t%s\tShould deliver the remind once : got %q.", tests.Failed, sent[n:])
+		}
+		t.Logf("\t%s\tShould deliver a remind notified twice once.", tests.Success)
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+)
+
+// participantsPageSize is the number of participants listed per page.
+const participantsPageSize = 20
+
+var (
+	participantsPrevBtn = tb.InlineButton{Unique: "participants_prev", Text: "◀ Prev"}
+	participantsNextBtn = tb.InlineButton{Unique: "participants_next", Text: "Next ▶"}
+)
+
+// Participants lists the participants in the order they were added, a page
+// at a time. The page number can be given, the first one by default.
+func (b *Bot) Participants(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Participants")
+	defer span.End()
+
+	page := 1
+	if raw := strings.TrimSpace(m.Payload); raw != "" {
+		var err error
+		if page, err = strconv.Atoi(raw); err != nil || page < 1 {
+			b.reply(m, "Set the page number, e.g. 2")
+			return
+		}
+	}
+
+	text, options, err := b.participantsPage(ctx, page)
+	if err != nil {
+		log.Println("handlers.Bot.Participants : error :", err)
+		return
+	}
+
+	b.reply(m, text, options...)
+}
+
+// ParticipantsPage turns the page of the participant list, the button data is
+// the page to show.
+func (b *Bot) ParticipantsPage(c *tb.Callback) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.ParticipantsPage")
+	defer span.End()
+
+	page, err := strconv.Atoi(c.Data)
+	if err != nil {
+		b.respond(c, "Unknown page")
+		return
+	}
+
+	text, options, err := b.participantsPage(ctx, page)
+	if err != nil {
+		log.Println("handlers.Bot.ParticipantsPage : error :", err)
+		b.respond(c, "Can't list participants, try again")
+		return
+	}
+
+	b.respond(c, "")
+	b.edit(c.Message, text, options...)
+}
+
+// participantsPage renders a page of the participant list with the options
+// adding the buttons turning to the neighbouring pages, if any. A page past
+// the end shows the last one.
+func (b *Bot) participantsPage(ctx context.Context, page int) (string, []interface{}, error) {
+	participants, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "error getting participants")
+	}
+
+	if len(participants) == 0 {
+		return "No participants yet, add them with /addparticipant", nil, nil
+	}
+
+	pages := (len(participants) + participantsPageSize - 1) / participantsPageSize
+	if page > pages {
+		page = pages
+	}
+
+	first := (page - 1) * participantsPageSize
+	last := first + participantsPageSize
+	if last > len(participants) {
+		last = len(participants)
+	}
+
+	lines := []string{fmt.Sprintf("Participants %d-%d of %d:", first+1, last, len(participants))}
+	for i, p := range participants[first:last] {
+		lines = append(lines, fmt.Sprintf("%d. %s, added %s", first+i+1, p.Name, p.AddedAt.In(b.location()).Format(absenceDateLayout)))
+	}
+
+	var row []tb.InlineButton
+	if page > 1 {
+		btn := participantsPrevBtn
+		btn.Data = strconv.Itoa(page - 1)
+		row = append(row, btn)
+	}
+	if page < pages {
+		btn := participantsNextBtn
+		btn.Data = strconv.Itoa(page + 1)
+		row = append(row, btn)
+	}
+
+	var options []interface{}
+	if len(row) > 0 {
+		options = append(options, &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{row}})
+	}
+
+	return strings.Join(lines, "\n"), options, nil
+}
+
+// findParticipant returns the participant with the given name, matched
+// case-insensitively and with or without "@", or participant.ErrNotFound.
+func (b *Bot) findParticipant(ctx context.Context, name string) (*participant.Participant, error) {
+	participants, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting participants")
+	}
+
+	p, ok := participant.Find(participants, name)
+	if !ok {
+		return nil, participant.ErrNotFound
+	}
+
+	return &p, nil
+}
+
+// listLine describes a list of names after a label, or nothing if the list
+// is empty.
+func listLine(label string, names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s: %s", label, strings.Join(names, ", "))
+}
+
+// joinLines joins the non-empty lines.
+func joinLines(lines ...string) string {
+	var kept []string
+	for _, l := range lines {
+		if l != "" {
+			kept = append(kept, l)
+		}
+	}
+
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+)
+
+const pauseDateLayout = "2006-01-02"
+
+func (b *Bot) SkipNext(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SkipNext")
+	defer span.End()
+
+	skipped, err := b.reminder.SkipNext()
+	if err != nil {
+		b.reply(m, fmt.Sprintf("Can't skip the next remind: %v", err))
+		return
+	}
+
+	if err := b.savePause(ctx); err != nil {
+		log.Println("handlers.Bot.SkipNext : error :", err)
+		return
+	}
+
+	b.reply(m, fmt.Sprintf("Remind of %s is skipped", skipped.In(b.location()).Format(DATE_TIME_LAYOUT)))
+}
+
+func (b *Bot) Pause(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Pause")
+	defer span.End()
+
+	raw := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(m.Payload), "until"))
+
+	until, err := time.ParseInLocation(pauseDateLayout, raw, b.location())
+	if err != nil {
+		b.reply(m, `Set the date to resume on in format "until 2006-01-02"`)
+		return
+	}
+
+	if !until.After(b.clock.Now()) {
+		b.reply(m, "The date to resume on must be in the future")
+		return
+	}
+
+	b.reminder.Pause(until)
+
+	if err := b.savePause(ctx); err != nil {
+		log.Println("handlers.Bot.Pause : error :", err)
+		return
+	}
+
+	b.reply(m, fmt.Sprintf("Reminder is paused until %s", until.Format(DATE_TIME_LAYOUT)))
+}
+
+func (b *Bot) Snooze(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Snooze")
+	defer span.End()
+
+	d, err := time.ParseDuration(strings.TrimSpace(m.Payload))
+	if err != nil || d <= 0 {
+		b.reply(m, `Set the delay of the next remind, e.g. "2h" or "30m"`)
+		return
+	}
+
+	next, err := b.reminder.SnoozeNext(d)
+	if err != nil {
+		b.reply(m, fmt.Sprintf("Can't snooze the next remind: %v", err))
+		return
+	}
+
+	if err := b.savePause(ctx); err != nil {
+		log.Println("handlers.Bot.Snooze : error :", err)
+		return
+	}
+
+	b.reply(m, fmt.Sprintf("Next remind is snoozed until %s", next.In(b.location()).Format(DATE_TIME_LAYOUT)))
+}
+
+// Resume cancels the pause, the skip and the snooze.
+func (b *Bot) Resume(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Resume")
+	defer span.End()
+
+	b.reminder.Pause(time.Time{})
+	b.reminder.Snooze(time.Time{})
+
+	if err := b.savePause(ctx); err != nil {
+		log.Println("handlers.Bot.Resume : error :", err)
+		return
+	}
+
+	b.reply(m, "Reminder is resumed")
+}
+
+// savePause stores the pause and the snooze of the reminder, the rest of the
+// schedule stays as is.
+func (b *Bot) savePause(ctx context.Context) error {
+	sch, err := b.schedule(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error getting reminder schedule")
+	}
+
+	snap := b.reminder.Snapshot()
+	sch.PausedUntil = optionalTime(snap.PausedUntil)
+	sch.SnoozedUntil = optionalTime(snap.SnoozedUntil)
+
+	if err := b.storage.Reminder.Save(ctx, *sch, b.clock.Now()); err != nil {
+		return errors.Wrap(err, "error saving reminder pause")
+	}
+
+	return nil
+}
+
+// applyPause restores the stored pause and snooze of a reminder.
+func applyPause(r *reminder.Reminder, sch *reminder.Schedule) {
+	var paused, snoozed time.Time
+	if sch.PausedUntil != nil {
+		paused = *sch.PausedUntil
+	}
+	if sch.SnoozedUntil != nil {
+		snoozed = *sch.SnoozedUntil
+	}
+
+	r.Pause(paused)
+	r.Snooze(snoozed)
+}
+
+// pauseState describes the pause and the snooze of the reminder.
+func (b *Bot) pauseState(snap reminder.Snapshot) string {
+	now := b.clock.Now()
+
+	var state []string
+	if snap.PausedUntil.After(now) {
+		state = append(state, "until "+snap.PausedUntil.In(snap.Location).Format(DATE_TIME_LAYOUT))
+	}
+	if snap.SnoozedUntil.After(now) {
+		state = append(state, "next remind snoozed until "+snap.SnoozedUntil.In(snap.Location).Format(DATE_TIME_LAYOUT))
+	}
+
+	if len(state) == 0 {
+		return "no"
+	}
+
+	return strings.Join(state, ", ")
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
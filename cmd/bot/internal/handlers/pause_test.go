@@ -0,0 +1,131 @@
+package handlers_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// reminds counts the reminds sent to the team chat.
+func (e *env) reminds() int {
+	var n int
+	for _, text := range e.sentTo("-100") {
+		if strings.HasSuffix(text, "\nFill in project server, please!") {
+			n++
+		}
+	}
+
+	return n
+}
+
+// advanceTo moves the clock on to the moment and reports whether a remind
+// was sent meanwhile.
+func (e *env) advanceTo(t *testing.T, at time.Time) bool {
+	t.Helper()
+
+	n := e.reminds()
+	tests.Advance(e.clk, at.Sub(e.clk.Now()), 10*time.Minute)
+
+	return tests.Wait(200*time.Millisecond, func() bool {
+		if e.reminds() > n {
+			return true
+		}
+		e.clk.Advance(100 * time.Millisecond)
+		return false
+	})
+}
+
+// startReminding adds @bob and starts the reminder at 10:00.
+func startReminding(t *testing.T, e *env) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	e.command(t, "/addparticipant @bob")
+	e.command(t, "/setremindtime 10:00")
+
+	e.tg.Command(e.chat, e.user, "/start")
+	started := func() bool {
+		v, err := e.st.Config.GetByName(ctx, config.BotStarted)
+		return err == nil && v.(bool)
+	}
+	if !tests.Wait(time.Second, started) {
+		t.Fatalf("\t%s\tShould start the reminder.", tests.Failed)
+	}
+}
+
+// day returns the moment of March 2020 in UTC.
+func day(d int, hour int, min int) time.Time {
+	return time.Date(2020, 3, d, hour, min, 0, 0, time.UTC)
+}
+
+// TestPause validates a skipped, snoozed or paused remind doesn't fire at its
+// time, and the reminds after it do.
+func TestPause(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	t.Log("Given a reminder reminding at 10:00 every day.")
+	{
+		startReminding(t, e)
+
+		if got := e.command(t, "/skipnext"); got != "Remind of 2 Mar 2020 10:00 is skipped" {
+			t.Fatalf("\t%s\tShould skip the next remind : got %q.", tests.Failed, got)
+		}
+		if e.advanceTo(t, day(2, 12, 0)) {
+			t.Fatalf("\t%s\tShould not send the skipped remind.", tests.Failed)
+		}
+		if !e.advanceTo(t, day(3, 10, 0)) {
+			t.Fatalf("\t%s\tShould send the remind after the skipped one.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould skip the next remind only.", tests.Success)
+
+		if got := e.command(t, "/snooze soon"); got != `Set the delay of the next remind, e.g. "2h" or "30m"` {
+			t.Fatalf("\t%s\tShould refuse an invalid delay : got %q.", tests.Failed, got)
+		}
+		if got := e.command(t, "/snooze 2h"); got != "Next remind is snoozed until 4 Mar 2020 12:00" {
+			t.Fatalf("\t%s\tShould snooze the next remind : got %q.", tests.Failed, got)
+		}
+		if e.advanceTo(t, day(4, 11, 50)) {
+			t.Fatalf("\t%s\tShould not send the snoozed remind at its time.", tests.Failed)
+		}
+		if !e.advanceTo(t, day(4, 12, 0)) {
+			t.Fatalf("\t%s\tShould send the snoozed remind after the delay.", tests.Failed)
+		}
+		if !e.advanceTo(t, day(5, 10, 0)) {
+			t.Fatalf("\t%s\tShould send the remind after the snoozed one at its time.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould snooze the next remind only.", tests.Success)
+
+		if got := e.command(t, "/pause until 2020-03-01"); got != "The date to resume on must be in the future" {
+			t.Fatalf("\t%s\tShould refuse a date in the past : got %q.", tests.Failed, got)
+		}
+		if got := e.command(t, "/pause tomorrow"); got != `Set the date to resume on in format "until 2006-01-02"` {
+			t.Fatalf("\t%s\tShould refuse an invalid date : got %q.", tests.Failed, got)
+		}
+		if got := e.command(t, "/pause until 2020-03-08"); got != "Reminder is paused until 8 Mar 2020 00:00" {
+			t.Fatalf("\t%s\tShould pause the reminder : got %q.", tests.Failed, got)
+		}
+		if e.advanceTo(t, day(7, 12, 0)) {
+			t.Fatalf("\t%s\tShould not send reminds while paused.", tests.Failed)
+		}
+		if !e.advanceTo(t, day(8, 10, 0)) {
+			t.Fatalf("\t%s\tShould send the reminds after the pause.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould pause the reminder until the date.", tests.Success)
+
+		e.command(t, "/pause until 2020-03-20")
+		e.command(t, "/snooze 3h")
+		if got := e.command(t, "/resume"); got != "Reminder is resumed" {
+			t.Fatalf("\t%s\tShould resume the reminder : got %q.", tests.Failed, got)
+		}
+		if !e.advanceTo(t, day(9, 10, 0)) {
+			t.Fatalf("\t%s\tShould send the next remind at its time once resumed.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould cancel the pause and the snooze on /resume.", tests.Success)
+	}
+}
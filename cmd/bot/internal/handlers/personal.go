@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+	"github.com/tmowka/telegram-reminder-bot/internal/personal"
+	"github.com/tmowka/telegram-reminder-bot/internal/storage"
+)
+
+const (
+	// maxPersonalReminders is how many pending reminders a user may have.
+	maxPersonalReminders = 50
+
+	// maxPersonalAttempts is the number of failed attempts to queue a
+	// personal reminder after which it is given up.
+	maxPersonalAttempts = 10
+
+	// personalRetryInterval is how long a personal reminder that failed to
+	// be queued waits for the next attempt.
+	personalRetryInterval = time.Minute
+
+	// personalResumeLimit is how many of the earliest pending personal
+	// reminders the leader keeps scheduled.
+	personalResumeLimit = 1000
+)
+
+// RemindMe sets a one-off reminder for the sender, e.g. "in 2h check the
+// deploy" or "tomorrow 10:00 call vendor", delivered as a reply to the
+// command. The time is read in the time zone of the sender.
+func (b *Bot) RemindMe(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.RemindMe")
+	defer span.End()
+
+	if m.Sender == nil {
+		return
+	}
+
+	if strings.TrimSpace(m.Payload) == "" {
+		b.reply(m, `Tell me when and what, e.g. "in 2h check the deploy" or "tomorrow 10:00 call vendor"`)
+		return
+	}
+
+	loc := b.userLocation(ctx, m.Sender)
+
+	dueAt, text, err := personal.Parse(m.Payload, b.clock.Now(), loc)
+	if err != nil {
+		b.reply(m, err.Error())
+		return
+	}
+
+	pending, err := b.storage.Personal.ListByUser(ctx, int64(m.Sender.ID))
+	if err != nil {
+		err = errors.Wrap(err, "error getting personal reminders")
+		log.Println("handlers.Bot.RemindMe : error :", err)
+		return
+	}
+	if len(pending) >= maxPersonalReminders {
+		b.reply(m, fmt.Sprintf("You already have %d reminders, cancel some with /cancel", len(pending)))
+		return
+	}
+
+	nr := personal.NewReminder{
+		ChatID:    strconv.FormatInt(m.Chat.ID, 10),
+		UserID:    int64(m.Sender.ID),
+		MessageID: m.ID,
+		Text:      text,
+		DueAt:     dueAt,
+	}
+
+	r, err := b.storage.Personal.Create(ctx, nr, b.clock.Now())
+	if err != nil {
+		err = errors.Wrap(err, "error creating personal reminder")
+		log.Println("handlers.Bot.RemindMe : error :", err)
+		return
+	}
+
+	b.scheduler.Set(personalJobID(r.ID), r.DueAt, b.personalJob(r.ID))
+
+	b.reply(m, fmt.Sprintf("I'll remind you on %s, cancel with /cancel %s", formatRemindIn(r.DueAt, loc), shortID(r.ID)))
+}
+
+// MyReminders lists the pending reminders of the sender.
+func (b *Bot) MyReminders(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.MyReminders")
+	defer span.End()
+
+	if m.Sender == nil {
+		return
+	}
+
+	reminders, err := b.storage.Personal.ListByUser(ctx, int64(m.Sender.ID))
+	if err != nil {
+		err = errors.Wrap(err, "error getting personal reminders")
+		log.Println("handlers.Bot.MyReminders : error :", err)
+		return
+	}
+
+	if len(reminders) == 0 {
+		b.reply(m, "You have no reminders, set one with /remindme")
+		return
+	}
+
+	loc := b.userLocation(ctx, m.Sender)
+
+	lines := []string{"Your reminders:"}
+	for _, r := range reminders {
+		lines = append(lines, fmt.Sprintf("%s %s %s", shortID(r.ID), formatRemindIn(r.DueAt, loc), r.Text))
+	}
+
+	b.reply(m, strings.Join(lines, "\n"))
+}
+
+// Cancel cancels a pending reminder of the sender by its ID as listed by
+// /myreminders, any unambiguous prefix of it will do.
+func (b *Bot) Cancel(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Cancel")
+	defer span.End()
+
+	if m.Sender == nil {
+		return
+	}
+
+	id := strings.ToLower(strings.TrimSpace(m.Payload))
+	if id == "" {
+		b.reply(m, "Which one? Find its ID with /myreminders")
+		return
+	}
+
+	reminders, err := b.storage.Personal.ListByUser(ctx, int64(m.Sender.ID))
+	if err != nil {
+		err = errors.Wrap(err, "error getting personal reminders")
+		log.Println("handlers.Bot.Cancel : error :", err)
+		return
+	}
+
+	var found []personal.Reminder
+	for _, r := range reminders {
+		if strings.HasPrefix(r.ID, id) {
+			found = append(found, r)
+		}
+	}
+
+	switch {
+	case len(found) == 0:
+		b.reply(m, fmt.Sprintf("You have no reminder %s", id))
+		return
+	case len(found) > 1:
+		b.reply(m, fmt.Sprintf("Several of your reminders start with %s, use more of the ID", id))
+		return
+	}
+
+	r := found[0]
+
+	cancelled, err := b.storage.Personal.Cancel(ctx, r.ID, b.clock.Now())
+	if err != nil {
+		err = errors.Wrap(err, "error cancelling personal reminder")
+		log.Println("handlers.Bot.Cancel : error :", err)
+		return
+	}
+	if !cancelled {
+		// Delivered meanwhile.
+		b.reply(m, fmt.Sprintf("You have no reminder %s", id))
+		return
+	}
+
+	b.scheduler.Remove(personalJobID(r.ID))
+
+	b.reply(m, fmt.Sprintf("Cancelled the reminder of %s: %s", formatRemindIn(r.DueAt, b.userLocation(ctx, m.Sender)), r.Text))
+}
+
+// resumePersonal schedules the pending personal reminders, including the
+// ones set through another replica, that are not scheduled yet.
+func (b *Bot) resumePersonal(ctx context.Context) {
+	ctx, span := trace.StartSpan(ctx, "handlers.Bot.resumePersonal")
+	defer span.End()
+
+	reminders, err := b.storage.Personal.ListPending(ctx, personalResumeLimit)
+	if err != nil {
+		err = errors.Wrap(err, "error getting pending personal reminders")
+		log.Println("handlers.Bot.resumePersonal : error :", err)
+		return
+	}
+
+	for _, r := range reminders {
+		if _, ok := b.scheduler.When(personalJobID(r.ID)); ok {
+			continue
+		}
+		b.scheduler.Set(personalJobID(r.ID), r.DueAt, b.personalJob(r.ID))
+	}
+}
+
+func (b *Bot) personalJob(id string) func(time.Time) {
+	return func(now time.Time) {
+		b.deliverPersonal(context.Background(), id, now)
+	}
+}
+
+// deliverPersonal queues the reply to the command that set the personal
+// reminder unless it was cancelled or sent meanwhile. The reminder is marked
+// as sent within the transaction queueing the reply, so it is queued once
+// even when a resumed job runs alongside. Failed attempts are retried every
+// personalRetryInterval until maxPersonalAttempts.
+func (b *Bot) deliverPersonal(ctx context.Context, id string, now time.Time) {
+	ctx, span := trace.StartSpan(ctx, "handlers.Bot.deliverPersonal")
+	defer span.End()
+
+	r, err := b.storage.Personal.Get(ctx, id)
+	switch {
+	case err == personal.ErrNotFound:
+		return
+	case err != nil:
+		err = errors.Wrap(err, "error getting personal reminder")
+		log.Println("handlers.Bot.deliverPersonal : error :", err)
+		return
+	case r.Status != personal.StatusPending:
+		return
+	}
+
+	queued := false
+	err = b.storage.WithinTx(ctx, func(tx *storage.Storage) error {
+		pending, err := tx.Personal.MarkSent(ctx, r.ID, b.clock.Now())
+		if err != nil {
+			return err
+		}
+		if !pending {
+			return nil
+		}
+
+		nm := outbox.NewMessage{
+			ChatID:  r.ChatID,
+			Text:    "Reminder: " + r.Text,
+			ReplyTo: r.MessageID,
+		}
+		if _, err := tx.Outbox.Enqueue(ctx, nm, b.clock.Now()); err != nil {
+			return errors.Wrap(err, "queueing personal reminder")
+		}
+
+		queued = true
+		return nil
+	})
+	if err == nil {
+		if queued {
+			b.queue.Wake()
+			log.Println("handlers.Bot.deliverPersonal : queued :", r.Text)
+		}
+		return
+	}
+
+	log.Printf("handlers.Bot.deliverPersonal : error : attempt %d of personal reminder %s : %v", r.Attempts+1, r.ID, err)
+
+	if r.Attempts+1 >= maxPersonalAttempts {
+		if err := b.storage.Personal.Fail(ctx, r.ID, err.Error(), b.clock.Now()); err != nil {
+			log.Println("handlers.Bot.deliverPersonal : error :", err)
+		}
+		return
+	}
+
+	if err := b.storage.Personal.Retry(ctx, r.ID, err.Error(), b.clock.Now()); err != nil {
+		log.Println("handlers.Bot.deliverPersonal : error :", err)
+	}
+
+	b.scheduler.Set(personalJobID(r.ID), now.Add(personalRetryInterval), b.personalJob(r.ID))
+}
+
+func personalJobID(id string) string {
+	return "personal:" + id
+}
+
+// shortID is the part of a personal reminder ID shown to users.
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+
+	return id
+}
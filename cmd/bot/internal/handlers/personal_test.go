@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestRemindMe validates a personal reminder is delivered once, as a reply
+// to the command that set it.
+func TestRemindMe(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	ctx := context.Background()
+
+	t.Log("Given a personal reminder coming due.")
+	{
+		if got := e.command(t, "/remindme in 1h call vendor"); !strings.HasPrefix(got, "I'll remind you on") {
+			t.Fatalf("\t%s\tShould set the reminder : got %q.", tests.Failed, got)
+		}
+		// The command is the message right before the answer.
+		answers := e.tg.Requests("sendMessage")
+		command := answers[len(answers)-1].MessageID - 1
+
+		n := len(e.tg.Sent())
+		e.clk.Advance(time.Hour)
+
+		sent := e.waitSent(t, n+1)
+		if sent[n] != "Reminder: call vendor" {
+			t.Fatalf("\t%s\tShould deliver the reminder : got %q.", tests.Failed, sent[n])
+		}
+		reqs := e.tg.Requests("sendMessage")
+		if got := reqs[len(reqs)-1].Params["reply_to_message_id"]; got != strconv.Itoa(command) {
+			t.Fatalf("\t%s\tShould reply to the command : got %q, want %d.", tests.Failed, got, command)
+		}
+		t.Logf("\t%s\tShould deliver the reminder as a reply to the command.", tests.Success)
+
+		e.clk.Advance(time.Hour)
+		time.Sleep(100 * time.Millisecond)
+		if sent := e.tg.Sent(); len(sent) != n+1 {
+			t.Fatalf("\t%s\tShould deliver the reminder once : got %q.", tests.Failed, sent[n:])
+		}
+		rs, err := e.st.Personal.ListPending(ctx, 10)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list pending reminders : %s.", tests.Failed, err)
+		}
+		if len(rs) != 0 {
+			t.Fatalf("\t%s\tShould mark the reminder as sent : got %+v.", tests.Failed, rs)
+		}
+		t.Logf("\t%s\tShould deliver the reminder once.", tests.Success)
+	}
+}
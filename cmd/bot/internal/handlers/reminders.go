@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+)
+
+// scheduleOf returns the schedule of the reminder, an empty one for the team
+// reminder if it was never configured.
+func (b *Bot) scheduleOf(ctx context.Context, reminderID string) (*reminder.Schedule, error) {
+	if reminderID == reminder.DefaultID {
+		return b.schedule(ctx)
+	}
+
+	return b.storage.Reminder.Get(ctx, reminderID)
+}
+
+// syncOthers runs the stored reminders other than the team one, e.g. the ones
+// added through the API, the same way as the team reminder while the bot is
+// started. Reminders that are gone from the storage are stopped.
+func (b *Bot) syncOthers(ctx context.Context, started bool, holidays string) error {
+	b.othersMu.Lock()
+	defer b.othersMu.Unlock()
+
+	var schedules []reminder.Schedule
+	if started {
+		var err error
+		if schedules, err = b.storage.Reminder.List(ctx); err != nil {
+			return errors.Wrap(err, "getting reminders")
+		}
+	}
+
+	stored := make(map[string]struct{}, len(schedules))
+	for i := range schedules {
+		sch := &schedules[i]
+		if sch.ID == reminder.DefaultID || sch.RemindTime == "" {
+			continue
+		}
+		stored[sch.ID] = struct{}{}
+
+		r, ok := b.others[sch.ID]
+		if !ok {
+			r = reminder.New(b.clock, b.scheduler, sch.ID, 24*time.Hour, b.location())
+			b.others[sch.ID] = r
+		}
+
+		if err := b.applySchedule(r, sch, holidays); err != nil {
+			log.Printf("handlers.Bot.syncOthers : error : reminder %s : %v", sch.ID, err)
+		}
+	}
+
+	for id, r := range b.others {
+		if _, ok := stored[id]; ok {
+			continue
+		}
+		if err := r.Stop(); err != nil {
+			return errors.Wrapf(err, "stopping reminder %s", id)
+		}
+		delete(b.others, id)
+	}
+
+	return nil
+}
+
+// applySchedule applies the schedule and the common settings of the bot to
+// a reminder other than the team one, starting it if it is stopped.
+func (b *Bot) applySchedule(r *reminder.Reminder, sch *reminder.Schedule, holidays string) error {
+	r.SetLocation(b.location())
+
+	if err := r.SetHolidays(holidays); err != nil {
+		return errors.Wrap(err, "setting holidays")
+	}
+	if err := r.SetWeekdaysToSkip(sch.WeekdaysToSkip); err != nil {
+		return errors.Wrap(err, "setting weekdays to skip")
+	}
+
+	applyPause(r, sch)
+
+	remindChan, err := r.Start(context.Background(), sch.RemindTime)
+	switch {
+	case err == reminder.ErrStarted:
+		return r.Reschedule(sch.RemindTime)
+	case err != nil:
+		return err
+	}
+
+	go b.remind(sch.ID, remindChan)
+
+	return nil
+}
@@ -1,42 +1,183 @@
 package handlers
 
 import (
+	"context"
 	"time"
 
-	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	tb "gopkg.in/tucnak/telebot.v2"
 
+	"github.com/tmowka/telegram-reminder-bot/internal/completion"
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/leader"
+	"github.com/tmowka/telegram-reminder-bot/internal/notifier"
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
 	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/scheduler"
+	"github.com/tmowka/telegram-reminder-bot/internal/storage"
+	"github.com/tmowka/telegram-reminder-bot/internal/webhook"
 )
 
-func Telebot(db *sqlx.DB, telebot *tb.Bot, chatId string, location string) error {
+// Config describes what the bot works with.
+type Config struct {
+	Storage    *storage.Storage             // Storage of the bot state.
+	Telebot    *tb.Bot                      // Telegram client the commands are handled with.
+	Clock      clock.Clock                  // Source of the current time, the wall clock by default.
+	ChatID     string                       // Team chat the reminds are sent to.
+	Location   string                       // Time zone of the reminder unless one was set with /settimezone.
+	Checker    completion.Checker           // Tells who completed the task, nobody is skipped without one.
+	Endpoints  []webhook.Endpoint           // Endpoints the reminder events are posted to.
+	Transports map[string]notifier.Notifier // Notifiers besides Telegram by the scheme of their addresses.
+	Fanout     []string                     // Addresses reminds are sent to besides the chat.
+	Settings   []Setting                    // Startup settings shown by /config.
+}
+
+// Telebot registers the handlers of the chat commands and returns the bot,
+// whose HTTP API can be served with API. Messages are sent to Telegram and
+// to addresses with the scheme of one of the transports, e.g. "slack:#team"
+// for the "slack" one. Reminds are also sent to the fanout addresses.
+func Telebot(cfg Config) (*Bot, error) {
+	st, telebot, clk, location := cfg.Storage, cfg.Telebot, cfg.Clock, cfg.Location
+	if clk == nil {
+		clk = clock.Real{}
+	}
+
+	// A time zone set with /settimezone takes precedence over the startup one.
+	stored, err := st.Config.GetByName(context.Background(), config.Location)
+	switch {
+	case err == nil:
+		location = stored.(string)
+	case err != config.ErrNotFound:
+		return nil, errors.Wrap(err, "error getting stored location")
+	}
+
 	loc, err := time.LoadLocation(location)
 	if err != nil {
-		return errors.Wrap(err, "error loading location")
+		return nil, errors.Wrap(err, "error loading location")
 	}
 
-	r := reminder.New(24*time.Hour, loc)
+	sched := scheduler.New(clk)
+
+	r := reminder.New(clk, sched, reminder.DefaultID, 24*time.Hour, loc)
+
+	holidays, err := st.Config.GetByName(context.Background(), config.Holidays)
+	switch {
+	case err == nil:
+		if err := r.SetHolidays(holidays.(string)); err != nil {
+			return nil, errors.Wrap(err, "error setting holidays")
+		}
+	case err != config.ErrNotFound:
+		return nil, errors.Wrap(err, "error getting holidays")
+	}
 
-	b := Bot{
-		db: db,
+	tg := notifier.NewTelegram(telebot)
+
+	router := notifier.NewRouter(tg)
+	for scheme, n := range cfg.Transports {
+		router.Register(scheme, n)
+	}
+	for _, address := range cfg.Fanout {
+		if !router.Supports(address) {
+			return nil, errors.Errorf("no transport for fanout address %q", address)
+		}
+	}
+
+	q := outbox.NewQueue(st.Outbox, router, outbox.QueueConfig{Clock: clk})
+	wh := webhook.NewDispatcher(st.Webhook, cfg.Endpoints, webhook.DispatcherConfig{Clock: clk})
+
+	b := &Bot{
+		storage: st,
+		clock:   clk,
 		chat: &chat{
-			id: chatId,
+			id: cfg.ChatID,
 		},
-		telebot:  telebot,
-		reminder: r,
+		fanout:     cfg.Fanout,
+		telebot:    telebot,
+		queue:      q,
+		webhooks:   wh,
+		scheduler:  sched,
+		reminder:   r,
+		checker:    cfg.Checker,
+		others:     make(map[string]*reminder.Reminder),
+		resumeWake: make(chan struct{}, 1),
+		settings:   cfg.Settings,
 	}
 
+	// Every replica serves commands and keeps its reminder in sync with the
+	// storage, only the leader fires reminds and delivers messages.
+	ctx, cancel := context.WithCancel(context.Background())
+	b.shutdown = cancel
+
+	elector := leader.New(st.Lease, leader.Config{Clock: clk})
+
+	b.wg.Add(2)
+	go func() {
+		defer b.wg.Done()
+		elector.Run(ctx, b.lead)
+	}()
+	go func() {
+		defer b.wg.Done()
+		b.syncLoop(ctx)
+	}()
+
 	telebot.Handle("/hello", b.Hello)
 	telebot.Handle("/help", b.Help)
 	telebot.Handle("/start", b.Start)
 	telebot.Handle("/stop", b.Stop)
 	telebot.Handle("/addparticipant", b.AddParticipant)
 	telebot.Handle("/removeparticipant", b.RemoveParticipant)
+	telebot.Handle("/participants", b.Participants)
+	telebot.Handle(&participantsPrevBtn, b.ParticipantsPage)
+	telebot.Handle(&participantsNextBtn, b.ParticipantsPage)
 	telebot.Handle("/setremindtime", b.SetRemindTime)
 	telebot.Handle("/setremindmessage", b.SetRemindMessage)
 	telebot.Handle("/setweekdaystoskip", b.SetWeekdaysToSkip)
+	telebot.Handle("/workdays", b.Workdays)
 	telebot.Handle("/info", b.Info)
+	telebot.Handle("/settimezone", b.SetTimezone)
+	telebot.Handle("/config", b.Config)
+	telebot.Handle("/export", b.Export)
+	telebot.Handle("/import", b.Import)
+	telebot.Handle(&importConfirmBtn, b.ConfirmImport)
+	telebot.Handle(&importCancelBtn, b.CancelImport)
+	telebot.Handle("/setup", b.Setup)
+	telebot.Handle(&setupTimeBtn, b.SetupTime)
+	telebot.Handle(&setupDayBtn, b.SetupDay)
+	telebot.Handle(&setupNextBtn, b.SetupNext)
+	telebot.Handle(&setupKeepBtn, b.SetupKeep)
+	telebot.Handle(&setupSaveBtn, b.SetupSave)
+	telebot.Handle(&setupCancelBtn, b.SetupCancel)
+	telebot.Handle(tb.OnText, b.SetupText)
+	telebot.Handle("/skipnext", b.SkipNext)
+	telebot.Handle("/pause", b.Pause)
+	telebot.Handle("/snooze", b.Snooze)
+	telebot.Handle("/resume", b.Resume)
+	telebot.Handle("/next", b.Next)
+	telebot.Handle("/setholidays", b.SetHolidays)
+	telebot.Handle("/addtogroup", b.AddToGroup)
+	telebot.Handle("/removefromgroup", b.RemoveFromGroup)
+	telebot.Handle("/groups", b.Groups)
+	telebot.Handle("/setremindgroups", b.SetRemindGroups)
+	telebot.Handle("/away", b.Away)
+	telebot.Handle("/back", b.Back)
+	telebot.Handle("/autosync", b.AutoSync)
+	telebot.Handle(tb.OnUserJoined, b.UserJoined)
+	telebot.Handle(tb.OnUserLeft, b.UserLeft)
+	telebot.Handle(&enrollJoinBtn, b.EnrollJoin)
+	telebot.Handle(&enrollDeclineBtn, b.EnrollDecline)
+	telebot.Handle("/joinme", b.JoinMe)
+	telebot.Handle("/leaveme", b.LeaveMe)
+	telebot.Handle("/mystatus", b.MyStatus)
+	telebot.Handle("/mytimezone", b.MyTimezone)
+	telebot.Handle("/lockparticipants", b.LockParticipants)
+	telebot.Handle("/done", b.Done)
+	telebot.Handle("/escalation", b.Escalation)
+	telebot.Handle("/setlead", b.SetLead)
+	telebot.Handle("/webhookfailures", b.WebhookFailures)
+	telebot.Handle("/remindme", b.RemindMe)
+	telebot.Handle("/myreminders", b.MyReminders)
+	telebot.Handle("/cancel", b.Cancel)
 
-	return nil
+	return b, nil
 }
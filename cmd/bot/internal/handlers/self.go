@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+)
+
+// errNameTaken is used when a user joins under the name of a participant
+// linked to somebody else.
+var errNameTaken = errors.New("Name is taken by another participant")
+
+// JoinMe adds the sender to participants.
+func (b *Bot) JoinMe(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.JoinMe")
+	defer span.End()
+
+	if !b.canChangeParticipants(ctx, m) {
+		return
+	}
+
+	p, already, err := b.join(ctx, m.Sender)
+	switch {
+	case err == errNameTaken:
+		b.reply(m, fmt.Sprintf("Somebody else is a participant as %s, ask a chat admin", participantName(m.Sender)))
+		return
+	case err != nil:
+		log.Println("handlers.Bot.JoinMe : error :", err)
+		return
+	}
+
+	if already {
+		b.reply(m, fmt.Sprintf("You are already a participant as %s", p.Name))
+		return
+	}
+
+	b.reply(m, fmt.Sprintf("%s joined the reminder", p.Name))
+}
+
+// LeaveMe removes the sender from participants.
+func (b *Bot) LeaveMe(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.LeaveMe")
+	defer span.End()
+
+	if !b.canChangeParticipants(ctx, m) {
+		return
+	}
+
+	p, err := b.self(ctx, m.Sender)
+	switch {
+	case err == participant.ErrNotFound:
+		b.reply(m, "You are not a participant")
+		return
+	case err != nil:
+		log.Println("handlers.Bot.LeaveMe : error :", err)
+		return
+	}
+
+	if err := b.storage.Participant.DeleteByName(ctx, p.Name); err != nil {
+		err = errors.Wrap(err, "error removing participant")
+		log.Println("handlers.Bot.LeaveMe : error :", err)
+		return
+	}
+
+	b.reply(m, fmt.Sprintf("%s left the reminder", p.Name))
+}
+
+// MyStatus prints how the reminder treats the sender.
+func (b *Bot) MyStatus(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.MyStatus")
+	defer span.End()
+
+	p, err := b.self(ctx, m.Sender)
+	switch {
+	case err == participant.ErrNotFound:
+		b.reply(m, "You are not a participant, join with /joinme")
+		return
+	case err != nil:
+		log.Println("handlers.Bot.MyStatus : error :", err)
+		return
+	}
+
+	sch, err := b.schedule(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting reminder schedule")
+		log.Println("handlers.Bot.MyStatus : error :", err)
+		return
+	}
+
+	groups, _ := participant.ParseGroups(sch.Groups)
+	now := b.clock.Now()
+	loc := b.participantLocation(p)
+
+	next := "none"
+	if times, err := b.reminder.Upcoming(1); err == nil {
+		next = formatRemindIn(times[0], loc)
+	}
+
+	var mentioned string
+	switch {
+	case p.Away(now):
+		mentioned = "no, you are away"
+	case !p.InAnyGroup(groups):
+		mentioned = "no, the reminder mentions " + remindGroups(groups)
+	default:
+		mentioned = "yes"
+	}
+
+	away := "no"
+	if p.AwayUntil != nil && p.AwayUntil.After(now) {
+		away = b.formatAbsence(*p.AwayFrom, *p.AwayUntil)
+	}
+
+	var lead string
+	if p.LeadID != "" {
+		participants, err := b.storage.Participant.List(ctx)
+		if err != nil {
+			err = errors.Wrap(err, "error getting participants")
+			log.Println("handlers.Bot.MyStatus : error :", err)
+			return
+		}
+		if l, ok := participant.FindByID(participants, p.LeadID); ok {
+			lead = l.Name
+		}
+	}
+
+	msg := fmt.Sprintf(`
+Participant: %s
+Added: %s
+Groups: %s
+Away: %s
+Time zone: %s
+Lead: %s
+Mentioned in reminds: %s
+Next remind: %s your time
+`,
+		p.Name,
+		p.AddedAt.In(loc).Format(absenceDateLayout),
+		strings.Join(p.GroupList(), ", "),
+		away,
+		loc.String(),
+		lead,
+		mentioned,
+		next,
+	)
+
+	b.reply(m, msg)
+}
+
+// MyTimezone sets the time zone of the sender, e.g. "Europe/Warsaw", or
+// prints it without one. The sender sees the reminds and sets personal
+// reminders in it.
+func (b *Bot) MyTimezone(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.MyTimezone")
+	defer span.End()
+
+	p, err := b.self(ctx, m.Sender)
+	switch {
+	case err == participant.ErrNotFound:
+		b.reply(m, "You are not a participant, join with /joinme")
+		return
+	case err != nil:
+		log.Println("handlers.Bot.MyTimezone : error :", err)
+		return
+	}
+
+	name := strings.TrimSpace(m.Payload)
+	if name == "" {
+		b.reply(m, "Your time zone is "+b.participantLocation(p).String())
+		return
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		b.reply(m, fmt.Sprintf("Unknown time zone %q, use IANA names like Europe/Warsaw", name))
+		return
+	}
+
+	if err := b.storage.Participant.SetTimezone(ctx, p.Name, loc.String(), b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving time zone")
+		log.Println("handlers.Bot.MyTimezone : error :", err)
+		return
+	}
+
+	b.reply(m, "Your time zone is set to "+loc.String())
+}
+
+// LockParticipants locks the participant list, or unlocks it with "off".
+// While it is locked only chat admins can change it. Chat admins only.
+func (b *Bot) LockParticipants(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.LockParticipants")
+	defer span.End()
+
+	if !b.isAdmin(m.Sender) {
+		b.reply(m, "Only chat admins can lock the participant list")
+		return
+	}
+
+	locked, ok := parseSwitch(m.Payload)
+	if !ok {
+		b.reply(m, `Set "on" or "off"`)
+		return
+	}
+
+	if err := b.storage.Config.Save(ctx, config.ParticipantsLocked, locked, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving config")
+		log.Println("handlers.Bot.LockParticipants : error :", err)
+		return
+	}
+
+	if locked {
+		b.reply(m, "Participant list is locked, only chat admins can change it")
+		return
+	}
+
+	b.reply(m, "Participant list is unlocked, everybody can join and leave")
+}
+
+// self returns the participant of a Telegram user, found by the user ID or,
+// for a participant added by hand and not linked to anybody yet, by the name.
+// Nothing is changed, the participant is linked to the user when joining.
+func (b *Bot) self(ctx context.Context, user *tb.User) (*participant.Participant, error) {
+	if user == nil {
+		return nil, participant.ErrNotFound
+	}
+
+	participants, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting participants")
+	}
+
+	for _, p := range participants {
+		if p.UserID == int64(user.ID) {
+			return &p, nil
+		}
+	}
+
+	p, found := participant.Find(participants, participantName(user))
+	if !found || p.UserID != 0 {
+		return nil, participant.ErrNotFound
+	}
+
+	return &p, nil
+}
+
+// join makes a Telegram user a participant. The participant of the user,
+// or the one added by hand under the name of the user, is linked to the
+// user, otherwise a new one is added. It reports whether the user was a
+// participant already.
+func (b *Bot) join(ctx context.Context, user *tb.User) (*participant.Participant, bool, error) {
+	p, err := b.self(ctx, user)
+	switch {
+	case err == nil:
+		if err := b.link(ctx, p, user); err != nil {
+			return nil, false, err
+		}
+		return p, true, nil
+	case err != participant.ErrNotFound:
+		return nil, false, err
+	}
+
+	p, err = b.enroll(ctx, user)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return p, false, nil
+}
+
+// link ties p to a Telegram user and renames it to the name the user goes by
+// now, unless another participant has that name.
+func (b *Bot) link(ctx context.Context, p *participant.Participant, user *tb.User) error {
+	name := participantName(user)
+	if p.UserID == int64(user.ID) && p.Name == name {
+		return nil
+	}
+
+	participants, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error getting participants")
+	}
+	if other, taken := participant.Find(participants, name); taken && other.ID != p.ID {
+		name = p.Name
+	}
+
+	if err := b.storage.Participant.Link(ctx, p.Name, int64(user.ID), name, b.clock.Now()); err != nil {
+		return errors.Wrap(err, "error linking participant")
+	}
+	p.UserID, p.Name = int64(user.ID), name
+
+	return nil
+}
+
+// enroll adds a Telegram user to participants. It fails with errNameTaken if
+// a participant linked to somebody else has the name of the user.
+func (b *Bot) enroll(ctx context.Context, user *tb.User) (*participant.Participant, error) {
+	name := participantName(user)
+
+	participants, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting participants")
+	}
+	if _, taken := participant.Find(participants, name); taken {
+		return nil, errNameTaken
+	}
+
+	np := participant.NewParticipant{
+		Name: name,
+	}
+
+	p, err := b.storage.Participant.CreateOrUpdate(ctx, np, b.clock.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "error adding participant")
+	}
+
+	if err := b.storage.Participant.Link(ctx, name, int64(user.ID), name, b.clock.Now()); err != nil {
+		return nil, errors.Wrap(err, "error linking participant")
+	}
+	p.UserID = int64(user.ID)
+
+	return p, nil
+}
+
+// canChangeParticipants reports whether the sender may change the
+// participant list, and tells the sender if not.
+func (b *Bot) canChangeParticipants(ctx context.Context, m *tb.Message) bool {
+	allowed, err := b.mayChangeParticipants(ctx, m.Sender)
+	if err != nil {
+		log.Println("handlers.Bot.canChangeParticipants : error :", err)
+		return false
+	}
+
+	if !allowed {
+		b.reply(m, "Participant list is locked, ask a chat admin")
+	}
+
+	return allowed
+}
+
+// mayChangeParticipants reports whether a user may change the participant
+// list, which is anybody unless the list is locked.
+func (b *Bot) mayChangeParticipants(ctx context.Context, user *tb.User) (bool, error) {
+	locked, err := b.storage.Config.GetByName(ctx, config.ParticipantsLocked)
+	switch {
+	case err == config.ErrNotFound:
+		return true, nil
+	case err != nil:
+		return false, errors.Wrap(err, "error getting config")
+	}
+
+	return !locked.(bool) || b.isAdmin(user), nil
+}
+
+// participantLocation returns the time zone of p, the one of the reminder
+// unless p has set another. Times shown to p are in it.
+func (b *Bot) participantLocation(p *participant.Participant) *time.Location {
+	if p.Timezone == "" {
+		return b.location()
+	}
+
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return b.location()
+	}
+
+	return loc
+}
+
+// userLocation returns the time zone of a Telegram user, the one set with
+// /mytimezone if the user is a participant.
+func (b *Bot) userLocation(ctx context.Context, user *tb.User) *time.Location {
+	p, err := b.self(ctx, user)
+	if err != nil {
+		if err != participant.ErrNotFound {
+			log.Println("handlers.Bot.userLocation : error :", err)
+		}
+		return b.location()
+	}
+
+	return b.participantLocation(p)
+}
+
+// parseSwitch reads "on" or "off" and reports whether it is either.
+func parseSwitch(raw string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "on":
+		return true, true
+	case "off":
+		return false, true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,169 @@
+package handlers_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/setup"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestSelfService validates the self-service commands only change the
+// participant of the sender when asked to.
+func TestSelfService(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	ctx := context.Background()
+	now := e.clk.Now()
+
+	get := func(name string) participant.Participant {
+		ps, err := e.st.Participant.List(ctx)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list participants : %s.", tests.Failed, err)
+		}
+		p, ok := participant.Find(ps, name)
+		if !ok {
+			t.Fatalf("\t%s\tShould find participant %s.", tests.Failed, name)
+		}
+		return p
+	}
+
+	t.Log("Given a participant added by hand under the name of the sender.")
+	{
+		if _, err := e.st.Participant.CreateOrUpdate(ctx, participant.NewParticipant{Name: "@alice"}, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to add a participant : %s.", tests.Failed, err)
+		}
+
+		if got := e.command(t, "/mystatus"); !strings.Contains(got, "Participant: @alice") {
+			t.Fatalf("\t%s\tShould show the status : got %q.", tests.Failed, got)
+		}
+		if p := get("@alice"); p.UserID != 0 {
+			t.Fatalf("\t%s\tShould not link the participant on /mystatus : got user %d.", tests.Failed, p.UserID)
+		}
+		t.Logf("\t%s\tShould not link the participant on /mystatus.", tests.Success)
+
+		if got := e.command(t, "/joinme"); got != "You are already a participant as @alice" {
+			t.Fatalf("\t%s\tShould tell the sender is a participant : got %q.", tests.Failed, got)
+		}
+		if p := get("@alice"); p.UserID != int64(e.user.ID) {
+			t.Fatalf("\t%s\tShould link the participant on /joinme : got user %d.", tests.Failed, p.UserID)
+		}
+		t.Logf("\t%s\tShould link the participant on /joinme.", tests.Success)
+	}
+
+	t.Log("Given a sender going by the name of a participant linked to somebody else.")
+	{
+		bob := &tb.User{ID: 9, Username: "bob", FirstName: "Bob"}
+		if _, err := e.st.Participant.CreateOrUpdate(ctx, participant.NewParticipant{Name: "@bob"}, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to add a participant : %s.", tests.Failed, err)
+		}
+		if err := e.st.Participant.Link(ctx, "@bob", 99, "@bob", now); err != nil {
+			t.Fatalf("\t%s\tShould be able to link a participant : %s.", tests.Failed, err)
+		}
+
+		n := len(e.tg.Sent())
+		e.tg.Command(e.chat, bob, "/mystatus")
+		e.waitSent(t, n+1)
+		e.tg.Command(e.chat, bob, "/joinme")
+		sent := e.waitSent(t, n+2)
+		if sent[n] != "You are not a participant, join with /joinme" {
+			t.Fatalf("\t%s\tShould not take the participant of somebody else : got %q.", tests.Failed, sent[n])
+		}
+		if !strings.HasPrefix(sent[n+1], "Somebody else is a participant as @bob") {
+			t.Fatalf("\t%s\tShould not join under the name of somebody else : got %q.", tests.Failed, sent[n+1])
+		}
+		if p := get("@bob"); p.UserID != 99 {
+			t.Fatalf("\t%s\tShould keep the participant linked : got user %d.", tests.Failed, p.UserID)
+		}
+		t.Logf("\t%s\tShould not take the participant of somebody else.", tests.Success)
+	}
+
+	t.Log("Given a participant in another time zone.")
+	{
+		if got := e.command(t, "/mytimezone Asia/Tokyo"); got != "Your time zone is set to Asia/Tokyo" {
+			t.Fatalf("\t%s\tShould set the time zone : got %q.", tests.Failed, got)
+		}
+
+		// 09:00 UTC is 18:00 in Tokyo.
+		if got := e.command(t, "/remindme today 20:00 call home"); !strings.Contains(got, "Mon 2 Mar 2020 20:00") {
+			t.Fatalf("\t%s\tShould show the personal reminder in the time zone : got %q.", tests.Failed, got)
+		}
+		rs, err := e.st.Personal.ListByUser(ctx, int64(e.user.ID))
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list personal reminders : %s.", tests.Failed, err)
+		}
+		if want := time.Date(2020, 3, 2, 11, 0, 0, 0, time.UTC); len(rs) != 1 || !rs[0].DueAt.Equal(want) {
+			t.Fatalf("\t%s\tShould read the time in the time zone : got %+v, want %s.", tests.Failed, rs, want)
+		}
+		t.Logf("\t%s\tShould read personal reminders in the time zone.", tests.Success)
+	}
+}
+
+// TestSetupLocked validates the setup wizard doesn't change a locked
+// participant list for someone who isn't a chat admin.
+func TestSetupLocked(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	ctx := context.Background()
+	now := e.clk.Now()
+
+	admin := &tb.User{ID: 8, Username: "root", FirstName: "Root"}
+	e.tg.SetAdmins(chatID, admin)
+
+	if err := e.st.Config.Save(ctx, config.ParticipantsLocked, true, now); err != nil {
+		t.Fatalf("saving config: %s", err)
+	}
+	if _, err := e.st.Participant.CreateOrUpdate(ctx, participant.NewParticipant{Name: "@bob"}, now); err != nil {
+		t.Fatalf("adding participant: %s", err)
+	}
+
+	t.Log("Given a setup drafted by a member while the participant list is locked.")
+	{
+		ses := setup.Session{
+			UserID:       e.user.ID,
+			ChatID:       "-100",
+			Step:         setup.StepConfirm,
+			MessageID:    500,
+			RemindTime:   "10:00",
+			Participants: "@bob, @mallory",
+		}
+		if err := e.st.Setup.Save(ctx, ses, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to save the setup session : %s.", tests.Failed, err)
+		}
+
+		e.tg.Press(&tb.Message{ID: 500, Chat: e.chat}, e.user, "setup_save", "")
+		if got := answer(t, e.tg, 1); !strings.HasPrefix(got, "Participant list is locked") {
+			t.Fatalf("\t%s\tShould refuse to change the participants : got %q.", tests.Failed, got)
+		}
+		ps, err := e.st.Participant.List(ctx)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list participants : %s.", tests.Failed, err)
+		}
+		if len(ps) != 1 {
+			t.Fatalf("\t%s\tShould keep the participants : got %+v.", tests.Failed, ps)
+		}
+		if _, err := e.st.Setup.Get(ctx, e.user.ID); err != nil {
+			t.Fatalf("\t%s\tShould keep the setup session : %s.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould refuse to change the participants.", tests.Success)
+
+		ses.Participants = "@bob"
+		if err := e.st.Setup.Save(ctx, ses, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to save the setup session : %s.", tests.Failed, err)
+		}
+
+		e.tg.Press(&tb.Message{ID: 500, Chat: e.chat}, e.user, "setup_save", "")
+		if got := answer(t, e.tg, 2); got != "Setup saved" {
+			t.Fatalf("\t%s\tShould save a setup keeping the participants : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould save a setup keeping the participants.", tests.Success)
+	}
+}
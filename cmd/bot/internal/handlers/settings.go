@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+)
+
+// Sources a setting can come from.
+const (
+	SourceDefault = "default"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+	SourceDB      = "db"
+)
+
+// Setting is a startup setting of the bot together with its origin.
+type Setting struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// overrides maps startup settings to the stored config that takes
+// precedence over them at runtime.
+var overrides = map[string]config.Name{
+	"bot-location": config.Location,
+}
+
+func (b *Bot) SetTimezone(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetTimezone")
+	defer span.End()
+
+	name := strings.TrimSpace(m.Payload)
+
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" {
+		b.send(fmt.Sprintf("Unknown time zone %q, use IANA names like Europe/Warsaw", name))
+		return
+	}
+
+	if err := b.storage.Config.Save(ctx, config.Location, loc.String(), b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving location")
+		log.Println("handlers.Bot.SetTimezone : error :", err)
+		return
+	}
+
+	b.reminder.SetLocation(loc)
+
+	if err := b.reload(ctx); err != nil {
+		err = errors.Wrap(err, "error reloading reminder")
+		log.Println("handlers.Bot.SetTimezone : error :", err)
+		return
+	}
+
+	b.send(fmt.Sprintf("Time zone is set to %s", loc))
+}
+
+func (b *Bot) Config(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Config")
+	defer span.End()
+
+	var lines []string
+	for _, s := range b.settings {
+		if name, ok := overrides[s.Name]; ok {
+			val, err := b.storage.Config.GetByName(ctx, name)
+			switch {
+			case err == nil:
+				s.Value, s.Source = fmt.Sprint(val), SourceDB
+			case err != config.ErrNotFound:
+				err = errors.Wrapf(err, "error getting config %s", name)
+				log.Println("handlers.Bot.Config : error :", err)
+			}
+		}
+
+		lines = append(lines, fmt.Sprintf("%s: %s (%s)", s.Name, s.Value, s.Source))
+	}
+
+	b.send(strings.Join(lines, "\n"))
+}
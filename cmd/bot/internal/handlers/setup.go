@@ -0,0 +1,514 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/setup"
+	"github.com/tmowka/telegram-reminder-bot/internal/storage"
+)
+
+var (
+	setupTimeBtn   = tb.InlineButton{Unique: "setup_time"}
+	setupDayBtn    = tb.InlineButton{Unique: "setup_day"}
+	setupNextBtn   = tb.InlineButton{Unique: "setup_next", Text: "Next"}
+	setupKeepBtn   = tb.InlineButton{Unique: "setup_keep", Text: "Keep current"}
+	setupSaveBtn   = tb.InlineButton{Unique: "setup_save", Text: "Save"}
+	setupCancelBtn = tb.InlineButton{Unique: "setup_cancel", Text: "Cancel"}
+)
+
+// errParticipantsLocked is used when a setup changing the participants is
+// saved by someone who may not change them.
+var errParticipantsLocked = errors.New("Participant list is locked")
+
+// setupTimes are the remind times offered as buttons, others can be typed.
+var setupTimes = []string{"09:00", "09:30", "10:00", "10:30", "11:00", "11:30", "12:00", "12:30"}
+
+// setupWeekdays are the day buttons in the order of a working week.
+var setupWeekdays = []time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+}
+
+// Setup starts the wizard configuring the team reminder. The draft is
+// prefilled with the current schedule and participants.
+func (b *Bot) Setup(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Setup")
+	defer span.End()
+
+	if m.Sender == nil {
+		return
+	}
+
+	sch, err := b.schedule(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting reminder schedule")
+		log.Println("handlers.Bot.Setup : error :", err)
+		return
+	}
+
+	participants, err := b.storage.Participant.List(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "error getting participants")
+		log.Println("handlers.Bot.Setup : error :", err)
+		return
+	}
+
+	names := make([]string, len(participants))
+	for i, p := range participants {
+		names[i] = p.Name
+	}
+
+	ses := setup.Session{
+		UserID:         m.Sender.ID,
+		ChatID:         strconv.FormatInt(m.Chat.ID, 10),
+		Step:           setup.StepTime,
+		RemindTime:     sch.RemindTime,
+		WeekdaysToSkip: sch.WeekdaysToSkip,
+		Message:        sch.Message,
+		Participants:   strings.Join(names, ", "),
+	}
+
+	text, markup := setupPrompt(&ses)
+	prompt := b.reply(m, text, markup)
+	if prompt == nil {
+		return
+	}
+	ses.MessageID = prompt.ID
+
+	if err := b.storage.Setup.Save(ctx, ses, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving setup session")
+		log.Println("handlers.Bot.Setup : error :", err)
+		return
+	}
+}
+
+func (b *Bot) SetupTime(c *tb.Callback) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetupTime")
+	defer span.End()
+
+	ses := b.setupSession(ctx, c, setup.StepTime)
+	if ses == nil {
+		return
+	}
+
+	if _, _, err := reminder.ParseRemindTime(c.Data); err != nil {
+		b.respond(c, "Invalid remind time")
+		return
+	}
+
+	ses.RemindTime = c.Data
+	ses.Step = setup.StepDays
+
+	b.setupAdvance(ctx, c, ses)
+}
+
+func (b *Bot) SetupDay(c *tb.Callback) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetupDay")
+	defer span.End()
+
+	ses := b.setupSession(ctx, c, setup.StepDays)
+	if ses == nil {
+		return
+	}
+
+	day, err := strconv.Atoi(c.Data)
+	if err != nil {
+		b.respond(c, "Unknown day")
+		return
+	}
+
+	skip := parseWeekdays(ses.WeekdaysToSkip)
+	if _, ok := skip[time.Weekday(day)]; ok {
+		delete(skip, time.Weekday(day))
+	} else {
+		skip[time.Weekday(day)] = struct{}{}
+	}
+	ses.WeekdaysToSkip = reminder.FormatWeekdays(skip)
+
+	b.setupAdvance(ctx, c, ses)
+}
+
+func (b *Bot) SetupNext(c *tb.Callback) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetupNext")
+	defer span.End()
+
+	ses := b.setupSession(ctx, c, setup.StepDays)
+	if ses == nil {
+		return
+	}
+
+	ses.Step = setup.StepMessage
+
+	b.setupAdvance(ctx, c, ses)
+}
+
+// SetupKeep leaves the draft value of a text step as is.
+func (b *Bot) SetupKeep(c *tb.Callback) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetupKeep")
+	defer span.End()
+
+	ses := b.setupSession(ctx, c, "")
+	if ses == nil {
+		return
+	}
+
+	switch ses.Step {
+	case setup.StepMessage:
+		ses.Step = setup.StepParticipants
+	case setup.StepParticipants:
+		ses.Step = setup.StepConfirm
+	default:
+		b.respond(c, "Nothing to keep at this step")
+		return
+	}
+
+	b.setupAdvance(ctx, c, ses)
+}
+
+// SetupText takes typed answers: a custom remind time, the remind message
+// and the participant names. Only replies to the current step of the wizard
+// are answers, other texts are ignored.
+func (b *Bot) SetupText(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetupText")
+	defer span.End()
+
+	if m.Sender == nil {
+		return
+	}
+
+	ses, err := b.storage.Setup.Get(ctx, m.Sender.ID)
+	if err != nil {
+		if err != setup.ErrNotFound {
+			err = errors.Wrap(err, "error getting setup session")
+			log.Println("handlers.Bot.SetupText : error :", err)
+		}
+		return
+	}
+
+	if ses.ChatID != strconv.FormatInt(m.Chat.ID, 10) || m.ReplyTo == nil || m.ReplyTo.ID != ses.MessageID {
+		return
+	}
+
+	text := strings.TrimSpace(m.Text)
+
+	switch ses.Step {
+	case setup.StepTime:
+		if _, err := time.Parse("15:04", text); err != nil {
+			b.reply(m, `Send the remind time in format "HH:MM"`)
+			return
+		}
+		ses.RemindTime = text
+		ses.Step = setup.StepDays
+	case setup.StepMessage:
+		ses.Message = text
+		ses.Step = setup.StepParticipants
+	case setup.StepParticipants:
+		ses.Participants = strings.Join(participant.SplitNames(text), ", ")
+		ses.Step = setup.StepConfirm
+	default:
+		return
+	}
+
+	text, markup := setupPrompt(ses)
+	if prompt := b.reply(m, text, markup); prompt != nil {
+		ses.MessageID = prompt.ID
+	}
+
+	if err := b.storage.Setup.Save(ctx, *ses, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving setup session")
+		log.Println("handlers.Bot.SetupText : error :", err)
+		return
+	}
+}
+
+func (b *Bot) SetupSave(c *tb.Callback) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetupSave")
+	defer span.End()
+
+	ses := b.setupSession(ctx, c, setup.StepConfirm)
+	if ses == nil {
+		return
+	}
+
+	allowed, err := b.mayChangeParticipants(ctx, c.Sender)
+	if err != nil {
+		log.Println("handlers.Bot.SetupSave : error :", err)
+		b.respond(c, "Saving failed, nothing was changed")
+		return
+	}
+
+	err = b.storage.WithinTx(ctx, func(tx *storage.Storage) error {
+		return applySetup(ctx, tx, ses, allowed, b.clock.Now())
+	})
+	switch {
+	case err == errParticipantsLocked:
+		b.respond(c, "Participant list is locked, keep the current participants or ask a chat admin")
+		return
+	case err != nil:
+		err = errors.Wrap(err, "error applying setup")
+		log.Println("handlers.Bot.SetupSave : error :", err)
+		b.respond(c, "Saving failed, nothing was changed")
+		return
+	}
+
+	if err := b.reload(ctx); err != nil {
+		err = errors.Wrap(err, "error reloading reminder")
+		log.Println("handlers.Bot.SetupSave : error :", err)
+	}
+
+	b.respond(c, "Setup saved")
+	b.edit(c.Message, "Setup saved\n"+setupSummary(ses))
+}
+
+func (b *Bot) SetupCancel(c *tb.Callback) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.SetupCancel")
+	defer span.End()
+
+	ses := b.setupSession(ctx, c, "")
+	if ses == nil {
+		return
+	}
+
+	if err := b.storage.Setup.Delete(ctx, ses.UserID); err != nil {
+		err = errors.Wrap(err, "error deleting setup session")
+		log.Println("handlers.Bot.SetupCancel : error :", err)
+	}
+
+	b.respond(c, "Setup cancelled")
+	b.edit(c.Message, "Setup cancelled")
+}
+
+// setupSession returns the session of the user pressing a button on its
+// prompt if it is at the given step, any step if it is empty. Otherwise the
+// press is answered and nil is returned, so nobody drives the wizard of
+// somebody else.
+func (b *Bot) setupSession(ctx context.Context, c *tb.Callback, step setup.Step) *setup.Session {
+	if c.Sender == nil || c.Message == nil {
+		return nil
+	}
+
+	ses, err := b.storage.Setup.Get(ctx, c.Sender.ID)
+	if err != nil {
+		if err != setup.ErrNotFound {
+			err = errors.Wrap(err, "error getting setup session")
+			log.Println("handlers.Bot.setupSession : error :", err)
+		}
+		b.respond(c, "You have no setup in progress, run /setup")
+		return nil
+	}
+
+	if c.Message.ID != ses.MessageID {
+		b.respond(c, "This is not your setup, run /setup")
+		return nil
+	}
+
+	if step != "" && ses.Step != step {
+		b.respond(c, "This step is already done")
+		return nil
+	}
+
+	return ses
+}
+
+// setupAdvance saves the session and shows its current step in place of the
+// message with the pressed button, which stays the prompt of the session.
+func (b *Bot) setupAdvance(ctx context.Context, c *tb.Callback, ses *setup.Session) {
+	if err := b.storage.Setup.Save(ctx, *ses, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error saving setup session")
+		log.Println("handlers.Bot.setupAdvance : error :", err)
+		b.respond(c, "Can't save the setup, try again")
+		return
+	}
+
+	b.respond(c, "")
+
+	text, markup := setupPrompt(ses)
+	b.edit(c.Message, text, markup)
+}
+
+// setupPrompt renders the current step of the wizard.
+func setupPrompt(ses *setup.Session) (string, *tb.ReplyMarkup) {
+	cancel := []tb.InlineButton{setupCancelBtn}
+
+	switch ses.Step {
+	case setup.StepTime:
+		var rows [][]tb.InlineButton
+		for i := 0; i < len(setupTimes); i += 4 {
+			var row []tb.InlineButton
+			for _, t := range setupTimes[i : i+4] {
+				btn := setupTimeBtn
+				btn.Text, btn.Data = t, t
+				row = append(row, btn)
+			}
+			rows = append(rows, row)
+		}
+
+		if ses.RemindTime != "" {
+			keep := setupTimeBtn
+			keep.Text, keep.Data = "Keep "+ses.RemindTime, ses.RemindTime
+			rows = append(rows, []tb.InlineButton{keep})
+		}
+
+		text := "Setup 1/4: when should I remind? Pick a time or reply with one in format \"HH:MM\""
+		return text, &tb.ReplyMarkup{InlineKeyboard: append(rows, cancel)}
+
+	case setup.StepDays:
+		skip := parseWeekdays(ses.WeekdaysToSkip)
+
+		var rows [][]tb.InlineButton
+		var row []tb.InlineButton
+		for _, d := range setupWeekdays {
+			btn := setupDayBtn
+			btn.Data = strconv.Itoa(int(d))
+			if _, ok := skip[d]; ok {
+				btn.Text = "▫️ " + d.String()[:3]
+			} else {
+				btn.Text = "✅ " + d.String()[:3]
+			}
+
+			row = append(row, btn)
+			if len(row) == 4 {
+				rows = append(rows, row)
+				row = nil
+			}
+		}
+		rows = append(rows, row, []tb.InlineButton{setupNextBtn}, cancel)
+
+		return "Setup 2/4: on which days? Tap a day to toggle it", &tb.ReplyMarkup{InlineKeyboard: rows}
+
+	case setup.StepMessage:
+		message := ses.Message
+		if message == "" {
+			message = defaultRemindMessage
+		}
+
+		text := fmt.Sprintf("Setup 3/4: reply to this message with the remind message. Current one:\n%s", message)
+		return text, &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{setupKeepBtn}, cancel}}
+
+	case setup.StepParticipants:
+		participants := ses.Participants
+		if participants == "" {
+			participants = "nobody"
+		}
+
+		text := fmt.Sprintf("Setup 4/4: reply to this message with participants separated by commas. Current ones:\n%s", participants)
+		return text, &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{setupKeepBtn}, cancel}}
+
+	default:
+		text := "Save this setup?\n" + setupSummary(ses)
+		return text, &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{setupSaveBtn, setupCancelBtn}}}
+	}
+}
+
+func setupSummary(ses *setup.Session) string {
+	skip := parseWeekdays(ses.WeekdaysToSkip)
+
+	var days []string
+	for _, d := range setupWeekdays {
+		if _, ok := skip[d]; !ok {
+			days = append(days, d.String()[:3])
+		}
+	}
+
+	message := ses.Message
+	if message == "" {
+		message = defaultRemindMessage
+	}
+
+	return fmt.Sprintf(`Remind time: %s
+Days: %s
+Message: %s
+Participants: %s`,
+		ses.RemindTime,
+		strings.Join(days, ", "),
+		message,
+		ses.Participants,
+	)
+}
+
+// applySetup saves the drafted schedule and participants, and closes the
+// session. Unless changing the participants is allowed, a draft changing
+// them fails with errParticipantsLocked.
+func applySetup(ctx context.Context, st *storage.Storage, ses *setup.Session, changeParticipants bool, now time.Time) error {
+	current, err := st.Participant.List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "listing participants")
+	}
+
+	// The changes are checked before any is made, the memory storage has no
+	// transactions to roll back.
+	var added []string
+	keep := make(map[string]bool)
+	for _, name := range participant.SplitNames(ses.Participants) {
+		keep[participant.Key(name)] = true
+		if _, ok := participant.Find(current, name); !ok {
+			added = append(added, name)
+		}
+	}
+
+	var removed []string
+	for _, p := range current {
+		if !keep[participant.Key(p.Name)] {
+			removed = append(removed, p.Name)
+		}
+	}
+
+	if !changeParticipants && len(added)+len(removed) > 0 {
+		return errParticipantsLocked
+	}
+
+	sch, err := st.Reminder.Get(ctx, reminder.DefaultID)
+	switch {
+	case err == reminder.ErrNotFound:
+		sch = &reminder.Schedule{ID: reminder.DefaultID}
+	case err != nil:
+		return errors.Wrap(err, "getting reminder schedule")
+	}
+
+	sch.RemindTime = ses.RemindTime
+	sch.WeekdaysToSkip = ses.WeekdaysToSkip
+	sch.Message = ses.Message
+
+	if err := st.Reminder.Save(ctx, *sch, now); err != nil {
+		return errors.Wrap(err, "saving reminder schedule")
+	}
+
+	for _, name := range added {
+		if _, err := st.Participant.CreateOrUpdate(ctx, participant.NewParticipant{Name: name}, now); err != nil {
+			return errors.Wrapf(err, "saving participant %s", name)
+		}
+	}
+
+	for _, name := range removed {
+		if err := st.Participant.DeleteByName(ctx, name); err != nil {
+			return errors.Wrapf(err, "deleting participant %s", name)
+		}
+	}
+
+	if err := st.Setup.Delete(ctx, ses.UserID); err != nil {
+		return errors.Wrap(err, "deleting setup session")
+	}
+
+	return nil
+}
+
+// parseWeekdays reads the drafted weekdays to skip. A schedule saved
+// before they were validated may hold invalid ones, then none are skipped.
+func parseWeekdays(list string) map[time.Weekday]struct{} {
+	days, err := reminder.ParseWeekdays(list)
+	if err != nil {
+		return make(map[time.Weekday]struct{})
+	}
+
+	return days
+}
@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/setup"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestSetupText validates the wizard takes typed answers only from replies
+// to its current step.
+func TestSetupText(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	ctx := context.Background()
+
+	session := func() *setup.Session {
+		ses, err := e.st.Setup.Get(ctx, e.user.ID)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to get the setup session : %s.", tests.Failed, err)
+		}
+		return ses
+	}
+
+	t.Log("Given a setup wizard waiting for the remind time.")
+	{
+		e.command(t, "/setup")
+		if !tests.Wait(time.Second, func() bool {
+			_, err := e.st.Setup.Get(ctx, e.user.ID)
+			return err == nil
+		}) {
+			t.Fatalf("\t%s\tShould start a setup session.", tests.Failed)
+		}
+		prompt := e.tg.Requests("sendMessage")[0].MessageID
+		if ses := session(); ses.MessageID != prompt {
+			t.Fatalf("\t%s\tShould remember the prompt : got %d, want %d.", tests.Failed, ses.MessageID, prompt)
+		}
+		t.Logf("\t%s\tShould start a setup session.", tests.Success)
+
+		e.tg.Command(e.chat, e.user, "10:15")
+		e.tg.Reply(e.chat, e.user, prompt+100, "10:15")
+		time.Sleep(200 * time.Millisecond)
+
+		if sent := e.tg.Sent(); len(sent) != 1 {
+			t.Fatalf("\t%s\tShould not answer texts that don't reply to the prompt : got %q.", tests.Failed, sent[1:])
+		}
+		if ses := session(); ses.Step != setup.StepTime || ses.RemindTime != "" {
+			t.Fatalf("\t%s\tShould ignore texts that don't reply to the prompt : got step %s, time %q.", tests.Failed, ses.Step, ses.RemindTime)
+		}
+		t.Logf("\t%s\tShould ignore texts that don't reply to the prompt.", tests.Success)
+
+		if got := e.reply(t, prompt, "10:15"); !strings.HasPrefix(got, "Setup 2/4") {
+			t.Fatalf("\t%s\tShould move to the next step : got %q.", tests.Failed, got)
+		}
+		// The session is saved after the answer is sent.
+		tests.Wait(time.Second, func() bool { return session().Step == setup.StepDays })
+		ses := session()
+		if ses.Step != setup.StepDays || ses.RemindTime != "10:15" {
+			t.Fatalf("\t%s\tShould take the reply as the remind time : got step %s, time %q.", tests.Failed, ses.Step, ses.RemindTime)
+		}
+		if last := e.tg.Requests("sendMessage")[1].MessageID; ses.MessageID != last {
+			t.Fatalf("\t%s\tShould wait for replies to the new prompt : got %d, want %d.", tests.Failed, ses.MessageID, last)
+		}
+		t.Logf("\t%s\tShould take a reply to the prompt as the answer.", tests.Success)
+	}
+}
+
+// TestSetupButtons validates the buttons of a wizard only drive the session
+// of the user who started it, and only with valid answers.
+func TestSetupButtons(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	ctx := context.Background()
+	bob := &tb.User{ID: 9, Username: "bob", FirstName: "Bob"}
+
+	session := func(user *tb.User) *setup.Session {
+		var ses *setup.Session
+		if !tests.Wait(time.Second, func() bool {
+			var err error
+			ses, err = e.st.Setup.Get(ctx, user.ID)
+			return err == nil
+		}) {
+			t.Fatalf("\t%s\tShould start a setup session for %s.", tests.Failed, user.Username)
+		}
+		return ses
+	}
+
+	t.Log("Given a setup wizard started by alice.")
+	{
+		e.command(t, "/setup")
+		prompt := &tb.Message{ID: session(e.user).MessageID, Chat: e.chat}
+
+		e.tg.Press(prompt, bob, "setup_time", "10:00")
+		if got := answer(t, e.tg, 1); got != "You have no setup in progress, run /setup" {
+			t.Fatalf("\t%s\tShould refuse a press without a session : got %q.", tests.Failed, got)
+		}
+		e.tg.Press(prompt, bob, "setup_cancel", "")
+		if got := answer(t, e.tg, 2); got != "You have no setup in progress, run /setup" {
+			t.Fatalf("\t%s\tShould refuse a cancel without a session : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould refuse presses of users without a session.", tests.Success)
+
+		n := len(e.tg.Sent())
+		e.tg.Command(e.chat, bob, "/setup")
+		e.waitSent(t, n+1)
+		own := session(bob).MessageID
+
+		e.tg.Press(prompt, bob, "setup_time", "10:00")
+		if got := answer(t, e.tg, 3); got != "This is not your setup, run /setup" {
+			t.Fatalf("\t%s\tShould refuse a press on the prompt of somebody else : got %q.", tests.Failed, got)
+		}
+		if ses := session(bob); ses.Step != setup.StepTime || ses.MessageID != own {
+			t.Fatalf("\t%s\tShould keep the session of the presser : got step %s, prompt %d.", tests.Failed, ses.Step, ses.MessageID)
+		}
+		if ses := session(e.user); ses.Step != setup.StepTime || ses.MessageID != prompt.ID {
+			t.Fatalf("\t%s\tShould keep the session of the owner : got step %s, prompt %d.", tests.Failed, ses.Step, ses.MessageID)
+		}
+		t.Logf("\t%s\tShould refuse presses on the prompt of somebody else.", tests.Success)
+
+		e.tg.Press(prompt, e.user, "setup_time", "25:00")
+		if got := answer(t, e.tg, 4); got != "Invalid remind time" {
+			t.Fatalf("\t%s\tShould refuse an invalid remind time : got %q.", tests.Failed, got)
+		}
+		if ses := session(e.user); ses.Step != setup.StepTime {
+			t.Fatalf("\t%s\tShould stay at the step : got %s.", tests.Failed, ses.Step)
+		}
+		t.Logf("\t%s\tShould refuse an invalid remind time.", tests.Success)
+
+		e.tg.Press(prompt, e.user, "setup_time", "10:00")
+		answer(t, e.tg, 5)
+		if ses := session(e.user); ses.Step != setup.StepDays || ses.RemindTime != "10:00" || ses.MessageID != prompt.ID {
+			t.Fatalf("\t%s\tShould take the remind time : got %+v.", tests.Failed, ses)
+		}
+		t.Logf("\t%s\tShould take the remind time from the owner.", tests.Success)
+	}
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/state"
+)
+
+const exportFileName = "reminder-bot-state.json"
+
+// importTTL is how long a previewed import waits for confirmation.
+const importTTL = 10 * time.Minute
+
+var (
+	importConfirmBtn = tb.InlineButton{Unique: "import_confirm", Text: "Import"}
+	importCancelBtn  = tb.InlineButton{Unique: "import_cancel", Text: "Cancel"}
+)
+
+// pendingImport is a previewed state document awaiting confirmation.
+type pendingImport struct {
+	state    *state.State
+	userID   int
+	expireAt time.Time
+}
+
+// imports keeps previewed documents by the token attached to their buttons.
+type imports struct {
+	mu      sync.Mutex
+	pending map[string]pendingImport
+}
+
+// add keeps the previewed document and drops the ones that expired by now.
+func (i *imports) add(p pendingImport, now time.Time) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.pending == nil {
+		i.pending = make(map[string]pendingImport)
+	}
+
+	for token, old := range i.pending {
+		if now.After(old.expireAt) {
+			delete(i.pending, token)
+		}
+	}
+
+	token := uuid.New().String()[:8]
+	i.pending[token] = p
+
+	return token
+}
+
+// take removes and returns the document previewed by the user, the ones
+// previewed by others are left alone.
+func (i *imports) take(token string, userID int) (pendingImport, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	p, ok := i.pending[token]
+	if !ok || p.userID != userID {
+		return pendingImport{}, false
+	}
+	delete(i.pending, token)
+
+	return p, true
+}
+
+func (b *Bot) Export(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Export")
+	defer span.End()
+
+	s, err := state.Export(ctx, b.storage)
+	if err != nil {
+		err = errors.Wrap(err, "error exporting state")
+		log.Println("handlers.Bot.Export : error :", err)
+		return
+	}
+
+	data, err := state.Encode(s)
+	if err != nil {
+		err = errors.Wrap(err, "error encoding state")
+		log.Println("handlers.Bot.Export : error :", err)
+		return
+	}
+
+	doc := &tb.Document{
+		File:     tb.FromReader(bytes.NewReader(data)),
+		MIME:     "application/json",
+		FileName: exportFileName,
+		Caption:  fmt.Sprintf("State version %d, reply with /import to restore it", s.Version),
+	}
+
+	b.reply(m, doc)
+}
+
+func (b *Bot) Import(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.Import")
+	defer span.End()
+
+	if !b.isAdmin(m.Sender) {
+		b.reply(m, "Only chat admins can import the bot state")
+		return
+	}
+
+	if m.ReplyTo == nil || m.ReplyTo.Document == nil {
+		b.reply(m, "Reply with /import to a document sent by /export")
+		return
+	}
+
+	rc, err := b.telebot.GetFile(&m.ReplyTo.Document.File)
+	if err != nil {
+		err = errors.Wrap(err, "error downloading document")
+		log.Println("handlers.Bot.Import : error :", err)
+		b.reply(m, "Can't download the document")
+		return
+	}
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		err = errors.Wrap(err, "error reading document")
+		log.Println("handlers.Bot.Import : error :", err)
+		return
+	}
+
+	s, err := state.Decode(data)
+	if err == nil {
+		err = state.Validate(s)
+	}
+	if err != nil {
+		b.reply(m, fmt.Sprintf("Can't import the document: %v", err))
+		return
+	}
+
+	current, err := state.Export(ctx, b.storage)
+	if err != nil {
+		err = errors.Wrap(err, "error exporting current state")
+		log.Println("handlers.Bot.Import : error :", err)
+		return
+	}
+
+	now := b.clock.Now()
+	token := b.imports.add(pendingImport{
+		state:    s,
+		userID:   m.Sender.ID,
+		expireAt: now.Add(importTTL),
+	}, now)
+
+	confirm, cancel := importConfirmBtn, importCancelBtn
+	confirm.Data, cancel.Data = token, token
+
+	preview := fmt.Sprintf(`Import will replace the bot state:
+Participants: %d -> %d
+Reminders: %d -> %d
+Config: %s
+`,
+		len(current.Participants), len(s.Participants),
+		len(current.Reminders), len(s.Reminders),
+		formatConfig(s.Config),
+	)
+
+	b.reply(m, preview, &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{{confirm, cancel}},
+	})
+}
+
+func (b *Bot) ConfirmImport(c *tb.Callback) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.ConfirmImport")
+	defer span.End()
+
+	if c.Sender == nil {
+		return
+	}
+
+	p, ok := b.imports.take(c.Data, c.Sender.ID)
+	if !ok || b.clock.Now().After(p.expireAt) {
+		b.respond(c, "This import is no longer available")
+		return
+	}
+
+	if err := state.Import(ctx, b.storage, *p.state, b.clock.Now()); err != nil {
+		err = errors.Wrap(err, "error importing state")
+		log.Println("handlers.Bot.ConfirmImport : error :", err)
+		b.respond(c, "Import failed, nothing was changed")
+		return
+	}
+
+	if err := b.sync(ctx); err != nil {
+		err = errors.Wrap(err, "error applying imported state")
+		log.Println("handlers.Bot.ConfirmImport : error :", err)
+	}
+
+	b.respond(c, "State imported")
+	b.edit(c.Message, "State imported")
+}
+
+func (b *Bot) CancelImport(c *tb.Callback) {
+	_, span := trace.StartSpan(context.Background(), "handlers.Bot.CancelImport")
+	defer span.End()
+
+	if c.Sender == nil {
+		return
+	}
+
+	if _, ok := b.imports.take(c.Data, c.Sender.ID); !ok {
+		b.respond(c, "This import is no longer available")
+		return
+	}
+
+	b.respond(c, "Import cancelled")
+	b.edit(c.Message, "Import cancelled")
+}
+
+// respond answers a button press with a short notification.
+func (b *Bot) respond(c *tb.Callback, text string) {
+	if err := b.telebot.Respond(c, &tb.CallbackResponse{Text: text}); err != nil {
+		err = errors.Wrap(err, "error responding to callback")
+		log.Println("handlers.Bot.respond : error :", err)
+	}
+}
+
+// edit replaces the text and removes the buttons of a message sent by the bot.
+func (b *Bot) edit(msg *tb.Message, what interface{}, options ...interface{}) {
+	if msg == nil {
+		return
+	}
+
+	if _, err := b.telebot.Edit(msg, what, options...); err != nil {
+		err = errors.Wrap(err, "error editing message")
+		log.Println("handlers.Bot.edit : error :", err)
+	}
+}
+
+func formatConfig(cfg map[config.Name]interface{}) string {
+	var buf bytes.Buffer
+	for _, name := range config.Names {
+		if val, ok := cfg[name]; ok {
+			fmt.Fprintf(&buf, "\n  %s: %v", name, val)
+		}
+	}
+
+	if buf.Len() == 0 {
+		return "defaults"
+	}
+
+	return buf.String()
+}
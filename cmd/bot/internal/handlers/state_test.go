@@ -0,0 +1,122 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/state"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestImportOwnership validates only the admin who previewed an import can
+// confirm it, and a press by someone else doesn't discard it.
+func TestImportOwnership(t *testing.T) {
+	e, teardown := newEnv(t)
+	defer teardown()
+
+	ctx := context.Background()
+	other := &tb.User{ID: 8, Username: "mallory", FirstName: "Mallory"}
+	e.tg.SetAdmins(chatID, e.user, other)
+
+	data, err := state.Encode(&state.State{Participants: []participant.Participant{{Name: "@dave"}}})
+	if err != nil {
+		t.Fatalf("encoding state: %s", err)
+	}
+	e.tg.AddFile("state", data)
+
+	t.Log("Given an import previewed by an admin.")
+	{
+		e.tg.Inject(tb.Update{
+			Message: &tb.Message{
+				ID:       1000,
+				Sender:   e.user,
+				Chat:     e.chat,
+				Text:     "/import",
+				Unixtime: time.Now().Unix(),
+				ReplyTo: &tb.Message{
+					ID:       999,
+					Chat:     e.chat,
+					Document: &tb.Document{File: tb.File{FileID: "state"}, FileName: "reminder-bot-state.json"},
+				},
+			},
+		})
+
+		sent := e.tg.WaitSent(1, time.Second)
+		if len(sent) != 1 || !strings.HasPrefix(sent[0], "Import will replace the bot state") {
+			t.Fatalf("\t%s\tShould preview the import : got %q.", tests.Failed, sent)
+		}
+		token := importToken(t, e.tg.Requests("sendMessage")[0].Params["reply_markup"])
+		t.Logf("\t%s\tShould preview the import.", tests.Success)
+
+		preview := &tb.Message{ID: 1001, Chat: e.chat}
+
+		e.tg.Press(preview, other, "import_confirm", token)
+		if got := answer(t, e.tg, 1); got != "This import is no longer available" {
+			t.Fatalf("\t%s\tShould not let another admin confirm the import : got %q.", tests.Failed, got)
+		}
+		if ps, _ := e.st.Participant.List(ctx); len(ps) != 0 {
+			t.Fatalf("\t%s\tShould not import the state for another admin : got %d participants.", tests.Failed, len(ps))
+		}
+		t.Logf("\t%s\tShould not let another admin confirm the import.", tests.Success)
+
+		e.tg.Press(preview, e.user, "import_confirm", token)
+		if got := answer(t, e.tg, 2); got != "State imported" {
+			t.Fatalf("\t%s\tShould let the admin who previewed it confirm the import : got %q.", tests.Failed, got)
+		}
+		ps, err := e.st.Participant.List(ctx)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list participants : %s.", tests.Failed, err)
+		}
+		if len(ps) != 1 || ps[0].Name != "@dave" {
+			t.Fatalf("\t%s\tShould import the state : got %+v.", tests.Failed, ps)
+		}
+		t.Logf("\t%s\tShould let the admin who previewed it confirm the import.", tests.Success)
+	}
+}
+
+// importToken returns the token attached to the confirmation button of an
+// import preview.
+func importToken(t *testing.T, rawMarkup string) string {
+	t.Helper()
+
+	var markup struct {
+		InlineKeyboard [][]struct {
+			Data string `json:"callback_data"`
+		} `json:"inline_keyboard"`
+	}
+	if err := json.Unmarshal([]byte(rawMarkup), &markup); err != nil {
+		t.Fatalf("decoding reply markup %q: %s", rawMarkup, err)
+	}
+
+	for _, row := range markup.InlineKeyboard {
+		for _, btn := range row {
+			if strings.HasPrefix(btn.Data, "\fimport_confirm|") {
+				return strings.TrimPrefix(btn.Data, "\fimport_confirm|")
+			}
+		}
+	}
+
+	t.Fatalf("no import button in %q", rawMarkup)
+	return ""
+}
+
+// answer waits for the nth answer to a button press and returns its text.
+func answer(t *testing.T, tg *tests.Telegram, n int) string {
+	t.Helper()
+
+	var answers []tests.Request
+	if !tests.Wait(time.Second, func() bool {
+		answers = tg.Requests("answerCallbackQuery")
+		return len(answers) >= n
+	}) {
+		t.Fatalf("\t%s\tShould answer the button press.", tests.Failed)
+	}
+
+	return answers[n-1].Params["text"]
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+)
+
+// syncInterval is how often the reminder picks up changes made through
+// another replica of the bot.
+const syncInterval = 10 * time.Second
+
+// lead delivers reminds, outgoing messages and webhooks while this replica
+// is the leader, until ctx is cancelled. The reminds, escalations and
+// personal reminders a former leader left pending are picked up as well.
+func (b *Bot) lead(ctx context.Context) {
+	b.resumeEscalations(ctx)
+
+	var wg sync.WaitGroup
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		b.queue.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		b.webhooks.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		b.resumeLoop(ctx)
+	}()
+
+	b.scheduler.Run(ctx)
+	wg.Wait()
+}
+
+// syncLoop applies the stored state to the reminder now and every
+// syncInterval until ctx is done.
+func (b *Bot) syncLoop(ctx context.Context) {
+	ticker := b.clock.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.sync(ctx); err != nil {
+			log.Println("handlers.Bot.sync : error :", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// sync applies the stored state to the reminders. Every replica keeps its
+// reminders in line with the storage, and only the one running the scheduler
+// fires them.
+func (b *Bot) sync(ctx context.Context) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.Bot.sync")
+	defer span.End()
+
+	location, err := b.storage.Config.GetByName(ctx, config.Location)
+	switch {
+	case err == nil:
+		loc, err := time.LoadLocation(location.(string))
+		if err != nil {
+			return errors.Wrap(err, "loading location")
+		}
+		b.reminder.SetLocation(loc)
+	case err != config.ErrNotFound:
+		return errors.Wrap(err, "getting location")
+	}
+
+	var rawHolidays string
+	holidays, err := b.storage.Config.GetByName(ctx, config.Holidays)
+	switch {
+	case err == nil:
+		rawHolidays = holidays.(string)
+	case err != config.ErrNotFound:
+		return errors.Wrap(err, "getting holidays")
+	}
+
+	// Holidays removed meanwhile, e.g. by an import, are cleared.
+	if err := b.reminder.SetHolidays(rawHolidays); err != nil {
+		return errors.Wrap(err, "setting holidays")
+	}
+
+	started, err := b.storage.Config.GetByName(ctx, config.BotStarted)
+	switch {
+	case err == config.ErrNotFound:
+		started = false
+	case err != nil:
+		return errors.Wrap(err, "getting started")
+	}
+
+	if err := b.syncOthers(ctx, started.(bool), rawHolidays); err != nil {
+		return errors.Wrap(err, "syncing reminders")
+	}
+
+	if !started.(bool) {
+		return b.reminder.Stop()
+	}
+
+	sch, err := b.schedule(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting reminder schedule")
+	}
+
+	if err := b.reminder.SetWeekdaysToSkip(sch.WeekdaysToSkip); err != nil {
+		return errors.Wrap(err, "setting weekdays to skip")
+	}
+
+	applyPause(b.reminder, sch)
+
+	if err := b.startReminder(sch); err != nil {
+		return errors.Wrap(err, "starting reminder")
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/webhook"
+)
+
+// webhookFailuresLimit is the number of failed attempts /webhookfailures
+// lists.
+const webhookFailuresLimit = 10
+
+// Ways a remind is acknowledged.
+const (
+	acknowledgedByDone  = "done"
+	acknowledgedByCheck = "completion_check"
+)
+
+// firedData is the data of webhook.EventFired.
+type firedData struct {
+	ReminderID   string    `json:"reminder_id"`
+	DueAt        time.Time `json:"due_at"`
+	Text         string    `json:"text"`         // Message posted to the team chat.
+	Participants []string  `json:"participants"` // Participants mentioned and expected to acknowledge the remind.
+}
+
+// acknowledgedData is the data of webhook.EventAcknowledged.
+type acknowledgedData struct {
+	ReminderID     string    `json:"reminder_id"`
+	Participant    string    `json:"participant"`
+	AcknowledgedAt time.Time `json:"acknowledged_at"`
+	By             string    `json:"by"` // Either "done" or "completion_check".
+}
+
+// escalatedData is the data of webhook.EventEscalated.
+type escalatedData struct {
+	ReminderID  string    `json:"reminder_id"`
+	DueAt       time.Time `json:"due_at"`
+	Participant string    `json:"participant"`       // Participant who didn't acknowledge the remind.
+	Step        int       `json:"step"`              // Number of the escalation step, starting from 1.
+	Delay       int       `json:"delay_minutes"`     // Delay of the step after the remind.
+	Action      string    `json:"action"`            // One of "dm", "lead" or "chat".
+	ChatID      string    `json:"chat_id,omitempty"` // Chat of the "chat" action.
+	Lead        string    `json:"lead,omitempty"`    // Lead of the participant for the "lead" action.
+}
+
+// emit posts the event to the webhooks subscribed to it. A failure to emit
+// an event doesn't fail what caused it.
+func (b *Bot) emit(ctx context.Context, event webhook.Event, data interface{}) {
+	if err := b.webhooks.Emit(ctx, event, data); err != nil {
+		err = errors.Wrapf(err, "error emitting %s", event)
+		log.Println("handlers.Bot.emit : error :", err)
+	}
+}
+
+// WebhookFailures lists the latest failed webhook delivery attempts.
+func (b *Bot) WebhookFailures(m *tb.Message) {
+	ctx, span := trace.StartSpan(context.Background(), "handlers.Bot.WebhookFailures")
+	defer span.End()
+
+	if !b.isAdmin(m.Sender) {
+		b.reply(m, "Only chat admins can see webhook failures")
+		return
+	}
+
+	attempts, err := b.storage.Webhook.ListFailedAttempts(ctx, webhookFailuresLimit)
+	if err != nil {
+		err = errors.Wrap(err, "error getting failed webhook attempts")
+		log.Println("handlers.Bot.WebhookFailures : error :", err)
+		return
+	}
+
+	if len(attempts) == 0 {
+		b.reply(m, "No webhook failures")
+		return
+	}
+
+	lines := []string{"Latest webhook failures:"}
+	for _, a := range attempts {
+		lines = append(lines, fmt.Sprintf("%s %s to %s, attempt %d: %s",
+			a.AttemptedAt.In(b.location()).Format(DATE_TIME_LAYOUT),
+			a.Event,
+			a.URL,
+			a.Attempt,
+			a.Error,
+		))
+	}
+
+	b.reply(m, strings.Join(lines, "\n"))
+}
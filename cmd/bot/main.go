@@ -1,33 +1,81 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
 	logger "log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/ardanlabs/conf"
 	"github.com/pkg/errors"
 
 	"github.com/tmowka/telegram-reminder-bot/cmd/bot/internal/handlers"
+	"github.com/tmowka/telegram-reminder-bot/internal/completion"
+	"github.com/tmowka/telegram-reminder-bot/internal/notifier"
 	"github.com/tmowka/telegram-reminder-bot/internal/platform/bot"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
 	"github.com/tmowka/telegram-reminder-bot/internal/platform/database"
 	"github.com/tmowka/telegram-reminder-bot/internal/schema"
+	"github.com/tmowka/telegram-reminder-bot/internal/state"
+	"github.com/tmowka/telegram-reminder-bot/internal/storage"
+	"github.com/tmowka/telegram-reminder-bot/internal/webhook"
 )
 
 type config struct {
 	DB struct {
+		Driver     string `conf:"default:postgres"` // One of postgres, sqlite or memory.
 		User       string `conf:"default:postgres"`
 		Password   string `conf:"default:password,noprint"`
 		Host       string `conf:"default:0.0.0.0"`
 		Name       string `conf:"default:postgres"`
 		DisableTLS bool   `conf:"default:false"`
+		Path       string `conf:"default:bot.db"` // Database file of the sqlite driver.
 	}
 	BOT struct {
 		Token    string `conf:""`
 		Location string `conf:"default:Europe/Minsk"`
 	}
 	CHAT struct {
-		Id string `conf:""`
+		Id     string   `conf:""`
+		Fanout []string `conf:""` // Addresses reminds are also sent to, e.g. "slack:#team;email:team@example.com".
 	}
+	SLACK struct {
+		WebhookURL string        `conf:"noprint"` // Incoming webhook sending to "slack:" addresses, disabled when empty.
+		Timeout    time.Duration `conf:"default:10s"`
+	}
+	SMTP struct {
+		Addr     string        `conf:""` // Mail server sending to "email:" addresses, e.g. "smtp.example.com:587", disabled when empty.
+		Username string        `conf:""`
+		Password string        `conf:"noprint"`
+		From     string        `conf:""`
+		Subject  string        `conf:"default:Reminder"`
+		Timeout  time.Duration `conf:"default:10s"`
+	}
+	API struct {
+		Host            string        `conf:"default:0.0.0.0:3000"`
+		Token           string        `conf:"noprint"` // Bearer token of the HTTP API, the API is disabled when empty.
+		ReadTimeout     time.Duration `conf:"default:5s"`
+		WriteTimeout    time.Duration `conf:"default:5s"`
+		ShutdownTimeout time.Duration `conf:"default:5s"`
+	}
+	CHECK struct {
+		URL     string        `conf:""`                  // Completion API template, e.g. "https://timesheet/api/filled?user={{.Username}}&date={{.Date}}", disabled when empty.
+		Field   string        `conf:"default:completed"` // JSON field of the response telling whether the task is completed.
+		Token   string        `conf:"noprint"`           // Bearer token of the completion API.
+		Timeout time.Duration `conf:"default:10s"`
+	}
+	WEBHOOK struct {
+		Urls   []string `conf:""`        // Endpoints the reminder events are posted to, separated by ";".
+		Secret string   `conf:"noprint"` // Key of the HMAC-SHA256 signature of the payloads.
+		Events []string `conf:""`        // Events to post, e.g. "reminder.fired;reminder.escalated", all of them if empty.
+	}
+	Args conf.Args // Optional command: "export <file>" or "import <file>".
 }
 
 func main() {
@@ -42,7 +90,16 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := run(cfg); err != nil {
+	switch cfg.Args.Num(0) {
+	case "export":
+		err = exportState(cfg, cfg.Args.Num(1))
+	case "import":
+		err = importState(cfg, cfg.Args.Num(1))
+	default:
+		err = run(cfg)
+	}
+
+	if err != nil {
 		logger.Println("error :", err)
 		os.Exit(1)
 	}
@@ -55,34 +112,19 @@ func run(cfg *config) error {
 		logger.LstdFlags|logger.Lmicroseconds|logger.Lshortfile)
 
 	// =========================================================================
-	// Start Database
+	// Start Storage
 
-	log.Println("main : Started : Initializing database support")
+	log.Println("main : Started : Initializing storage support")
 
-	db, err := database.Open(database.Config{
-		User:       cfg.DB.User,
-		Password:   cfg.DB.Password,
-		Host:       cfg.DB.Host,
-		Name:       cfg.DB.Name,
-		DisableTLS: cfg.DB.DisableTLS,
-	})
+	st, closeStorage, err := openStorage(cfg)
 	if err != nil {
-		return errors.Wrap(err, "connecting to db")
+		return err
 	}
 	defer func() {
-		log.Printf("main : Database Stopping : %s", cfg.DB.Host)
-		db.Close()
+		log.Printf("main : Storage Stopping : %s", cfg.DB.Driver)
+		closeStorage()
 	}()
 
-	// =========================================================================
-	// Migrate Database
-
-	log.Println("main : Started : Migrating database")
-
-	if err := schema.Migrate(db); err != nil {
-		return errors.Wrap(err, "migrating db")
-	}
-
 	// =========================================================================
 	// Start Bot
 
@@ -95,13 +137,92 @@ func run(cfg *config) error {
 		return errors.Wrap(err, "creating telebot")
 	}
 
-	err = handlers.Telebot(db, b, cfg.CHAT.Id, cfg.BOT.Location)
+	checker, err := completionChecker(cfg)
+	if err != nil {
+		return err
+	}
+
+	endpoints, err := webhookEndpoints(cfg)
+	if err != nil {
+		return err
+	}
+
+	transports, err := notifiers(cfg)
+	if err != nil {
+		return err
+	}
+
+	reminderBot, err := handlers.Telebot(handlers.Config{
+		Storage:    st,
+		Telebot:    b,
+		Clock:      clock.Real{},
+		ChatID:     cfg.CHAT.Id,
+		Location:   cfg.BOT.Location,
+		Checker:    checker,
+		Endpoints:  endpoints,
+		Transports: transports,
+		Fanout:     cfg.CHAT.Fanout,
+		Settings:   settings(cfg, os.Args[1:]),
+	})
 	if err != nil {
 		return errors.Wrap(err, "registration of telebot handlers")
 	}
+	defer func() {
+		log.Println("main : Bot Stopping : Releasing the lease")
+		reminderBot.Shutdown()
+	}()
+
+	// Make a channel to listen for errors coming from the listener. Use a
+	// buffered channel so the goroutine can exit if we don't collect this error.
+	serverErrors := make(chan error, 1)
+
+	var api *http.Server
+	if cfg.API.Token != "" {
+		api = &http.Server{
+			Addr:         cfg.API.Host,
+			Handler:      reminderBot.API(log, cfg.API.Token),
+			ReadTimeout:  cfg.API.ReadTimeout,
+			WriteTimeout: cfg.API.WriteTimeout,
+		}
+
+		go func() {
+			log.Printf("main : API listening on %s", api.Addr)
+			serverErrors <- api.ListenAndServe()
+		}()
+	} else {
+		log.Println("main : API disabled : no token set")
+	}
 
 	log.Println("main : Started : Starting telebot")
-	b.Start()
+	go b.Start()
+
+	// Make a channel to listen for an interrupt or terminate signal from the OS.
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	// =========================================================================
+	// Shutdown
+
+	select {
+	case err := <-serverErrors:
+		b.Stop()
+		return errors.Wrap(err, "server error")
+
+	case sig := <-shutdown:
+		log.Printf("main : %v : Start shutdown", sig)
+
+		b.Stop()
+
+		if api != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.API.ShutdownTimeout)
+			defer cancel()
+
+			if err := api.Shutdown(ctx); err != nil {
+				api.Close()
+				return errors.Wrap(err, "could not stop API gracefully")
+			}
+		}
+	}
 
 	return nil
 }
@@ -115,15 +236,14 @@ func migrate(cfg *config) error {
 	// =========================================================================
 	// Migrate Database
 
+	if cfg.DB.Driver == storage.Memory {
+		log.Println("migrate : Skipped : Nothing to migrate in memory")
+		return nil
+	}
+
 	log.Println("migrate : Started : Migrating database")
 
-	db, err := database.Open(database.Config{
-		User:       cfg.DB.User,
-		Password:   cfg.DB.Password,
-		Host:       cfg.DB.Host,
-		Name:       cfg.DB.Name,
-		DisableTLS: cfg.DB.DisableTLS,
-	})
+	db, err := database.Open(dbConfig(cfg))
 	if err != nil {
 		return errors.Wrap(err, "connecting to db")
 	}
@@ -137,6 +257,251 @@ func migrate(cfg *config) error {
 	return nil
 }
 
+// openStorage opens the configured storage backend. The database ones are
+// expected to be migrated already.
+func openStorage(cfg *config) (*storage.Storage, func(), error) {
+	switch cfg.DB.Driver {
+	case storage.Memory:
+		return storage.NewMemory(), func() {}, nil
+	case storage.Postgres, storage.SQLite:
+		db, err := database.Open(dbConfig(cfg))
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "connecting to db")
+		}
+		return storage.NewDB(db), func() { db.Close() }, nil
+	default:
+		return nil, nil, errors.Errorf("unknown storage driver: %s", cfg.DB.Driver)
+	}
+}
+
+// completionChecker returns the configured completion checker, nil if the
+// completion isn't checked.
+func completionChecker(cfg *config) (completion.Checker, error) {
+	if cfg.CHECK.URL == "" {
+		return nil, nil
+	}
+
+	checker, err := completion.NewHTTP(completion.HTTPConfig{
+		URL:     cfg.CHECK.URL,
+		Field:   cfg.CHECK.Field,
+		Token:   cfg.CHECK.Token,
+		Timeout: cfg.CHECK.Timeout,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "creating completion checker")
+	}
+
+	return checker, nil
+}
+
+// notifiers returns the configured transports besides Telegram by the
+// scheme of their addresses.
+func notifiers(cfg *config) (map[string]notifier.Notifier, error) {
+	transports := make(map[string]notifier.Notifier)
+
+	if cfg.SLACK.WebhookURL != "" {
+		slack, err := notifier.NewSlack(notifier.SlackConfig{
+			WebhookURL: cfg.SLACK.WebhookURL,
+			Timeout:    cfg.SLACK.Timeout,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "creating slack notifier")
+		}
+		transports["slack"] = slack
+	}
+
+	if cfg.SMTP.Addr != "" {
+		email, err := notifier.NewSMTP(notifier.SMTPConfig{
+			Addr:     cfg.SMTP.Addr,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+			Subject:  cfg.SMTP.Subject,
+			Timeout:  cfg.SMTP.Timeout,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "creating email notifier")
+		}
+		transports["email"] = email
+	}
+
+	return transports, nil
+}
+
+// webhookEndpoints returns the configured webhook endpoints.
+func webhookEndpoints(cfg *config) ([]webhook.Endpoint, error) {
+	var events []webhook.Event
+	for _, raw := range cfg.WEBHOOK.Events {
+		event := webhook.Event(strings.TrimSpace(raw))
+		if event == "" {
+			continue
+		}
+
+		known := false
+		for _, e := range webhook.Events {
+			known = known || e == event
+		}
+		if !known {
+			return nil, errors.Errorf("unknown webhook event %q", event)
+		}
+
+		events = append(events, event)
+	}
+
+	var endpoints []webhook.Endpoint
+	for _, url := range cfg.WEBHOOK.Urls {
+		if url = strings.TrimSpace(url); url == "" {
+			continue
+		}
+
+		endpoints = append(endpoints, webhook.Endpoint{
+			URL:    url,
+			Secret: cfg.WEBHOOK.Secret,
+			Events: events,
+		})
+	}
+
+	return endpoints, nil
+}
+
+func exportState(cfg *config, path string) error {
+	if path == "" {
+		return errors.New("usage: export <file>")
+	}
+
+	st, closeStorage, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	s, err := state.Export(context.Background(), st)
+	if err != nil {
+		return errors.Wrap(err, "exporting state")
+	}
+
+	data, err := state.Encode(s)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return errors.Wrap(err, "writing state")
+	}
+
+	return nil
+}
+
+func importState(cfg *config, path string) error {
+	if path == "" {
+		return errors.New("usage: import <file>")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "reading state")
+	}
+
+	s, err := state.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	st, closeStorage, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	if err := state.Import(context.Background(), st, *s, time.Now()); err != nil {
+		return errors.Wrap(err, "importing state")
+	}
+
+	return nil
+}
+
+// settings reports the effective startup settings and where each of them
+// came from, flags taking precedence over environment variables.
+func settings(cfg *config, args []string) []handlers.Setting {
+	fields := []struct {
+		key   string
+		value interface{}
+	}{
+		{"db-driver", cfg.DB.Driver},
+		{"db-user", cfg.DB.User},
+		{"db-password", "***"},
+		{"db-host", cfg.DB.Host},
+		{"db-name", cfg.DB.Name},
+		{"db-disable-tls", cfg.DB.DisableTLS},
+		{"db-path", cfg.DB.Path},
+		{"bot-token", "***"},
+		{"bot-location", cfg.BOT.Location},
+		{"chat-id", cfg.CHAT.Id},
+		{"chat-fanout", strings.Join(cfg.CHAT.Fanout, ";")},
+		{"slack-webhook-url", "***"},
+		{"slack-timeout", cfg.SLACK.Timeout},
+		{"smtp-addr", cfg.SMTP.Addr},
+		{"smtp-username", cfg.SMTP.Username},
+		{"smtp-password", "***"},
+		{"smtp-from", cfg.SMTP.From},
+		{"smtp-subject", cfg.SMTP.Subject},
+		{"smtp-timeout", cfg.SMTP.Timeout},
+		{"api-host", cfg.API.Host},
+		{"api-token", "***"},
+		{"api-read-timeout", cfg.API.ReadTimeout},
+		{"api-write-timeout", cfg.API.WriteTimeout},
+		{"api-shutdown-timeout", cfg.API.ShutdownTimeout},
+		{"webhook-urls", strings.Join(cfg.WEBHOOK.Urls, ";")},
+		{"webhook-secret", "***"},
+		{"webhook-events", strings.Join(cfg.WEBHOOK.Events, ";")},
+		{"check-url", cfg.CHECK.URL},
+		{"check-field", cfg.CHECK.Field},
+		{"check-token", "***"},
+		{"check-timeout", cfg.CHECK.Timeout},
+	}
+
+	settings := make([]handlers.Setting, len(fields))
+	for i, f := range fields {
+		source := handlers.SourceDefault
+
+		env := "BOT_" + strings.ToUpper(strings.Replace(f.key, "-", "_", -1))
+		if _, ok := os.LookupEnv(env); ok {
+			source = handlers.SourceEnv
+		}
+
+		for _, arg := range args {
+			if arg == "--"+f.key || strings.HasPrefix(arg, "--"+f.key+"=") {
+				source = handlers.SourceFlag
+			}
+		}
+
+		settings[i] = handlers.Setting{
+			Name:   f.key,
+			Value:  fmt.Sprint(f.value),
+			Source: source,
+		}
+	}
+
+	return settings
+}
+
+func dbConfig(cfg *config) database.Config {
+	driver := database.Postgres
+	if cfg.DB.Driver == storage.SQLite {
+		driver = database.SQLite
+	}
+
+	return database.Config{
+		Driver:     driver,
+		User:       cfg.DB.User,
+		Password:   cfg.DB.Password,
+		Host:       cfg.DB.Host,
+		Name:       cfg.DB.Name,
+		DisableTLS: cfg.DB.DisableTLS,
+		Path:       cfg.DB.Path,
+	}
+}
+
 func configure() (*config, error) {
 	// =========================================================================
 	// Logging
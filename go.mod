@@ -9,6 +9,7 @@ require (
 	github.com/google/uuid v1.1.1
 	github.com/jmoiron/sqlx v1.2.0
 	github.com/lib/pq v1.5.2
+	github.com/mattn/go-sqlite3 v1.14.14
 	github.com/pkg/errors v0.9.1
 	go.opencensus.io v0.22.3
 	gopkg.in/tucnak/telebot.v2 v2.0.0-20200426184946-59629fe0483e
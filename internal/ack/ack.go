@@ -0,0 +1,112 @@
+// Package ack keeps track of the participants who acknowledged a remind and
+// of the escalation of the ones who didn't.
+package ack
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Store is the repository of acknowledgements.
+type Store interface {
+	// Expect records that the participants owe an acknowledgement for the
+	// remind due at dueAt.
+	Expect(ctx context.Context, reminderID string, dueAt time.Time, participantIDs []string, now time.Time) error
+
+	// Acknowledge closes every open acknowledgement of the participant for
+	// the reminder and returns how many there were.
+	Acknowledge(ctx context.Context, reminderID string, participantID string, now time.Time) (int, error)
+
+	// ListOpen returns the open acknowledgements of reminds due since the
+	// given moment, the earliest first.
+	ListOpen(ctx context.Context, since time.Time) ([]Ack, error)
+
+	// SetEscalated records the number of escalation steps taken for an open
+	// acknowledgement.
+	SetEscalated(ctx context.Context, reminderID string, dueAt time.Time, participantID string, steps int, now time.Time) error
+}
+
+// dbStore keeps acknowledgements in the acks table of a Postgres or SQLite
+// database.
+type dbStore struct {
+	db sqlx.ExtContext
+}
+
+// NewDBStore returns a Store backed by the acks table of db.
+func NewDBStore(db sqlx.ExtContext) Store {
+	return &dbStore{db: db}
+}
+
+func (s *dbStore) Expect(ctx context.Context, reminderID string, dueAt time.Time, participantIDs []string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.ack.Expect")
+	defer span.End()
+
+	const q = `insert into acks
+		(reminder_id, due_at, participant_id, escalated, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6)
+		on conflict (reminder_id, due_at, participant_id) do nothing`
+
+	for _, id := range participantIDs {
+		if _, err := s.db.ExecContext(ctx, q, reminderID, dueAt.UTC(), id, 0, now.UTC(), now.UTC()); err != nil {
+			return errors.Wrap(err, "inserting ack")
+		}
+	}
+
+	return nil
+}
+
+func (s *dbStore) Acknowledge(ctx context.Context, reminderID string, participantID string, now time.Time) (int, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.ack.Acknowledge")
+	defer span.End()
+
+	const q = `update acks
+		set acked_at = $1, updated_at = $2
+		where reminder_id = $3 and participant_id = $4 and acked_at is null`
+
+	res, err := s.db.ExecContext(ctx, q, now.UTC(), now.UTC(), reminderID, participantID)
+	if err != nil {
+		return 0, errors.Wrap(err, "acknowledging")
+	}
+
+	upd, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "acknowledging")
+	}
+
+	return int(upd), nil
+}
+
+func (s *dbStore) ListOpen(ctx context.Context, since time.Time) ([]Ack, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.ack.ListOpen")
+	defer span.End()
+
+	var acks []Ack
+	const q = `select * from acks
+		where acked_at is null and due_at >= $1
+		order by due_at`
+
+	if err := sqlx.SelectContext(ctx, s.db, &acks, q, since.UTC()); err != nil {
+		return nil, errors.Wrap(err, "selecting open acks")
+	}
+
+	return acks, nil
+}
+
+func (s *dbStore) SetEscalated(ctx context.Context, reminderID string, dueAt time.Time, participantID string, steps int, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.ack.SetEscalated")
+	defer span.End()
+
+	const q = `update acks
+		set escalated = $1, updated_at = $2
+		where reminder_id = $3 and due_at = $4 and participant_id = $5`
+
+	if _, err := s.db.ExecContext(ctx, q, steps, now.UTC(), reminderID, dueAt.UTC(), participantID); err != nil {
+		return errors.Wrap(err, "recording escalation")
+	}
+
+	return nil
+}
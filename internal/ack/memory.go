@@ -0,0 +1,103 @@
+package ack
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type key struct {
+	reminderID    string
+	dueAt         int64
+	participantID string
+}
+
+// memoryStore keeps acknowledgements in process memory. It is meant for
+// tests and short-lived deployments, nothing survives a restart.
+type memoryStore struct {
+	mu   sync.Mutex
+	acks map[key]Ack
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() Store {
+	return &memoryStore{acks: make(map[key]Ack)}
+}
+
+func (s *memoryStore) Expect(ctx context.Context, reminderID string, dueAt time.Time, participantIDs []string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range participantIDs {
+		k := key{reminderID: reminderID, dueAt: dueAt.UnixNano(), participantID: id}
+		if _, ok := s.acks[k]; ok {
+			continue
+		}
+
+		s.acks[k] = Ack{
+			ReminderID:    reminderID,
+			DueAt:         dueAt.UTC(),
+			ParticipantID: id,
+			CreatedAt:     now.UTC(),
+			UpdatedAt:     now.UTC(),
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStore) Acknowledge(ctx context.Context, reminderID string, participantID string, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for k, a := range s.acks {
+		if a.ReminderID != reminderID || a.ParticipantID != participantID || a.AckedAt != nil {
+			continue
+		}
+
+		acked := now.UTC()
+		a.AckedAt = &acked
+		a.UpdatedAt = now.UTC()
+		s.acks[k] = a
+		n++
+	}
+
+	return n, nil
+}
+
+func (s *memoryStore) ListOpen(ctx context.Context, since time.Time) ([]Ack, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var acks []Ack
+	for _, a := range s.acks {
+		if a.AckedAt == nil && !a.DueAt.Before(since) {
+			acks = append(acks, a)
+		}
+	}
+
+	sort.Slice(acks, func(i, j int) bool {
+		return acks[i].DueAt.Before(acks[j].DueAt)
+	})
+
+	return acks, nil
+}
+
+func (s *memoryStore) SetEscalated(ctx context.Context, reminderID string, dueAt time.Time, participantID string, steps int, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := key{reminderID: reminderID, dueAt: dueAt.UnixNano(), participantID: participantID}
+	a, ok := s.acks[k]
+	if !ok {
+		return nil
+	}
+
+	a.Escalated = steps
+	a.UpdatedAt = now.UTC()
+	s.acks[k] = a
+
+	return nil
+}
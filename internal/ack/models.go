@@ -0,0 +1,15 @@
+package ack
+
+import "time"
+
+// Ack is the acknowledgement a participant owes for a remind. It is open
+// until AckedAt is set.
+type Ack struct {
+	ReminderID    string     `db:"reminder_id" json:"reminder_id"`       // Reminder that fired.
+	DueAt         time.Time  `db:"due_at" json:"due_at"`                 // When the remind was due.
+	ParticipantID string     `db:"participant_id" json:"participant_id"` // Participant mentioned in the remind.
+	AckedAt       *time.Time `db:"acked_at" json:"acked_at,omitempty"`   // When the participant acknowledged the remind.
+	Escalated     int        `db:"escalated" json:"escalated"`           // Number of escalation steps taken.
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`         // When the remind was queued.
+	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`         // When the ack record was last modified.
+}
@@ -0,0 +1,17 @@
+// Package completion verifies whether participants did what a remind asks
+// for, so the bot mentions only the ones who didn't.
+package completion
+
+import (
+	"context"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+)
+
+// Checker reports whether a participant completed the task of the remind
+// due at the given moment. The date of the remind is the one in the time
+// zone of dueAt.
+type Checker interface {
+	Completed(ctx context.Context, p participant.Participant, dueAt time.Time) (bool, error)
+}
@@ -0,0 +1,170 @@
+package completion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+)
+
+// HTTPConfig describes the endpoint an HTTP checker asks.
+type HTTPConfig struct {
+	// URL is a text/template of the address to ask for every participant,
+	// e.g. "https://timesheet/api/filled?user={{.Username}}&date={{.Date}}".
+	// The fields are Name ("@alice"), Username ("alice"), UserID and Date
+	// ("2006-01-02" in the time zone of the due time), all of them already
+	// escaped for a URL.
+	URL     string
+	Field   string        // JSON field of the response holding the result, "completed" by default, nested ones as "data.completed".
+	Token   string        // Sent as a bearer token when set.
+	Timeout time.Duration // Timeout of a single request.
+	Client  *http.Client  // Client making the requests, one with Timeout by default.
+}
+
+// HTTP is a Checker asking a JSON API, e.g. a timesheet one, about every
+// participant. The response is expected to be a JSON object with a boolean
+// in Field, any status other than 2xx is an error.
+type HTTP struct {
+	url *template.Template
+	cfg HTTPConfig
+}
+
+// urlData is what the URL template is executed with.
+type urlData struct {
+	Name     string
+	Username string
+	UserID   string
+	Date     string
+}
+
+func NewHTTP(cfg HTTPConfig) (*HTTP, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("URL is required")
+	}
+	if cfg.Field == "" {
+		cfg.Field = "completed"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	t, err := template.New("url").Option("missingkey=error").Parse(cfg.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing URL template")
+	}
+
+	h := HTTP{
+		url: t,
+		cfg: cfg,
+	}
+
+	return &h, nil
+}
+
+func (h *HTTP) Completed(ctx context.Context, p participant.Participant, dueAt time.Time) (bool, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.completion.HTTP.Completed")
+	defer span.End()
+
+	address, err := h.address(p, dueAt)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, address, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "creating request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if h.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.cfg.Token)
+	}
+
+	resp, err := h.cfg.Client.Do(req)
+	if err != nil {
+		return false, errors.Wrapf(err, "checking %s", p.Name)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return false, errors.Wrapf(err, "reading response for %s", p.Name)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false, errors.Errorf("checking %s: unexpected status %s", p.Name, resp.Status)
+	}
+
+	completed, err := field(body, h.cfg.Field)
+	if err != nil {
+		return false, errors.Wrapf(err, "interpreting response for %s", p.Name)
+	}
+
+	return completed, nil
+}
+
+// address returns the URL to ask about p.
+func (h *HTTP) address(p participant.Participant, dueAt time.Time) (string, error) {
+	data := urlData{
+		Name:     url.QueryEscape(p.Name),
+		Username: url.QueryEscape(strings.TrimPrefix(p.Name, "@")),
+		Date:     dueAt.Format("2006-01-02"),
+	}
+	if p.UserID != 0 {
+		data.UserID = strconv.FormatInt(p.UserID, 10)
+	}
+
+	var buf bytes.Buffer
+	if err := h.url.Execute(&buf, data); err != nil {
+		return "", errors.Wrap(err, "executing URL template")
+	}
+
+	if _, err := url.Parse(buf.String()); err != nil {
+		return "", errors.Wrap(err, "parsing URL")
+	}
+
+	return buf.String(), nil
+}
+
+// field returns the boolean at the dot separated path of a JSON object.
+func field(body []byte, path string) (bool, error) {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return false, err
+	}
+
+	for _, name := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return false, errors.Errorf("%q is not an object", path)
+		}
+		if v, ok = obj[name]; !ok {
+			return false, errors.Errorf("no %q field", path)
+		}
+	}
+
+	completed, ok := v.(bool)
+	if !ok {
+		return false, errors.Errorf("%q is not a boolean", path)
+	}
+
+	return completed, nil
+}
@@ -0,0 +1,128 @@
+package completion_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/completion"
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestHTTP validates the HTTP checker asks about every participant on the
+// date of the remind and reads the answer.
+func TestHTTP(t *testing.T) {
+	api := tests.NewCompletion()
+	defer api.Close()
+
+	checker, err := completion.NewHTTP(completion.HTTPConfig{
+		URL: api.URL() + "/completed?user={{.Username}}&date={{.Date}}",
+	})
+	if err != nil {
+		t.Fatalf("creating checker: %s", err)
+	}
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("loading location: %s", err)
+	}
+
+	// 23:30 UTC on 2 March is already 3 March in Tokyo.
+	dueAt := time.Date(2020, 3, 2, 23, 30, 0, 0, time.UTC).In(tokyo)
+
+	api.Complete("alice", "2020-03-03")
+	api.Complete("bob", "2020-03-02")
+	api.Fail("carol")
+
+	ctx := context.Background()
+
+	t.Log("Given a completion API knowing who completed the task.")
+	{
+		done, err := checker.Completed(ctx, participant.Participant{Name: "@alice"}, dueAt)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to check alice : %s.", tests.Failed, err)
+		}
+		if !done {
+			t.Fatalf("\t%s\tShould report alice completed the task.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould report alice completed the task.", tests.Success)
+
+		done, err = checker.Completed(ctx, participant.Participant{Name: "@bob"}, dueAt)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to check bob : %s.", tests.Failed, err)
+		}
+		if done {
+			t.Fatalf("\t%s\tShould ask about the date in the time zone of the remind.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould ask about the date in the time zone of the remind.", tests.Success)
+
+		if _, err := checker.Completed(ctx, participant.Participant{Name: "@carol"}, dueAt); err == nil || !strings.Contains(err.Error(), "500") {
+			t.Fatalf("\t%s\tShould fail on an error status : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould fail on an error status.", tests.Success)
+
+		if got, want := strings.Join(api.Asked(), ","), "alice,bob,carol"; got != want {
+			t.Fatalf("\t%s\tShould ask about every participant once : got %s, want %s.", tests.Failed, got, want)
+		}
+		t.Logf("\t%s\tShould ask about every participant once.", tests.Success)
+	}
+}
+
+// TestHTTPResponse validates the HTTP checker sends the token and reads the
+// configured field of the response.
+func TestHTTPResponse(t *testing.T) {
+	tt := []struct {
+		name string
+		body string
+		done bool
+		err  string
+	}{
+		{name: "nested true", body: `{"data": {"filled": true}}`, done: true},
+		{name: "nested false", body: `{"data": {"filled": false}}`},
+		{name: "missing field", body: `{"data": {}}`, err: `no "data.filled" field`},
+		{name: "not an object", body: `{"data": [true]}`, err: `"data.filled" is not an object`},
+		{name: "not a boolean", body: `{"data": {"filled": "yes"}}`, err: `"data.filled" is not a boolean`},
+		{name: "not JSON", body: `yes`, err: "interpreting response"},
+	}
+
+	t.Log("Given a completion API answering with a nested field.")
+	{
+		for _, tc := range tt {
+			var auth, user string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth, user = r.Header.Get("Authorization"), r.URL.Query().Get("user")
+				w.Write([]byte(tc.body))
+			}))
+
+			checker, err := completion.NewHTTP(completion.HTTPConfig{
+				URL:   srv.URL + "/?user={{.Name}}&id={{.UserID}}",
+				Field: "data.filled",
+				Token: "secret",
+			})
+			if err != nil {
+				srv.Close()
+				t.Fatalf("creating checker: %s", err)
+			}
+
+			done, err := checker.Completed(context.Background(), participant.Participant{Name: "@alice", UserID: 7}, time.Now())
+			srv.Close()
+
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("\t%s\tShould read a response with %s : %s.", tests.Failed, tc.name, err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("\t%s\tShould reject a response with %s : got %v, want %q.", tests.Failed, tc.name, err, tc.err)
+			case done != tc.done:
+				t.Fatalf("\t%s\tShould read a response with %s : got %t.", tests.Failed, tc.name, done)
+			}
+			if auth != "Bearer secret" || user != "@alice" {
+				t.Fatalf("\t%s\tShould ask with the token about @alice : got %q, %q.", tests.Failed, auth, user)
+			}
+			t.Logf("\t%s\tShould handle a response with %s.", tests.Success, tc.name)
+		}
+	}
+}
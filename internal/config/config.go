@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,20 +18,72 @@ var (
 	ErrNotFound = errors.New("Config not found")
 )
 
-func GetByName(ctx context.Context, db *sqlx.DB, name Name) (interface{}, error) {
+// Validate checks that val is acceptable for the config name.
+func Validate(name Name, val interface{}) error {
+	switch name {
+	case BotStarted, AutoSync, ParticipantsLocked:
+		if _, ok := val.(bool); !ok {
+			return errors.Errorf("config %s must be a boolean", name)
+		}
+	case Location:
+		loc, ok := val.(string)
+		if !ok {
+			return errors.Errorf("config %s must be a string", name)
+		}
+		if _, err := time.LoadLocation(loc); err != nil {
+			return errors.Wrapf(err, "config %s", name)
+		}
+	case Holidays:
+		dates, ok := val.(string)
+		if !ok {
+			return errors.Errorf("config %s must be a string", name)
+		}
+		for _, date := range strings.Split(dates, ",") {
+			if date = strings.TrimSpace(date); date == "" {
+				continue
+			}
+			if _, err := time.Parse("2006-01-02", date); err != nil {
+				return errors.Errorf("config %s: invalid date %q, expected format 2006-01-02", name, date)
+			}
+		}
+	default:
+		return errors.Errorf("unknown config name: %s", name)
+	}
+
+	return nil
+}
+
+// Store is the repository of the bot configuration.
+type Store interface {
+	GetByName(ctx context.Context, name Name) (interface{}, error)
+	Save(ctx context.Context, name Name, val interface{}, now time.Time) error
+	Delete(ctx context.Context, name Name) error
+}
+
+// dbStore keeps the configuration in the config table of a Postgres or
+// SQLite database.
+type dbStore struct {
+	db sqlx.ExtContext
+}
+
+// NewDBStore returns a Store backed by the config table of db.
+func NewDBStore(db sqlx.ExtContext) Store {
+	return &dbStore{db: db}
+}
+
+func (s *dbStore) GetByName(ctx context.Context, name Name) (interface{}, error) {
 	ctx, span := trace.StartSpan(ctx, "internal.config.GetByName")
 	defer span.End()
 
 	var bc BooleanConfig
-	//var ic IntegerConfig
 	var sc StringConfig
 
 	const q = `select * from config
 		where name = $1`
 
 	switch name {
-	case BotStarted:
-		if err := db.GetContext(ctx, &bc, q, name); err != nil {
+	case BotStarted, AutoSync, ParticipantsLocked:
+		if err := sqlx.GetContext(ctx, s.db, &bc, q, name); err != nil {
 			if err == sql.ErrNoRows {
 				return nil, ErrNotFound
 			}
@@ -38,8 +91,8 @@ func GetByName(ctx context.Context, db *sqlx.DB, name Name) (interface{}, error)
 			return nil, errors.Wrap(err, "selecting boolean config by name")
 		}
 		return bc.Value, nil
-	case RemindMessage, RemindTime, WeekdaysToSkip:
-		if err := db.GetContext(ctx, &sc, q, name); err != nil {
+	case Location, Holidays:
+		if err := sqlx.GetContext(ctx, s.db, &sc, q, name); err != nil {
 			if err == sql.ErrNoRows {
 				return nil, ErrNotFound
 			}
@@ -53,7 +106,7 @@ func GetByName(ctx context.Context, db *sqlx.DB, name Name) (interface{}, error)
 	}
 }
 
-func Save(ctx context.Context, db *sqlx.DB, name Name, val interface{}, now time.Time) error {
+func (s *dbStore) Save(ctx context.Context, name Name, val interface{}, now time.Time) error {
 	ctx, span := trace.StartSpan(ctx, "internal.config.Save")
 	defer span.End()
 
@@ -65,7 +118,7 @@ func Save(ctx context.Context, db *sqlx.DB, name Name, val interface{}, now time
 		values ($1, $2, $3, $4, $5)`
 
 	switch name {
-	case BotStarted:
+	case BotStarted, AutoSync, ParticipantsLocked:
 		cfg := &BooleanConfig{
 			config: config{
 				ID:   uuid.New().String(),
@@ -74,7 +127,7 @@ func Save(ctx context.Context, db *sqlx.DB, name Name, val interface{}, now time
 			Value: val.(bool),
 		}
 
-		res, err := db.ExecContext(ctx, updateQ,
+		res, err := s.db.ExecContext(ctx, updateQ,
 			cfg.Value, now.UTC(), cfg.Name,
 		)
 		if err != nil {
@@ -89,13 +142,13 @@ func Save(ctx context.Context, db *sqlx.DB, name Name, val interface{}, now time
 			return nil
 		}
 
-		_, err = db.ExecContext(ctx, insertQ,
+		_, err = s.db.ExecContext(ctx, insertQ,
 			cfg.ID, cfg.Name, cfg.Value, now.UTC(), now.UTC())
 		if err != nil {
 			return errors.Wrap(err, "inserting config")
 		}
 		return nil
-	case RemindMessage, RemindTime, WeekdaysToSkip:
+	case Location, Holidays:
 		cfg := &StringConfig{
 			config: config{
 				ID:   uuid.New().String(),
@@ -104,7 +157,7 @@ func Save(ctx context.Context, db *sqlx.DB, name Name, val interface{}, now time
 			Value: "\"" + val.(string) + "\"",
 		}
 
-		res, err := db.ExecContext(ctx, updateQ,
+		res, err := s.db.ExecContext(ctx, updateQ,
 			cfg.Value, now.UTC(), cfg.Name,
 		)
 		if err != nil {
@@ -119,7 +172,7 @@ func Save(ctx context.Context, db *sqlx.DB, name Name, val interface{}, now time
 			return nil
 		}
 
-		_, err = db.ExecContext(ctx, insertQ,
+		_, err = s.db.ExecContext(ctx, insertQ,
 			cfg.ID, cfg.Name, cfg.Value, now.UTC(), now.UTC())
 		if err != nil {
 			return errors.Wrap(err, "inserting config")
@@ -129,3 +182,19 @@ func Save(ctx context.Context, db *sqlx.DB, name Name, val interface{}, now time
 		return errors.Errorf("unknown config name to insert: %s", name)
 	}
 }
+
+// Delete removes the config, so the default applies again. Deleting a config
+// that was never saved does nothing.
+func (s *dbStore) Delete(ctx context.Context, name Name) error {
+	ctx, span := trace.StartSpan(ctx, "internal.config.Delete")
+	defer span.End()
+
+	const q = `delete from config
+		where name = $1`
+
+	if _, err := s.db.ExecContext(ctx, q, name); err != nil {
+		return errors.Wrapf(err, "deleting config %s", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// memoryStore keeps the configuration in process memory. It is meant for
+// tests and short-lived deployments, nothing survives a restart.
+type memoryStore struct {
+	mu     sync.RWMutex
+	values map[Name]interface{}
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() Store {
+	return &memoryStore{values: make(map[Name]interface{})}
+}
+
+func (s *memoryStore) GetByName(ctx context.Context, name Name) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	switch name {
+	case BotStarted, Location, Holidays, AutoSync, ParticipantsLocked:
+		val, ok := s.values[name]
+		if !ok {
+			return nil, ErrNotFound
+		}
+		return val, nil
+	default:
+		return nil, errors.Errorf("unknown config name to select: %s", name)
+	}
+}
+
+func (s *memoryStore) Save(ctx context.Context, name Name, val interface{}, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name {
+	case BotStarted, AutoSync, ParticipantsLocked:
+		s.values[name] = val.(bool)
+		return nil
+	case Location, Holidays:
+		s.values[name] = val.(string)
+		return nil
+	default:
+		return errors.Errorf("unknown config name to insert: %s", name)
+	}
+}
+
+func (s *memoryStore) Delete(ctx context.Context, name Name) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.values, name)
+
+	return nil
+}
@@ -5,12 +5,23 @@ import "time"
 type Name string
 
 const (
-	BotStarted     Name = "BotStarted"
-	RemindTime     Name = "RemindTime"
-	RemindMessage  Name = "RemindMessage"
-	WeekdaysToSkip Name = "WeekdaysToSkip"
+	BotStarted Name = "BotStarted"
+	Location   Name = "Location"
+	Holidays   Name = "Holidays"
+	AutoSync   Name = "AutoSync"
+
+	ParticipantsLocked Name = "ParticipantsLocked"
 )
 
+// Names lists every known config name.
+var Names = []Name{
+	BotStarted,
+	Location,
+	Holidays,
+	AutoSync,
+	ParticipantsLocked,
+}
+
 type config struct {
 	ID        string    `db:"config_id" json:"id"`          // Unique identifier.
 	Name      Name      `db:"name" json:"name"`             // Name of the config.
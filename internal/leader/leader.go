@@ -0,0 +1,142 @@
+// Package leader elects a single leader among the replicas of the bot with a
+// lease kept in the shared database. The leader renews the lease with
+// heartbeats, and another replica takes over once it expires.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+)
+
+// Config tunes an Elector. Zero values are replaced with defaults.
+type Config struct {
+	Clock clock.Clock
+
+	// Name of the lease, replicas compete for the same name.
+	Name string
+
+	// Holder identifies this replica, by default its host name, process ID
+	// and a random suffix.
+	Holder string
+
+	// TTL is how long the lease lasts without a heartbeat, the time it takes
+	// another replica to take over from a crashed leader.
+	TTL time.Duration
+
+	// Heartbeat is how often the lease is renewed or, by followers, tried.
+	Heartbeat time.Duration
+}
+
+// term is a leadership of the replica, running lead until it is cancelled.
+type term struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Elector runs a function on this replica for as long as it is the leader.
+type Elector struct {
+	store Store
+	cfg   Config
+}
+
+func New(store Store, cfg Config) *Elector {
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real{}
+	}
+	if cfg.Name == "" {
+		cfg.Name = "scheduler"
+	}
+	if cfg.Holder == "" {
+		host, _ := os.Hostname()
+		cfg.Holder = fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.New().String()[:8])
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 15 * time.Second
+	}
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = cfg.TTL / 3
+	}
+
+	return &Elector{store: store, cfg: cfg}
+}
+
+// Holder returns the identity of this replica.
+func (e *Elector) Holder() string {
+	return e.cfg.Holder
+}
+
+// Run competes for the lease until ctx is done. While the lease is held, lead
+// runs with a context that is cancelled as soon as the lease may be lost,
+// and Run waits for it to return before competing again. The lease is
+// released when ctx is done.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	ticker := e.cfg.Clock.NewTicker(e.cfg.Heartbeat)
+	defer ticker.Stop()
+
+	// The leadership of this replica, nil while it follows.
+	var current *term
+
+	// A heartbeat that fails may leave the lease to expire, leadership is
+	// given up before it does.
+	var heldUntil time.Time
+
+	resign := func() {
+		if current == nil {
+			return
+		}
+		current.cancel()
+		<-current.done
+		current = nil
+		log.Println("leader.Elector.Run : resigned :", e.cfg.Holder)
+	}
+	defer func() {
+		resign()
+
+		// The parent context is done, the lease is released on a fresh one.
+		if err := e.store.Release(context.Background(), e.cfg.Name, e.cfg.Holder); err != nil {
+			log.Println("leader.Elector.Run : error :", err)
+		}
+	}()
+
+	for {
+		now := e.cfg.Clock.Now()
+		expireAt := now.Add(e.cfg.TTL)
+
+		held, err := e.store.Acquire(ctx, e.cfg.Name, e.cfg.Holder, expireAt, now)
+		switch {
+		case err != nil:
+			log.Println("leader.Elector.Run : error :", err)
+			if current != nil && !now.Add(e.cfg.Heartbeat).Before(heldUntil) {
+				resign()
+			}
+		case held:
+			heldUntil = expireAt
+			if current == nil {
+				log.Println("leader.Elector.Run : elected :", e.cfg.Holder)
+
+				leadCtx, cancel := context.WithCancel(ctx)
+				current = &term{cancel: cancel, done: make(chan struct{})}
+
+				go func(done chan struct{}) {
+					defer close(done)
+					lead(leadCtx)
+				}(current.done)
+			}
+		default:
+			resign()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package leader_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/leader"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// store wraps a Store, counting the attempts to acquire the lease and
+// failing them for the holders cut off from the database.
+type store struct {
+	leader.Store
+
+	mu      sync.Mutex
+	calls   map[string]int  // Acquire calls by holder.
+	failing map[string]bool // Holders whose Acquire fails.
+}
+
+func (s *store) Acquire(ctx context.Context, name string, holder string, expireAt time.Time, now time.Time) (bool, error) {
+	s.mu.Lock()
+	s.calls[holder]++
+	failing := s.failing[holder]
+	s.mu.Unlock()
+
+	if failing {
+		return false, errors.New("connection refused")
+	}
+
+	return s.Store.Acquire(ctx, name, holder, expireAt, now)
+}
+
+func (s *store) attempts(holder string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[holder]
+}
+
+func (s *store) fail(holder string, failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failing[holder] = failing
+}
+
+// terms records which replicas lead, and whether two ever led at once.
+type terms struct {
+	mu      sync.Mutex
+	leading map[string]bool
+	overlap bool
+}
+
+// lead returns the function the holder runs while it leads.
+func (ts *terms) lead(holder string) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ts.mu.Lock()
+		ts.leading[holder] = true
+		if len(ts.leading) > 1 {
+			ts.overlap = true
+		}
+		ts.mu.Unlock()
+
+		<-ctx.Done()
+
+		ts.mu.Lock()
+		delete(ts.leading, holder)
+		ts.mu.Unlock()
+	}
+}
+
+// leader returns the holder leading, or an empty string if none does.
+func (ts *terms) leader() string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for h := range ts.leading {
+		return h
+	}
+
+	return ""
+}
+
+func (ts *terms) overlapped() bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	return ts.overlap
+}
+
+// TestElector validates two replicas competing for one lease never lead at
+// once, a replica cut off from the database resigns before the other one
+// takes over, and a replica shutting down hands the lead over right away.
+func TestElector(t *testing.T) {
+	for _, backend := range tests.Backends {
+		backend := backend
+		t.Run(backend, func(t *testing.T) {
+			testElector(t, backend)
+		})
+	}
+}
+
+func testElector(t *testing.T, backend string) {
+	st, teardown := tests.NewStorage(t, backend)
+	defer teardown()
+
+	clk := clock.NewFake(time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC))
+	s := &store{Store: st.Lease, calls: make(map[string]int), failing: make(map[string]bool)}
+	ts := &terms{leading: make(map[string]bool)}
+
+	run := func(holder string) (context.CancelFunc, chan struct{}) {
+		e := leader.New(s, leader.Config{Clock: clk, Holder: holder, TTL: 15 * time.Second, Heartbeat: 5 * time.Second})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			e.Run(ctx, ts.lead(holder))
+		}()
+
+		return cancel, done
+	}
+
+	leads := func(holder string) func() bool {
+		return func() bool {
+			return ts.leader() == holder
+		}
+	}
+
+	t.Log("Given two replicas competing for the lease.")
+	{
+		cancelA, doneA := run("a")
+		defer func() {
+			cancelA()
+			<-doneA
+		}()
+		if !tests.Wait(time.Second, leads("a")) {
+			t.Fatalf("\t%s\tShould elect the first replica.", tests.Failed)
+		}
+
+		cancelB, doneB := run("b")
+		defer func() {
+			cancelB()
+			<-doneB
+		}()
+		tests.Wait(time.Second, func() bool { return s.attempts("b") > 0 })
+
+		tests.Advance(clk, 30*time.Second, time.Second)
+		if !tests.Wait(time.Second, leads("a")) || ts.overlapped() {
+			t.Fatalf("\t%s\tShould let only one replica lead : got %q.", tests.Failed, ts.leader())
+		}
+		t.Logf("\t%s\tShould let only one replica lead.", tests.Success)
+
+		s.fail("a", true)
+		tests.Advance(clk, 12*time.Second, time.Second)
+		if !tests.Wait(time.Second, leads("")) {
+			t.Fatalf("\t%s\tShould resign when the heartbeats fail : got %q.", tests.Failed, ts.leader())
+		}
+		t.Logf("\t%s\tShould resign when the heartbeats fail.", tests.Success)
+
+		tests.Advance(clk, 10*time.Second, time.Second)
+		if !tests.Wait(time.Second, leads("b")) {
+			t.Fatalf("\t%s\tShould take over once the lease expires : got %q.", tests.Failed, ts.leader())
+		}
+		if ts.overlapped() {
+			t.Fatalf("\t%s\tShould not take over before the leader resigned.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould take over once the lease expires.", tests.Success)
+
+		s.fail("a", false)
+		tests.Advance(clk, 10*time.Second, time.Second)
+		if !tests.Wait(time.Second, leads("b")) || ts.overlapped() {
+			t.Fatalf("\t%s\tShould keep the new leader : got %q.", tests.Failed, ts.leader())
+		}
+		t.Logf("\t%s\tShould keep the new leader once the old one is back.", tests.Success)
+
+		cancelB()
+		<-doneB
+		tests.Advance(clk, 5*time.Second, time.Second)
+		if !tests.Wait(time.Second, leads("a")) {
+			t.Fatalf("\t%s\tShould hand the lead over on shutdown : got %q.", tests.Failed, ts.leader())
+		}
+		t.Logf("\t%s\tShould release the lease on shutdown.", tests.Success)
+	}
+}
@@ -0,0 +1,49 @@
+package leader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type lease struct {
+	holder   string
+	expireAt time.Time
+}
+
+// memoryStore keeps leases in process memory, which only makes sense for a
+// single process. It is meant for tests and short-lived deployments.
+type memoryStore struct {
+	mu     sync.Mutex
+	leases map[string]lease
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() Store {
+	return &memoryStore{leases: make(map[string]lease)}
+}
+
+func (s *memoryStore) Acquire(ctx context.Context, name string, holder string, expireAt time.Time, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.leases[name]
+	if ok && l.holder != holder && !l.expireAt.Before(now) {
+		return false, nil
+	}
+
+	s.leases[name] = lease{holder: holder, expireAt: expireAt}
+
+	return true, nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, name string, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.leases[name]; ok && l.holder == holder {
+		delete(s.leases, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package leader
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Store is the repository of leases.
+type Store interface {
+	// Acquire takes the named lease for holder until expireAt if it is free,
+	// expired or already held by holder, and reports whether holder holds it.
+	Acquire(ctx context.Context, name string, holder string, expireAt time.Time, now time.Time) (bool, error)
+
+	// Release gives the named lease up if holder holds it.
+	Release(ctx context.Context, name string, holder string) error
+}
+
+// dbStore keeps leases in the leases table of a Postgres or SQLite database.
+// Both serialize the conditional update, so a lease is never granted to
+// two holders at once.
+type dbStore struct {
+	db sqlx.ExtContext
+}
+
+// NewDBStore returns a Store backed by the leases table of db.
+func NewDBStore(db sqlx.ExtContext) Store {
+	return &dbStore{db: db}
+}
+
+func (s *dbStore) Acquire(ctx context.Context, name string, holder string, expireAt time.Time, now time.Time) (bool, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.leader.Acquire")
+	defer span.End()
+
+	const updateQ = `update leases
+		set holder = $1, expire_at = $2, updated_at = $3
+		where name = $4 and (holder = $5 or expire_at < $6)`
+	const insertQ = `insert into leases
+		(name, holder, expire_at, updated_at)
+		values ($1, $2, $3, $4)
+		on conflict (name) do nothing`
+
+	res, err := s.db.ExecContext(ctx, updateQ,
+		holder, expireAt.UTC(), now.UTC(), name, holder, now.UTC(),
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "updating lease")
+	}
+
+	upd, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "updating lease")
+	}
+	if upd > 0 {
+		return true, nil
+	}
+
+	// Nobody has held the lease yet, or somebody else holds it.
+	res, err = s.db.ExecContext(ctx, insertQ,
+		name, holder, expireAt.UTC(), now.UTC(),
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "inserting lease")
+	}
+
+	ins, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "inserting lease")
+	}
+
+	return ins > 0, nil
+}
+
+func (s *dbStore) Release(ctx context.Context, name string, holder string) error {
+	ctx, span := trace.StartSpan(ctx, "internal.leader.Release")
+	defer span.End()
+
+	const q = `delete from leases
+		where name = $1 and holder = $2`
+
+	if _, err := s.db.ExecContext(ctx, q, name, holder); err != nil {
+		return errors.Wrapf(err, "releasing lease %s", name)
+	}
+
+	return nil
+}
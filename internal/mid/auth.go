@@ -0,0 +1,37 @@
+package mid
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/web"
+)
+
+// Authenticate rejects the requests that don't carry the token in the
+// Authorization header as "Bearer <token>". An empty token rejects every
+// request.
+func Authenticate(token string) web.Middleware {
+	return func(after web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+			ctx, span := trace.StartSpan(ctx, "internal.mid.Authenticate")
+			defer span.End()
+
+			parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+			if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+				return web.NewRequestError(web.ErrUnauthorized, http.StatusUnauthorized)
+			}
+
+			if token == "" || subtle.ConstantTimeCompare([]byte(parts[1]), []byte(token)) != 1 {
+				return web.NewRequestError(web.ErrUnauthorized, http.StatusUnauthorized)
+			}
+
+			return after(ctx, w, r, params)
+		}
+
+		return h
+	}
+}
@@ -0,0 +1,37 @@
+package mid
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/web"
+)
+
+// Errors handles errors coming out of the call chain. It detects normal
+// application errors which are used to respond to the client in a uniform
+// way, any other error is reported as an internal one. Every error is
+// logged.
+func Errors(log *log.Logger) web.Middleware {
+	return func(before web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+			ctx, span := trace.StartSpan(ctx, "internal.mid.Errors")
+			defer span.End()
+
+			if err := before(ctx, w, r, params); err != nil {
+				log.Printf("ERROR : %+v", err)
+
+				if err := web.RespondError(ctx, w, err); err != nil {
+					return err
+				}
+			}
+
+			// The error has been handled so we can stop propagating it.
+			return nil
+		}
+
+		return h
+	}
+}
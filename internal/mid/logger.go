@@ -0,0 +1,37 @@
+package mid
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/web"
+)
+
+// Logger writes some information about the request to the logs in the
+// format: (200) GET /foo -> IP ADDR (latency)
+func Logger(log *log.Logger) web.Middleware {
+	return func(before web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+			ctx, span := trace.StartSpan(ctx, "internal.mid.Logger")
+			defer span.End()
+
+			err := before(ctx, w, r, params)
+
+			if v, ok := ctx.Value(web.KeyValues).(*web.Values); ok {
+				log.Printf("(%d) : %s %s -> %s (%s)",
+					v.StatusCode,
+					r.Method, r.URL.Path,
+					r.RemoteAddr, time.Since(v.Now),
+				)
+			}
+
+			return err
+		}
+
+		return h
+	}
+}
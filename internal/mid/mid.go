@@ -0,0 +1,2 @@
+// Package mid contains the middleware of the HTTP API.
+package mid
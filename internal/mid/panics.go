@@ -0,0 +1,35 @@
+package mid
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/web"
+)
+
+// Panics recovers from panics and converts the panic to an error so it is
+// reported in Errors and handled appropriately.
+func Panics(log *log.Logger) web.Middleware {
+	return func(after web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) (err error) {
+			ctx, span := trace.StartSpan(ctx, "internal.mid.Panics")
+			defer span.End()
+
+			defer func() {
+				if rec := recover(); rec != nil {
+					err = errors.Errorf("panic: %v", rec)
+					log.Printf("%s", debug.Stack())
+				}
+			}()
+
+			return after(ctx, w, r, params)
+		}
+
+		return h
+	}
+}
@@ -0,0 +1,97 @@
+// Package notifier delivers messages through the messaging services the
+// team uses: Telegram, Slack and email.
+package notifier
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+)
+
+// Notifier delivers a message to a recipient of a messaging service, e.g. a
+// chat ID for Telegram or an address for email. Failures are reported with
+// the errors of the outbox package, so the outbox queue retries them.
+type Notifier interface {
+	Send(ctx context.Context, to string, text string) error
+}
+
+// Router is a Notifier delivering every message with the notifier of the
+// scheme of its address: "slack:#team" is sent to "#team" by the notifier
+// registered as "slack". Addresses without a scheme, like Telegram chat IDs,
+// are sent by the default notifier.
+type Router struct {
+	def     Notifier
+	schemes map[string]Notifier
+}
+
+// NewRouter creates a Router sending addresses without a scheme with def.
+func NewRouter(def Notifier) *Router {
+	return &Router{
+		def:     def,
+		schemes: make(map[string]Notifier),
+	}
+}
+
+// Register makes the router send addresses with the scheme with n.
+func (r *Router) Register(scheme string, n Notifier) {
+	r.schemes[scheme] = n
+}
+
+// Supports reports whether the router has a notifier for the address.
+func (r *Router) Supports(address string) bool {
+	_, _, err := r.route(address)
+	return err == nil
+}
+
+func (r *Router) Send(ctx context.Context, address string, text string) error {
+	n, to, err := r.route(address)
+	if err != nil {
+		return &outbox.PermanentError{Err: err}
+	}
+
+	return n.Send(ctx, to, text)
+}
+
+// Reply sends the message as a reply when the notifier of the address can,
+// and as a plain message otherwise.
+func (r *Router) Reply(ctx context.Context, address string, messageID int, text string) error {
+	n, to, err := r.route(address)
+	if err != nil {
+		return &outbox.PermanentError{Err: err}
+	}
+
+	if rp, ok := n.(outbox.Replier); ok {
+		return rp.Reply(ctx, to, messageID, text)
+	}
+
+	return n.Send(ctx, to, text)
+}
+
+// route returns the notifier of the address and the recipient within it.
+func (r *Router) route(address string) (Notifier, string, error) {
+	scheme, to := Split(address)
+	if scheme == "" {
+		return r.def, to, nil
+	}
+
+	n, ok := r.schemes[scheme]
+	if !ok {
+		return nil, "", errors.Errorf("no notifier for %q", address)
+	}
+
+	return n, to, nil
+}
+
+// Split splits an address into its scheme and the recipient, the scheme is
+// empty when there is none.
+func Split(address string) (scheme string, to string) {
+	i := strings.Index(address, ":")
+	if i <= 0 {
+		return "", address
+	}
+
+	return address[:i], address[i+1:]
+}
@@ -0,0 +1,79 @@
+package notifier_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/notifier"
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// recorder is a Notifier remembering the recipients of the messages.
+type recorder struct {
+	sent []string
+}
+
+func (r *recorder) Send(ctx context.Context, to string, text string) error {
+	r.sent = append(r.sent, to)
+	return nil
+}
+
+// TestRouter validates the router sends every address with the notifier of
+// its scheme.
+func TestRouter(t *testing.T) {
+	hook := tests.NewSlack()
+	defer hook.Close()
+
+	def := &recorder{}
+	email := &recorder{}
+
+	slack, err := notifier.NewSlack(notifier.SlackConfig{WebhookURL: hook.URL()})
+	if err != nil {
+		t.Fatalf("creating notifier: %s", err)
+	}
+
+	r := notifier.NewRouter(def)
+	r.Register("slack", slack)
+	r.Register("email", email)
+
+	ctx := context.Background()
+
+	t.Log("Given a router with Slack and email notifiers.")
+	{
+		for _, address := range []string{"-100", "slack:#team", "email:alice@example.com"} {
+			if !r.Supports(address) {
+				t.Fatalf("\t%s\tShould support %s.", tests.Failed, address)
+			}
+			if err := r.Send(ctx, address, "Time to fill the report"); err != nil {
+				t.Fatalf("\t%s\tShould be able to send to %s : %s.", tests.Failed, address, err)
+			}
+		}
+
+		if got := strings.Join(def.sent, ","); got != "-100" {
+			t.Fatalf("\t%s\tShould send addresses without a scheme with the default notifier : got %q.", tests.Failed, got)
+		}
+		if msgs := hook.Messages(); len(msgs) != 1 || msgs[0].Channel != "#team" {
+			t.Fatalf("\t%s\tShould send slack addresses to the channel : got %+v.", tests.Failed, msgs)
+		}
+		if got := strings.Join(email.sent, ","); got != "alice@example.com" {
+			t.Fatalf("\t%s\tShould send email addresses to the mailbox : got %q.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould send every address with the notifier of its scheme.", tests.Success)
+
+		if r.Supports("sms:+100") {
+			t.Fatalf("\t%s\tShould not support an unknown scheme.", tests.Failed)
+		}
+		var perm *outbox.PermanentError
+		if err := r.Send(ctx, "sms:+100", "Time to fill the report"); err == nil || !errors.As(err, &perm) {
+			t.Fatalf("\t%s\tShould not retry an unknown scheme : got %v.", tests.Failed, err)
+		}
+		if len(def.sent) != 1 || len(email.sent) != 1 {
+			t.Fatalf("\t%s\tShould not send an unknown scheme : got %q, %q.", tests.Failed, def.sent, email.sent)
+		}
+		t.Logf("\t%s\tShould not send an unknown scheme.", tests.Success)
+	}
+}
@@ -0,0 +1,96 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+)
+
+// SlackConfig describes a Slack incoming webhook.
+type SlackConfig struct {
+	WebhookURL string        // Address of the incoming webhook.
+	Timeout    time.Duration // Timeout of a single request.
+	Client     *http.Client  // Client making the requests, one with Timeout by default.
+}
+
+// Slack posts messages through a Slack incoming webhook. The recipient is
+// the channel, e.g. "#team", and may be empty for the channel the webhook
+// was created for.
+type Slack struct {
+	cfg SlackConfig
+}
+
+// slackMessage is the JSON document posted to the webhook.
+type slackMessage struct {
+	Channel string `json:"channel,omitempty"` // Overrides the channel of the webhook.
+	Text    string `json:"text"`              // Message text.
+}
+
+func NewSlack(cfg SlackConfig) (*Slack, error) {
+	if cfg.WebhookURL == "" {
+		return nil, errors.New("webhook URL is required")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	return &Slack{cfg: cfg}, nil
+}
+
+func (s *Slack) Send(ctx context.Context, channel string, text string) error {
+	ctx, span := trace.StartSpan(ctx, "internal.notifier.Slack.Send")
+	defer span.End()
+
+	body, err := json.Marshal(slackMessage{Channel: channel, Text: text})
+	if err != nil {
+		return errors.Wrap(err, "marshalling slack message")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, s.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "posting to slack")
+	}
+	defer resp.Body.Close()
+
+	// Slack explains the errors in the body, e.g. "channel_not_found".
+	reply, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After")); seconds > 0 {
+			return &outbox.RetryAfterError{After: time.Duration(seconds) * time.Second}
+		}
+		return errors.New("slack is rate limiting")
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499:
+		err := errors.Errorf("slack answered %s: %s", resp.Status, strings.TrimSpace(string(reply)))
+		return &outbox.PermanentError{Err: err}
+	default:
+		return errors.Errorf("slack answered %s: %s", resp.Status, strings.TrimSpace(string(reply)))
+	}
+}
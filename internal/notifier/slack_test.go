@@ -0,0 +1,78 @@
+package notifier_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/notifier"
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestSlack validates the Slack notifier posts messages to the channel and
+// tells the outbox which failures are worth retrying.
+func TestSlack(t *testing.T) {
+	hook := tests.NewSlack()
+	defer hook.Close()
+
+	s, err := notifier.NewSlack(notifier.SlackConfig{WebhookURL: hook.URL()})
+	if err != nil {
+		t.Fatalf("creating notifier: %s", err)
+	}
+
+	ctx := context.Background()
+
+	t.Log("Given a Slack webhook accepting messages.")
+	{
+		if err := s.Send(ctx, "#team", "Time to fill the report"); err != nil {
+			t.Fatalf("\t%s\tShould be able to post a message : %s.", tests.Failed, err)
+		}
+		if err := s.Send(ctx, "", "Time to fill the report"); err != nil {
+			t.Fatalf("\t%s\tShould be able to post a message : %s.", tests.Failed, err)
+		}
+
+		msgs := hook.Messages()
+		if len(msgs) != 2 {
+			t.Fatalf("\t%s\tShould post every message : got %+v.", tests.Failed, msgs)
+		}
+		if msgs[0].Channel != "#team" || msgs[0].Text != "Time to fill the report" {
+			t.Fatalf("\t%s\tShould post the message to the channel : got %+v.", tests.Failed, msgs[0])
+		}
+		if msgs[1].Channel != "" {
+			t.Fatalf("\t%s\tShould leave the channel to the webhook without one : got %+v.", tests.Failed, msgs[1])
+		}
+		t.Logf("\t%s\tShould post the message to the channel.", tests.Success)
+	}
+
+	t.Log("Given a Slack webhook failing.")
+	{
+		var perm *outbox.PermanentError
+
+		err := s.Send(ctx, "#team", "")
+		if err == nil || !errors.As(err, &perm) {
+			t.Fatalf("\t%s\tShould not retry a message the webhook refuses : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould not retry a message the webhook refuses.", tests.Success)
+
+		hook.Fail(http.StatusNotFound)
+		err = s.Send(ctx, "#gone", "Time to fill the report")
+		if err == nil || !errors.As(err, &perm) {
+			t.Fatalf("\t%s\tShould not retry a message to a missing webhook : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould not retry a message to a missing webhook.", tests.Success)
+
+		hook.Fail(http.StatusInternalServerError)
+		err = s.Send(ctx, "#team", "Time to fill the report")
+		if err == nil || errors.As(err, &perm) {
+			t.Fatalf("\t%s\tShould retry a message on a server error : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould retry a message on a server error.", tests.Success)
+
+		if msgs := hook.Messages(); len(msgs) != 2 {
+			t.Fatalf("\t%s\tShould not post failed messages : got %+v.", tests.Failed, msgs)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+)
+
+// SMTPConfig describes the mail server an SMTP notifier sends through.
+type SMTPConfig struct {
+	Addr     string        // Address of the server, e.g. "smtp.example.com:587".
+	Username string        // Authenticates with PLAIN when set, the server has to offer STARTTLS unless it is local.
+	Password string        // Password of Username.
+	From     string        // Sender address.
+	Subject  string        // Subject of the emails, "Reminder" by default.
+	Timeout  time.Duration // Timeout of sending a single email.
+	Clock    clock.Clock   // Source of the Date header, the wall clock by default.
+}
+
+// SMTP emails messages through a mail server, upgrading the connection with
+// STARTTLS when the server offers it. The recipient is a comma separated
+// list of addresses, e.g. "alice@example.com,bob@example.com".
+type SMTP struct {
+	cfg  SMTPConfig
+	host string
+}
+
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("address is required")
+	}
+	if cfg.From == "" {
+		return nil, errors.New("sender is required")
+	}
+
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing address")
+	}
+
+	if cfg.Subject == "" {
+		cfg.Subject = "Reminder"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real{}
+	}
+
+	return &SMTP{cfg: cfg, host: host}, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, to string, text string) error {
+	ctx, span := trace.StartSpan(ctx, "internal.notifier.SMTP.Send")
+	defer span.End()
+
+	var rcpts []string
+	for _, addr := range strings.Split(to, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			rcpts = append(rcpts, addr)
+		}
+	}
+	if len(rcpts) == 0 {
+		return &outbox.PermanentError{Err: errors.New("no recipients")}
+	}
+
+	msg, err := s.message(rcpts, text)
+	if err != nil {
+		return &outbox.PermanentError{Err: err}
+	}
+
+	err = s.send(ctx, rcpts, msg)
+
+	// Replies 5xx are permanent failures, e.g. an unknown mailbox.
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return &outbox.PermanentError{Err: err}
+	}
+
+	return err
+}
+
+// send makes a single SMTP session delivering msg to the recipients.
+func (s *SMTP) send(ctx context.Context, rcpts []string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.cfg.Addr)
+	if err != nil {
+		return errors.Wrap(err, "connecting to mail server")
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return errors.Wrap(err, "greeting mail server")
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return errors.Wrap(err, "starting TLS")
+		}
+	}
+
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.host)); err != nil {
+			return errors.Wrap(err, "authenticating")
+		}
+	}
+
+	if err := c.Mail(s.cfg.From); err != nil {
+		return errors.Wrap(err, "setting sender")
+	}
+	for _, rcpt := range rcpts {
+		if err := c.Rcpt(rcpt); err != nil {
+			return errors.Wrapf(err, "adding recipient %s", rcpt)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return errors.Wrap(err, "starting data")
+	}
+	if _, err := w.Write(msg); err != nil {
+		return errors.Wrap(err, "writing message")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "sending message")
+	}
+
+	return c.Quit()
+}
+
+// message builds a plain text email of the text.
+func (s *SMTP) message(rcpts []string, text string) ([]byte, error) {
+	for _, addr := range append([]string{s.cfg.From}, rcpts...) {
+		if strings.ContainsAny(addr, "\r\n") {
+			return nil, errors.Errorf("invalid address %q", addr)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(rcpts, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", s.cfg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", s.cfg.Clock.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(strings.Replace(text, "\n", "\r\n", -1))); err != nil {
+		return nil, errors.Wrap(err, "encoding message")
+	}
+	if err := qp.Close(); err != nil {
+		return nil, errors.Wrap(err, "encoding message")
+	}
+
+	return buf.Bytes(), nil
+}
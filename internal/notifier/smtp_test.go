@@ -0,0 +1,99 @@
+package notifier_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/notifier"
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestSMTP validates the SMTP notifier emails messages to every recipient
+// and doesn't retry unknown mailboxes.
+func TestSMTP(t *testing.T) {
+	server := tests.NewSMTP()
+	defer server.Close()
+
+	s, err := notifier.NewSMTP(notifier.SMTPConfig{
+		Addr:    server.Addr(),
+		From:    "bot@example.com",
+		Subject: "Daily report",
+		Clock:   clock.NewFake(time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC)),
+	})
+	if err != nil {
+		t.Fatalf("creating notifier: %s", err)
+	}
+
+	ctx := context.Background()
+
+	t.Log("Given a mail server accepting emails.")
+	{
+		if err := s.Send(ctx, "alice@example.com, bob@example.com", "Time to fill the report"); err != nil {
+			t.Fatalf("\t%s\tShould be able to send an email : %s.", tests.Failed, err)
+		}
+
+		mails := server.Mails()
+		if len(mails) != 1 {
+			t.Fatalf("\t%s\tShould send a single email : got %+v.", tests.Failed, mails)
+		}
+		m := mails[0]
+		if m.From != "bot@example.com" || strings.Join(m.To, ",") != "alice@example.com,bob@example.com" {
+			t.Fatalf("\t%s\tShould send the email to every recipient : got from %s to %v.", tests.Failed, m.From, m.To)
+		}
+		t.Logf("\t%s\tShould send the email to every recipient.", tests.Success)
+
+		for _, want := range []string{
+			"To: alice@example.com, bob@example.com",
+			"Subject: Daily report",
+			"Date: Mon, 02 Mar 2020 09:00:00 +0000",
+			"Time to fill the report",
+		} {
+			if !strings.Contains(m.Data, want) {
+				t.Fatalf("\t%s\tShould write %q in the email : got %q.", tests.Failed, want, m.Data)
+			}
+		}
+		t.Logf("\t%s\tShould write the headers and the message.", tests.Success)
+	}
+
+	t.Log("Given a mail server refusing a recipient.")
+	{
+		server.Reject("mallory@example.com")
+
+		var perm *outbox.PermanentError
+
+		err := s.Send(ctx, "alice@example.com,mallory@example.com", "Time to fill the report")
+		if err == nil || !errors.As(err, &perm) {
+			t.Fatalf("\t%s\tShould not retry an unknown mailbox : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould not retry an unknown mailbox.", tests.Success)
+
+		err = s.Send(ctx, " , ", "Time to fill the report")
+		if err == nil || !errors.As(err, &perm) {
+			t.Fatalf("\t%s\tShould not retry an email without recipients : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould not retry an email without recipients.", tests.Success)
+
+		if mails := server.Mails(); len(mails) != 1 {
+			t.Fatalf("\t%s\tShould not send refused emails : got %+v.", tests.Failed, mails)
+		}
+	}
+
+	t.Log("Given a mail server that can't be reached.")
+	{
+		server.Close()
+
+		var perm *outbox.PermanentError
+
+		err := s.Send(ctx, "alice@example.com", "Time to fill the report")
+		if err == nil || errors.As(err, &perm) {
+			t.Fatalf("\t%s\tShould retry when the server is down : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould retry when the server is down.", tests.Success)
+	}
+}
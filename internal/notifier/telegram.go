@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+)
+
+var (
+	retryAfterRx = regexp.MustCompile(`retry after (\d+)`)
+	errorCodeRx  = regexp.MustCompile(`\((\d{3})\)$`)
+
+	replyNotFoundRx = regexp.MustCompile(`(reply message|message to be replied) not found`)
+)
+
+// Telegram sends messages to Telegram chats through telebot and translates
+// Telegram API errors into the ones the outbox queue understands.
+type Telegram struct {
+	telebot *tb.Bot
+}
+
+func NewTelegram(telebot *tb.Bot) *Telegram {
+	return &Telegram{telebot: telebot}
+}
+
+func (t *Telegram) Send(ctx context.Context, chatID string, text string) error {
+	_, err := t.telebot.Send(recipient(chatID), text)
+	return translate(err)
+}
+
+// Reply sends a message to a chat as a reply to one of its messages, or as a
+// plain message if that one was deleted meanwhile.
+func (t *Telegram) Reply(ctx context.Context, chatID string, messageID int, text string) error {
+	_, err := t.telebot.Send(recipient(chatID), text, &tb.SendOptions{ReplyTo: &tb.Message{ID: messageID}})
+	if err != nil && replyNotFoundRx.MatchString(err.Error()) {
+		_, err = t.telebot.Send(recipient(chatID), text)
+	}
+
+	return translate(err)
+}
+
+// translate translates a Telegram API error into the ones the outbox queue
+// understands.
+func translate(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if match := retryAfterRx.FindStringSubmatch(err.Error()); match != nil {
+		seconds, _ := strconv.Atoi(match[1])
+		return &outbox.RetryAfterError{After: time.Duration(seconds) * time.Second}
+	}
+
+	if match := errorCodeRx.FindStringSubmatch(err.Error()); match != nil {
+		if code, _ := strconv.Atoi(match[1]); code >= 400 && code < 500 {
+			return &outbox.PermanentError{Err: err}
+		}
+	}
+
+	return errors.Wrap(err, "error sending telebot message")
+}
+
+// recipient is a chat ID or a channel username.
+type recipient string
+
+func (r recipient) Recipient() string {
+	return string(r)
+}
@@ -0,0 +1,124 @@
+package occurrence
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type key struct {
+	reminderID string
+	dueAt      int64
+}
+
+// memoryStore keeps occurrences in process memory. It is meant for tests and
+// short-lived deployments, nothing survives a restart.
+type memoryStore struct {
+	mu          sync.Mutex
+	occurrences map[key]Occurrence
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() Store {
+	return &memoryStore{occurrences: make(map[key]Occurrence)}
+}
+
+func keyOf(reminderID string, dueAt time.Time) key {
+	return key{reminderID: reminderID, dueAt: dueAt.UnixNano()}
+}
+
+func (s *memoryStore) Create(ctx context.Context, reminderID string, dueAt time.Time, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := keyOf(reminderID, dueAt)
+	if _, ok := s.occurrences[k]; ok {
+		return nil
+	}
+
+	s.occurrences[k] = Occurrence{
+		ReminderID: reminderID,
+		DueAt:      dueAt.UTC(),
+		Status:     StatusPending,
+		CreatedAt:  now.UTC(),
+		UpdatedAt:  now.UTC(),
+	}
+
+	return nil
+}
+
+func (s *memoryStore) ListPending(ctx context.Context, limit int) ([]Occurrence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var occurrences []Occurrence
+	for _, o := range s.occurrences {
+		if o.Status == StatusPending {
+			occurrences = append(occurrences, o)
+		}
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].DueAt.Before(occurrences[j].DueAt)
+	})
+
+	if len(occurrences) > limit {
+		occurrences = occurrences[:limit]
+	}
+
+	return occurrences, nil
+}
+
+func (s *memoryStore) MarkSent(ctx context.Context, reminderID string, dueAt time.Time, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := keyOf(reminderID, dueAt)
+	o, ok := s.occurrences[k]
+	if !ok || o.Status != StatusPending {
+		return false, nil
+	}
+
+	o.Status = StatusSent
+	o.UpdatedAt = now.UTC()
+	s.occurrences[k] = o
+
+	return true, nil
+}
+
+func (s *memoryStore) Retry(ctx context.Context, reminderID string, dueAt time.Time, lastError string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := keyOf(reminderID, dueAt)
+	o, ok := s.occurrences[k]
+	if !ok || o.Status != StatusPending {
+		return nil
+	}
+
+	o.Attempts++
+	o.LastError = lastError
+	o.UpdatedAt = now.UTC()
+	s.occurrences[k] = o
+
+	return nil
+}
+
+func (s *memoryStore) Fail(ctx context.Context, reminderID string, dueAt time.Time, lastError string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := keyOf(reminderID, dueAt)
+	o, ok := s.occurrences[k]
+	if !ok || o.Status != StatusPending {
+		return nil
+	}
+
+	o.Status = StatusFailed
+	o.LastError = lastError
+	o.UpdatedAt = now.UTC()
+	s.occurrences[k] = o
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package occurrence
+
+import "time"
+
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusSent    Status = "sent"
+	StatusFailed  Status = "failed"
+)
+
+// Occurrence is a single remind of a reminder, identified by the time it was
+// due at.
+type Occurrence struct {
+	ReminderID string    `db:"reminder_id" json:"reminder_id"` // Reminder that fired.
+	DueAt      time.Time `db:"due_at" json:"due_at"`           // When the remind was due.
+	Status     Status    `db:"status" json:"status"`           // Delivery status.
+	Attempts   int       `db:"attempts" json:"attempts"`       // Number of failed delivery attempts.
+	LastError  string    `db:"last_error" json:"last_error"`   // Error of the last failed attempt.
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`   // When the remind fired.
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`   // When the occurrence record was last modified.
+}
@@ -0,0 +1,131 @@
+// Package occurrence keeps the ledger of fired reminds, so each of them is
+// delivered once even if the bot restarts or another replica takes over in
+// the middle of the delivery.
+package occurrence
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Store is the repository of occurrences.
+type Store interface {
+	// Create records a pending occurrence unless it is already recorded.
+	Create(ctx context.Context, reminderID string, dueAt time.Time, now time.Time) error
+
+	// ListPending returns the occurrences that were not delivered yet, the
+	// earliest first.
+	ListPending(ctx context.Context, limit int) ([]Occurrence, error)
+
+	// MarkSent marks a pending occurrence as sent and reports whether it was
+	// pending. Only the caller that gets true may deliver the remind.
+	MarkSent(ctx context.Context, reminderID string, dueAt time.Time, now time.Time) (bool, error)
+
+	// Retry records a failed delivery attempt, the occurrence stays pending.
+	Retry(ctx context.Context, reminderID string, dueAt time.Time, lastError string, now time.Time) error
+
+	// Fail marks a pending occurrence as undeliverable.
+	Fail(ctx context.Context, reminderID string, dueAt time.Time, lastError string, now time.Time) error
+}
+
+// dbStore keeps occurrences in the occurrences table of a Postgres or SQLite
+// database.
+type dbStore struct {
+	db sqlx.ExtContext
+}
+
+// NewDBStore returns a Store backed by the occurrences table of db.
+func NewDBStore(db sqlx.ExtContext) Store {
+	return &dbStore{db: db}
+}
+
+func (s *dbStore) Create(ctx context.Context, reminderID string, dueAt time.Time, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.occurrence.Create")
+	defer span.End()
+
+	const q = `insert into occurrences
+		(reminder_id, due_at, status, attempts, last_error, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7)
+		on conflict (reminder_id, due_at) do nothing`
+
+	_, err := s.db.ExecContext(ctx, q,
+		reminderID, dueAt.UTC(), StatusPending, 0, "", now.UTC(), now.UTC(),
+	)
+	if err != nil {
+		return errors.Wrap(err, "inserting occurrence")
+	}
+
+	return nil
+}
+
+func (s *dbStore) ListPending(ctx context.Context, limit int) ([]Occurrence, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.occurrence.ListPending")
+	defer span.End()
+
+	var occurrences []Occurrence
+	const q = `select * from occurrences
+		where status = $1
+		order by due_at
+		limit $2`
+
+	if err := sqlx.SelectContext(ctx, s.db, &occurrences, q, StatusPending, limit); err != nil {
+		return nil, errors.Wrap(err, "selecting pending occurrences")
+	}
+
+	return occurrences, nil
+}
+
+func (s *dbStore) MarkSent(ctx context.Context, reminderID string, dueAt time.Time, now time.Time) (bool, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.occurrence.MarkSent")
+	defer span.End()
+
+	const q = `update occurrences
+		set status = $1, updated_at = $2
+		where reminder_id = $3 and due_at = $4 and status = $5`
+
+	res, err := s.db.ExecContext(ctx, q, StatusSent, now.UTC(), reminderID, dueAt.UTC(), StatusPending)
+	if err != nil {
+		return false, errors.Wrap(err, "marking occurrence as sent")
+	}
+
+	upd, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "marking occurrence as sent")
+	}
+
+	return upd > 0, nil
+}
+
+func (s *dbStore) Retry(ctx context.Context, reminderID string, dueAt time.Time, lastError string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.occurrence.Retry")
+	defer span.End()
+
+	const q = `update occurrences
+		set attempts = attempts + 1, last_error = $1, updated_at = $2
+		where reminder_id = $3 and due_at = $4 and status = $5`
+
+	if _, err := s.db.ExecContext(ctx, q, lastError, now.UTC(), reminderID, dueAt.UTC(), StatusPending); err != nil {
+		return errors.Wrap(err, "recording occurrence attempt")
+	}
+
+	return nil
+}
+
+func (s *dbStore) Fail(ctx context.Context, reminderID string, dueAt time.Time, lastError string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.occurrence.Fail")
+	defer span.End()
+
+	const q = `update occurrences
+		set status = $1, last_error = $2, updated_at = $3
+		where reminder_id = $4 and due_at = $5 and status = $6`
+
+	if _, err := s.db.ExecContext(ctx, q, StatusFailed, lastError, now.UTC(), reminderID, dueAt.UTC(), StatusPending); err != nil {
+		return errors.Wrap(err, "failing occurrence")
+	}
+
+	return nil
+}
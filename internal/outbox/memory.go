@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps messages in process memory. It is meant for tests and
+// short-lived deployments, nothing survives a restart.
+type memoryStore struct {
+	mu       sync.Mutex
+	messages map[string]Message
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() Store {
+	return &memoryStore{messages: make(map[string]Message)}
+}
+
+func (s *memoryStore) Enqueue(ctx context.Context, nm NewMessage, now time.Time) (*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := Message{
+		ID:            uuid.New().String(),
+		ChatID:        nm.ChatID,
+		Text:          nm.Text,
+		ReplyTo:       nm.ReplyTo,
+		Status:        StatusPending,
+		NextAttemptAt: now.UTC(),
+		CreatedAt:     now.UTC(),
+		UpdatedAt:     now.UTC(),
+	}
+	s.messages[m.ID] = m
+
+	return &m, nil
+}
+
+func (s *memoryStore) ListPending(ctx context.Context, now time.Time, limit int) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var messages []Message
+	for _, m := range s.messages {
+		if m.Status == StatusPending {
+			messages = append(messages, m)
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	// Like the database, leave out the messages backing off and the ones
+	// queued after them to the same chat.
+	blocked := make(map[string]bool)
+	due := messages[:0]
+	for _, m := range messages {
+		if blocked[m.ChatID] || m.NextAttemptAt.After(now) {
+			blocked[m.ChatID] = true
+			continue
+		}
+		due = append(due, m)
+	}
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	return due, nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.messages, id)
+
+	return nil
+}
+
+func (s *memoryStore) Retry(ctx context.Context, id string, nextAttemptAt time.Time, lastError string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.messages[id]
+	if !ok {
+		return nil
+	}
+
+	m.Attempts++
+	m.NextAttemptAt = nextAttemptAt.UTC()
+	m.LastError = lastError
+	m.UpdatedAt = now.UTC()
+	s.messages[id] = m
+
+	return nil
+}
+
+func (s *memoryStore) Fail(ctx context.Context, id string, lastError string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.messages[id]
+	if !ok {
+		return nil
+	}
+
+	m.Status = StatusFailed
+	m.Attempts++
+	m.LastError = lastError
+	m.UpdatedAt = now.UTC()
+	s.messages[id] = m
+
+	return nil
+}
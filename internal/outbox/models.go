@@ -0,0 +1,29 @@
+package outbox
+
+import "time"
+
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusFailed  Status = "failed"
+)
+
+type Message struct {
+	ID            string    `db:"message_id" json:"id"`                   // Unique identifier.
+	ChatID        string    `db:"chat_id" json:"chat_id"`                 // Recipient chat.
+	Text          string    `db:"text" json:"text"`                       // Message text.
+	ReplyTo       int       `db:"reply_to" json:"reply_to"`               // Message of the chat this one replies to, 0 for none.
+	Status        Status    `db:"status" json:"status"`                   // Delivery status.
+	Attempts      int       `db:"attempts" json:"attempts"`               // Number of failed delivery attempts.
+	NextAttemptAt time.Time `db:"next_attempt_at" json:"next_attempt_at"` // When the next delivery attempt is allowed.
+	LastError     string    `db:"last_error" json:"last_error"`           // Error of the last failed attempt.
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`           // When the message was queued.
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`           // When the message record was last modified.
+}
+
+type NewMessage struct {
+	ChatID  string `json:"chat_id" validate:"required"`
+	Text    string `json:"text" validate:"required"`
+	ReplyTo int    `json:"reply_to"`
+}
@@ -0,0 +1,133 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Store is the repository of undelivered messages.
+type Store interface {
+	Enqueue(ctx context.Context, nm NewMessage, now time.Time) (*Message, error)
+	ListPending(ctx context.Context, now time.Time, limit int) ([]Message, error)
+	Delete(ctx context.Context, id string) error
+	Retry(ctx context.Context, id string, nextAttemptAt time.Time, lastError string, now time.Time) error
+	Fail(ctx context.Context, id string, lastError string, now time.Time) error
+}
+
+// dbStore keeps messages in the outbox table of a Postgres or SQLite
+// database.
+type dbStore struct {
+	db sqlx.ExtContext
+}
+
+// NewDBStore returns a Store backed by the outbox table of db.
+func NewDBStore(db sqlx.ExtContext) Store {
+	return &dbStore{db: db}
+}
+
+func (s *dbStore) Enqueue(ctx context.Context, nm NewMessage, now time.Time) (*Message, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.outbox.Enqueue")
+	defer span.End()
+
+	m := Message{
+		ID:            uuid.New().String(),
+		ChatID:        nm.ChatID,
+		Text:          nm.Text,
+		ReplyTo:       nm.ReplyTo,
+		Status:        StatusPending,
+		NextAttemptAt: now.UTC(),
+		CreatedAt:     now.UTC(),
+		UpdatedAt:     now.UTC(),
+	}
+
+	const q = `insert into outbox
+		(message_id, chat_id, text, reply_to, status, attempts, next_attempt_at, last_error, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := s.db.ExecContext(ctx, q,
+		m.ID, m.ChatID, m.Text, m.ReplyTo, m.Status, m.Attempts, m.NextAttemptAt, m.LastError, m.CreatedAt, m.UpdatedAt,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting outbox message")
+	}
+
+	return &m, nil
+}
+
+// ListPending returns undelivered messages due by now in the order they were
+// queued. Messages backing off are left out, so they don't hold back the
+// other chats, and so are the messages queued after them to the same chat,
+// which are delivered in order.
+func (s *dbStore) ListPending(ctx context.Context, now time.Time, limit int) ([]Message, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.outbox.ListPending")
+	defer span.End()
+
+	var messages []Message
+	const q = `select * from outbox m
+		where m.status = $1 and m.next_attempt_at <= $2
+		and not exists (
+			select 1 from outbox b
+			where b.chat_id = m.chat_id and b.status = $1
+			and b.next_attempt_at > $2 and b.created_at < m.created_at
+		)
+		order by m.created_at
+		limit $3`
+
+	if err := sqlx.SelectContext(ctx, s.db, &messages, q, StatusPending, now.UTC(), limit); err != nil {
+		return nil, errors.Wrap(err, "selecting pending outbox messages")
+	}
+
+	return messages, nil
+}
+
+// Delete removes a delivered message from the outbox.
+func (s *dbStore) Delete(ctx context.Context, id string) error {
+	ctx, span := trace.StartSpan(ctx, "internal.outbox.Delete")
+	defer span.End()
+
+	const q = `delete from outbox
+		where message_id = $1`
+
+	if _, err := s.db.ExecContext(ctx, q, id); err != nil {
+		return errors.Wrapf(err, "deleting outbox message %s", id)
+	}
+
+	return nil
+}
+
+// Retry records a failed attempt and postpones the message until nextAttemptAt.
+func (s *dbStore) Retry(ctx context.Context, id string, nextAttemptAt time.Time, lastError string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.outbox.Retry")
+	defer span.End()
+
+	const q = `update outbox
+		set attempts = attempts + 1, next_attempt_at = $1, last_error = $2, updated_at = $3
+		where message_id = $4`
+
+	if _, err := s.db.ExecContext(ctx, q, nextAttemptAt.UTC(), lastError, now.UTC(), id); err != nil {
+		return errors.Wrapf(err, "rescheduling outbox message %s", id)
+	}
+
+	return nil
+}
+
+// Fail marks the message as undeliverable so it is not attempted again.
+func (s *dbStore) Fail(ctx context.Context, id string, lastError string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.outbox.Fail")
+	defer span.End()
+
+	const q = `update outbox
+		set status = $1, attempts = attempts + 1, last_error = $2, updated_at = $3
+		where message_id = $4`
+
+	if _, err := s.db.ExecContext(ctx, q, StatusFailed, lastError, now.UTC(), id); err != nil {
+		return errors.Wrapf(err, "failing outbox message %s", id)
+	}
+
+	return nil
+}
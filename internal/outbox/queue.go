@@ -0,0 +1,247 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+)
+
+// Sender delivers a single message to a chat.
+type Sender interface {
+	Send(ctx context.Context, chatID string, text string) error
+}
+
+// Replier is a Sender able to send a message as a reply to another message
+// of the chat. Messages replying to one are sent as plain messages by
+// Senders that are not.
+type Replier interface {
+	Reply(ctx context.Context, chatID string, messageID int, text string) error
+}
+
+// RetryAfterError is returned by a Sender when the API asks to wait before
+// sending more messages (Telegram's 429 "Too Many Requests").
+type RetryAfterError struct {
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("flood wait, retry after %s", e.After)
+}
+
+// PermanentError is returned by a Sender when retrying the same message
+// can't succeed, e.g. the chat doesn't exist or the bot was kicked.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+type QueueConfig struct {
+	Clock          clock.Clock   // Source of the current time, the wall clock by default.
+	PollInterval   time.Duration // How often the outbox is checked without being woken up.
+	ChatInterval   time.Duration // Minimal delay between two messages to the same chat.
+	GlobalInterval time.Duration // Minimal delay between any two messages.
+	MinBackoff     time.Duration // Delay before the first retry.
+	MaxBackoff     time.Duration // Upper bound of the exponential backoff.
+	MaxAttempts    int           // Attempts after which a message is marked as failed.
+	BatchSize      int           // Messages loaded from the outbox at once.
+}
+
+// Queue delivers messages persisted in the outbox, retrying transient
+// failures with exponential backoff while respecting rate limits.
+type Queue struct {
+	store  Store
+	sender Sender
+	cfg    QueueConfig
+	wake   chan struct{}
+
+	chatNext   map[string]time.Time
+	globalNext time.Time
+}
+
+func NewQueue(store Store, sender Sender, cfg QueueConfig) *Queue {
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real{}
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.ChatInterval <= 0 {
+		cfg.ChatInterval = 3 * time.Second
+	}
+	if cfg.GlobalInterval <= 0 {
+		cfg.GlobalInterval = 34 * time.Millisecond
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 10 * time.Minute
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	return &Queue{
+		store:    store,
+		sender:   sender,
+		cfg:      cfg,
+		wake:     make(chan struct{}, 1),
+		chatNext: make(map[string]time.Time),
+	}
+}
+
+// Push persists the message and wakes the delivery loop up.
+func (q *Queue) Push(ctx context.Context, nm NewMessage) error {
+	ctx, span := trace.StartSpan(ctx, "internal.outbox.Queue.Push")
+	defer span.End()
+
+	if _, err := q.store.Enqueue(ctx, nm, q.cfg.Clock.Now()); err != nil {
+		return errors.Wrap(err, "enqueuing message")
+	}
+
+	q.Wake()
+
+	return nil
+}
+
+// Wake makes the delivery loop check the outbox right away, e.g. after a
+// message was enqueued within a transaction.
+func (q *Queue) Wake() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers queued messages until ctx is cancelled.
+func (q *Queue) Run(ctx context.Context) {
+	timer := q.cfg.Clock.NewTimer(0)
+	defer func() { timer.Stop() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-timer.C():
+		}
+
+		timer.Stop()
+		q.flush(ctx)
+		timer = q.cfg.Clock.NewTimer(q.cfg.PollInterval)
+	}
+}
+
+func (q *Queue) flush(ctx context.Context) {
+	messages, err := q.store.ListPending(ctx, q.cfg.Clock.Now(), q.cfg.BatchSize)
+	if err != nil {
+		log.Println("outbox.Queue.flush : error :", err)
+		return
+	}
+
+	// Messages of a chat are delivered in order, so once one of them has to
+	// wait the rest of that chat waits too.
+	blocked := make(map[string]bool)
+
+	for _, m := range messages {
+		if ctx.Err() != nil {
+			return
+		}
+
+		now := q.cfg.Clock.Now()
+		if blocked[m.ChatID] || now.Before(q.chatNext[m.ChatID]) {
+			blocked[m.ChatID] = true
+			continue
+		}
+
+		if wait := q.globalNext.Sub(now); wait > 0 {
+			timer := q.cfg.Clock.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C():
+			}
+		}
+
+		if !q.deliver(ctx, m) {
+			blocked[m.ChatID] = true
+		}
+	}
+}
+
+// deliver makes a single delivery attempt and reports whether it succeeded.
+func (q *Queue) deliver(ctx context.Context, m Message) bool {
+	err := q.send(ctx, m)
+
+	now := q.cfg.Clock.Now()
+	q.globalNext = now.Add(q.cfg.GlobalInterval)
+	q.chatNext[m.ChatID] = now.Add(q.cfg.ChatInterval)
+
+	if err == nil {
+		if err := q.store.Delete(ctx, m.ID); err != nil {
+			log.Println("outbox.Queue.deliver : error :", err)
+		}
+		log.Println("outbox.Queue.deliver : msg :", m.Text)
+		return true
+	}
+
+	log.Printf("outbox.Queue.deliver : error : attempt %d of message %s : %v", m.Attempts+1, m.ID, err)
+
+	var permanent *PermanentError
+	if errors.As(err, &permanent) || m.Attempts+1 >= q.cfg.MaxAttempts {
+		if err := q.store.Fail(ctx, m.ID, err.Error(), now); err != nil {
+			log.Println("outbox.Queue.deliver : error :", err)
+		}
+		return false
+	}
+
+	next := now.Add(q.backoff(m.Attempts))
+
+	var flood *RetryAfterError
+	if errors.As(err, &flood) {
+		next = now.Add(flood.After)
+		q.chatNext[m.ChatID] = next
+	}
+
+	if err := q.store.Retry(ctx, m.ID, next, err.Error(), now); err != nil {
+		log.Println("outbox.Queue.deliver : error :", err)
+	}
+
+	return false
+}
+
+// send sends the message, as a reply when it is one and the sender can.
+func (q *Queue) send(ctx context.Context, m Message) error {
+	if r, ok := q.sender.(Replier); ok && m.ReplyTo != 0 {
+		return r.Reply(ctx, m.ChatID, m.ReplyTo, m.Text)
+	}
+
+	return q.sender.Send(ctx, m.ChatID, m.Text)
+}
+
+// backoff returns the delay before the retry that follows the given number
+// of failed attempts.
+func (q *Queue) backoff(attempts int) time.Duration {
+	d := q.cfg.MinBackoff
+	for i := 0; i < attempts; i++ {
+		d *= 2
+		if d >= q.cfg.MaxBackoff {
+			return q.cfg.MaxBackoff
+		}
+	}
+
+	return d
+}
@@ -0,0 +1,243 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// flakySender fails the first failures sends.
+type flakySender struct {
+	mu       sync.Mutex
+	failures int
+	sent     []string
+}
+
+func (s *flakySender) Send(ctx context.Context, chatID string, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = append(s.sent, text)
+	if len(s.sent) <= s.failures {
+		return errors.New("unavailable")
+	}
+
+	return nil
+}
+
+func (s *flakySender) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.sent)
+}
+
+// TestQueueBackoff validates the queue retries failed messages after the
+// backoff measured by its clock.
+func TestQueueBackoff(t *testing.T) {
+	clk := clock.NewFake(time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC))
+	store := outbox.NewMemoryStore()
+	sender := &flakySender{failures: 2}
+
+	q := outbox.NewQueue(store, sender, outbox.QueueConfig{
+		Clock:        clk,
+		PollInterval: time.Second,
+		MinBackoff:   10 * time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	t.Log("Given a message the sender fails to deliver twice.")
+	{
+		if err := q.Push(ctx, outbox.NewMessage{ChatID: "1", Text: "hello"}); err != nil {
+			t.Fatalf("\t%s\tShould be able to push a message : %s.", tests.Failed, err)
+		}
+
+		if !tests.Wait(time.Second, func() bool { return sender.attempts() == 1 }) {
+			t.Fatalf("\t%s\tShould try to deliver it right away : got %d attempts.", tests.Failed, sender.attempts())
+		}
+		t.Logf("\t%s\tShould try to deliver it right away.", tests.Success)
+
+		tests.Advance(clk, 9*time.Second, time.Second)
+		if got := sender.attempts(); got != 1 {
+			t.Fatalf("\t%s\tShould wait for the first backoff : got %d attempts.", tests.Failed, got)
+		}
+		tests.Advance(clk, time.Second, time.Second)
+		if !tests.Wait(time.Second, func() bool { return sender.attempts() == 2 }) {
+			t.Fatalf("\t%s\tShould retry after the first backoff : got %d attempts.", tests.Failed, sender.attempts())
+		}
+		t.Logf("\t%s\tShould retry after the first backoff.", tests.Success)
+
+		tests.Advance(clk, 19*time.Second, time.Second)
+		if got := sender.attempts(); got != 2 {
+			t.Fatalf("\t%s\tShould double the backoff : got %d attempts.", tests.Failed, got)
+		}
+		tests.Advance(clk, time.Second, time.Second)
+		if !tests.Wait(time.Second, func() bool { return sender.attempts() == 3 }) {
+			t.Fatalf("\t%s\tShould retry after the doubled backoff : got %d attempts.", tests.Failed, sender.attempts())
+		}
+		t.Logf("\t%s\tShould retry after the doubled backoff.", tests.Success)
+
+		pending := func() int {
+			ms, err := store.ListPending(ctx, clk.Now().Add(time.Hour), 10)
+			if err != nil {
+				t.Fatalf("\t%s\tShould be able to list pending messages : %s.", tests.Failed, err)
+			}
+			return len(ms)
+		}
+		if !tests.Wait(time.Second, func() bool { return pending() == 0 }) {
+			t.Fatalf("\t%s\tShould remove the delivered message from the outbox.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould remove the delivered message from the outbox.", tests.Success)
+	}
+}
+
+// TestQueuePermanent validates a permanent error fails the message without
+// retrying it.
+func TestQueuePermanent(t *testing.T) {
+	clk := clock.NewFake(time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC))
+	store := outbox.NewMemoryStore()
+
+	var attempts int
+	var mu sync.Mutex
+	sender := senderFunc(func(ctx context.Context, chatID string, text string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		return &outbox.PermanentError{Err: errors.New("chat not found")}
+	})
+
+	q := outbox.NewQueue(store, sender, outbox.QueueConfig{Clock: clk})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	t.Log("Given a message to a chat that doesn't exist.")
+	{
+		if err := q.Push(ctx, outbox.NewMessage{ChatID: "1", Text: "hello"}); err != nil {
+			t.Fatalf("\t%s\tShould be able to push a message : %s.", tests.Failed, err)
+		}
+
+		failed := func() bool {
+			ms, err := store.ListPending(ctx, clk.Now().Add(time.Hour), 10)
+			return err == nil && len(ms) == 0
+		}
+		if !tests.Wait(time.Second, failed) {
+			t.Fatalf("\t%s\tShould fail the message.", tests.Failed)
+		}
+
+		tests.Advance(clk, time.Hour, time.Minute)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if attempts != 1 {
+			t.Fatalf("\t%s\tShould not retry it : got %d attempts.", tests.Failed, attempts)
+		}
+		t.Logf("\t%s\tShould fail the message without retrying it.", tests.Success)
+	}
+}
+
+// TestQueueStarvation validates messages backing off for one chat don't
+// hold back the messages to the other chats.
+func TestQueueStarvation(t *testing.T) {
+	for _, backend := range tests.Backends {
+		backend := backend
+		t.Run(backend, func(t *testing.T) {
+			st, teardown := tests.NewStorage(t, backend)
+			defer teardown()
+
+			clk := clock.NewFake(time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC))
+			ctx := context.Background()
+
+			// More messages than a batch backing off for a chat that blocked
+			// the bot.
+			for i := 0; i < 10; i++ {
+				m, err := st.Outbox.Enqueue(ctx, outbox.NewMessage{ChatID: "dm", Text: "hello"}, clk.Now().Add(-time.Minute))
+				if err != nil {
+					t.Fatalf("enqueuing message: %s", err)
+				}
+				if err := st.Outbox.Retry(ctx, m.ID, clk.Now().Add(time.Hour), "unavailable", clk.Now()); err != nil {
+					t.Fatalf("retrying message: %s", err)
+				}
+			}
+
+			var mu sync.Mutex
+			var sent []string
+			sender := senderFunc(func(ctx context.Context, chatID string, text string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				sent = append(sent, chatID)
+				return nil
+			})
+
+			q := outbox.NewQueue(st.Outbox, sender, outbox.QueueConfig{
+				Clock:        clk,
+				PollInterval: time.Second,
+				BatchSize:    5,
+			})
+
+			runCtx, cancel := context.WithCancel(ctx)
+			done := make(chan struct{})
+			go func() {
+				q.Run(runCtx)
+				close(done)
+			}()
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			t.Log("Given more messages than a batch backing off for one chat.")
+			{
+				if err := q.Push(ctx, outbox.NewMessage{ChatID: "team", Text: "remind"}); err != nil {
+					t.Fatalf("\t%s\tShould be able to push a message : %s.", tests.Failed, err)
+				}
+
+				delivered := func() bool {
+					mu.Lock()
+					defer mu.Unlock()
+					return len(sent) > 0
+				}
+				if !tests.Wait(time.Second, delivered) {
+					t.Fatalf("\t%s\tShould deliver the message to the other chat.", tests.Failed)
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				if len(sent) != 1 || sent[0] != "team" {
+					t.Fatalf("\t%s\tShould not retry before the backoff : got %q.", tests.Failed, sent)
+				}
+				t.Logf("\t%s\tShould deliver the message to the other chat.", tests.Success)
+			}
+		})
+	}
+}
+
+type senderFunc func(ctx context.Context, chatID string, text string) error
+
+func (f senderFunc) Send(ctx context.Context, chatID string, text string) error {
+	return f(ctx, chatID, text)
+}
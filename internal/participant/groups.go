@@ -0,0 +1,69 @@
+package participant
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var groupRx = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// ParseGroups reads a list of group names separated by commas or spaces,
+// e.g. "backend, qa". Names are lowercased, duplicates are dropped and the
+// result is sorted.
+func ParseGroups(raw string) ([]string, error) {
+	seen := make(map[string]bool)
+	var groups []string
+
+	for _, g := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' }) {
+		g = strings.ToLower(strings.TrimPrefix(g, "#"))
+		if !groupRx.MatchString(g) {
+			return nil, errors.Errorf("invalid group %q, use letters, digits, - and _", g)
+		}
+		if seen[g] {
+			continue
+		}
+		seen[g] = true
+		groups = append(groups, g)
+	}
+
+	sort.Strings(groups)
+
+	return groups, nil
+}
+
+// FormatGroups writes groups in the stored format "backend,qa".
+func FormatGroups(groups []string) string {
+	return strings.Join(groups, ",")
+}
+
+// GroupList returns the groups of the participant.
+func (p Participant) GroupList() []string {
+	groups, err := ParseGroups(p.Groups)
+	if err != nil {
+		return nil
+	}
+
+	return groups
+}
+
+// InAnyGroup reports whether the participant belongs to one of groups. Every
+// participant belongs to an empty list of groups, it stands for the whole
+// team.
+func (p Participant) InAnyGroup(groups []string) bool {
+	if len(groups) == 0 {
+		return true
+	}
+
+	for _, g := range p.GroupList() {
+		for _, want := range groups {
+			if g == want {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,165 @@
+package participant
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps participants in process memory. It is meant for tests
+// and short-lived deployments, nothing survives a restart.
+type memoryStore struct {
+	mu           sync.RWMutex
+	participants map[string]Participant // Keyed by name.
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() Store {
+	return &memoryStore{participants: make(map[string]Participant)}
+}
+
+func (s *memoryStore) List(ctx context.Context) ([]Participant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	participants := make([]Participant, 0, len(s.participants))
+	for _, p := range s.participants {
+		participants = append(participants, p)
+	}
+
+	sort.Slice(participants, func(i, j int) bool {
+		if !participants[i].AddedAt.Equal(participants[j].AddedAt) {
+			return participants[i].AddedAt.Before(participants[j].AddedAt)
+		}
+		return participants[i].Name < participants[j].Name
+	})
+
+	return participants, nil
+}
+
+func (s *memoryStore) CreateOrUpdate(ctx context.Context, participant NewParticipant, now time.Time) (*Participant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.participants[participant.Name]
+	if !ok {
+		p = Participant{
+			ID:      uuid.New().String(),
+			Name:    participant.Name,
+			AddedAt: now.UTC(),
+		}
+	}
+	p.UpdatedAt = now.UTC()
+
+	s.participants[p.Name] = p
+
+	return &p, nil
+}
+
+func (s *memoryStore) DeleteByName(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.participants[name]
+	if !ok {
+		return nil
+	}
+
+	delete(s.participants, name)
+
+	for n, led := range s.participants {
+		if led.LeadID == p.ID {
+			led.LeadID = ""
+			s.participants[n] = led
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStore) SetGroups(ctx context.Context, name string, groups []string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.participants[name]
+	if !ok {
+		return ErrNotFound
+	}
+
+	p.Groups = FormatGroups(groups)
+	p.UpdatedAt = now.UTC()
+	s.participants[name] = p
+
+	return nil
+}
+
+func (s *memoryStore) SetAbsence(ctx context.Context, name string, from *time.Time, until *time.Time, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.participants[name]
+	if !ok {
+		return ErrNotFound
+	}
+
+	p.AwayFrom = utc(from)
+	p.AwayUntil = utc(until)
+	p.UpdatedAt = now.UTC()
+	s.participants[name] = p
+
+	return nil
+}
+
+func (s *memoryStore) Link(ctx context.Context, name string, userID int64, newName string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.participants[name]
+	if !ok {
+		return ErrNotFound
+	}
+
+	p.UserID = userID
+	p.Name = newName
+	p.UpdatedAt = now.UTC()
+
+	delete(s.participants, name)
+	s.participants[newName] = p
+
+	return nil
+}
+
+func (s *memoryStore) SetTimezone(ctx context.Context, name string, timezone string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.participants[name]
+	if !ok {
+		return ErrNotFound
+	}
+
+	p.Timezone = timezone
+	p.UpdatedAt = now.UTC()
+	s.participants[name] = p
+
+	return nil
+}
+
+func (s *memoryStore) SetLead(ctx context.Context, name string, leadID string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.participants[name]
+	if !ok {
+		return ErrNotFound
+	}
+
+	p.LeadID = leadID
+	p.UpdatedAt = now.UTC()
+	s.participants[name] = p
+
+	return nil
+}
@@ -3,12 +3,44 @@ package participant
 import "time"
 
 type Participant struct {
-	ID        string    `db:"participant_id" json:"id"`
-	Name      string    `db:"name" json:"name"`
-	AddedAt   time.Time `db:"added_at" json:"added_at"`
-	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	ID        string     `db:"participant_id" json:"id"`
+	Name      string     `db:"name" json:"name"`
+	AddedAt   time.Time  `db:"added_at" json:"added_at"`
+	Groups    string     `db:"group_names" json:"groups,omitempty"`    // Groups in format "backend,qa".
+	AwayFrom  *time.Time `db:"away_from" json:"away_from,omitempty"`   // Start of the absence.
+	AwayUntil *time.Time `db:"away_until" json:"away_until,omitempty"` // End of the absence, the participant is back at this moment.
+	UserID    int64      `db:"user_id" json:"user_id,omitempty"`       // Telegram user, 0 if the participant was added by name.
+	Timezone  string     `db:"timezone" json:"timezone,omitempty"`     // Time zone of the participant, the one of the reminder if empty.
+	LeadID    string     `db:"lead_id" json:"lead_id,omitempty"`       // ID of the participant reminds are escalated to.
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// Away reports whether the participant is absent at t.
+func (p Participant) Away(t time.Time) bool {
+	if p.AwayFrom == nil || p.AwayUntil == nil {
+		return false
+	}
+
+	return !t.Before(*p.AwayFrom) && t.Before(*p.AwayUntil)
 }
 
 type NewParticipant struct {
 	Name string `json:"name" validate:"required"`
 }
+
+// UpdateParticipant defines what information may be provided to modify an
+// existing participant. All fields are optional so clients can send just the
+// fields they want changed, an empty value clears the field.
+type UpdateParticipant struct {
+	Groups   *string  `json:"groups"` // Groups in format "backend,qa".
+	Away     *Absence `json:"away"`   // An absence without dates clears it.
+	Timezone *string  `json:"timezone"`
+	Lead     *string  `json:"lead"`
+}
+
+// Absence is the period a participant is away, the participant is back at
+// Until.
+type Absence struct {
+	From  *time.Time `json:"from"`
+	Until *time.Time `json:"until"`
+}
@@ -0,0 +1,71 @@
+package participant
+
+import "strings"
+
+// Key returns the form of a participant name used to match it: trimmed,
+// lowercased and without the leading "@", so "@Alice" and "alice" are the
+// same participant.
+func Key(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "@"))
+}
+
+// SplitNames splits a list of names separated by commas, e.g. "alice, Bob
+// Smith". A part with a mention is split by spaces as well, e.g. "@alice
+// @bob" or "alice @bob". Empty names and repeated ones are dropped.
+func SplitNames(raw string) []string {
+	seen := make(map[string]bool)
+	var names []string
+
+	add := func(name string) {
+		name = strings.Join(strings.Fields(name), " ")
+		if name == "" || seen[Key(name)] {
+			return
+		}
+		seen[Key(name)] = true
+		names = append(names, name)
+	}
+
+	for _, part := range strings.Split(raw, ",") {
+		fields := strings.Fields(part)
+
+		mentions := false
+		for _, f := range fields {
+			if strings.HasPrefix(f, "@") {
+				mentions = true
+			}
+		}
+
+		if !mentions {
+			add(part)
+			continue
+		}
+		for _, f := range fields {
+			add(f)
+		}
+	}
+
+	return names
+}
+
+// Find returns the participant with the given name, matched by Key.
+func Find(participants []Participant, name string) (Participant, bool) {
+	key := Key(name)
+	for _, p := range participants {
+		if Key(p.Name) == key {
+			return p, true
+		}
+	}
+
+	return Participant{}, false
+}
+
+// FindByID returns the participant with the given ID.
+func FindByID(participants []Participant, id string) (Participant, bool) {
+	for _, p := range participants {
+		if p.ID == id {
+			return p, true
+		}
+	}
+
+	return Participant{}, false
+}
@@ -14,23 +14,50 @@ import (
 var (
 	// ErrInvalidID is used when an invalid UUID is provided.
 	ErrInvalidID = errors.New("ID is not in its proper form")
+
+	// ErrNotFound is used when a specific Participant is requested but does not exist.
+	ErrNotFound = errors.New("Participant not found")
 )
 
-func List(ctx context.Context, db *sqlx.DB) ([]Participant, error) {
+// Store is the repository of reminder participants.
+type Store interface {
+	List(ctx context.Context) ([]Participant, error)
+	CreateOrUpdate(ctx context.Context, participant NewParticipant, now time.Time) (*Participant, error)
+	DeleteByName(ctx context.Context, name string) error
+	SetGroups(ctx context.Context, name string, groups []string, now time.Time) error
+	SetAbsence(ctx context.Context, name string, from *time.Time, until *time.Time, now time.Time) error
+	Link(ctx context.Context, name string, userID int64, newName string, now time.Time) error
+	SetTimezone(ctx context.Context, name string, timezone string, now time.Time) error
+	SetLead(ctx context.Context, name string, leadID string, now time.Time) error
+}
+
+// dbStore keeps participants in the participants table of a Postgres or
+// SQLite database.
+type dbStore struct {
+	db sqlx.ExtContext
+}
+
+// NewDBStore returns a Store backed by the participants table of db.
+func NewDBStore(db sqlx.ExtContext) Store {
+	return &dbStore{db: db}
+}
+
+func (s *dbStore) List(ctx context.Context) ([]Participant, error) {
 	ctx, span := trace.StartSpan(ctx, "internal.participant.List")
 	defer span.End()
 
 	var participants []Participant
-	const q = `select * from participants`
+	const q = `select * from participants
+		order by added_at, name`
 
-	if err := db.SelectContext(ctx, &participants, q); err != nil {
+	if err := sqlx.SelectContext(ctx, s.db, &participants, q); err != nil {
 		return nil, errors.Wrap(err, "selecting participants")
 	}
 
 	return participants, nil
 }
 
-func CreateOrUpdate(ctx context.Context, db *sqlx.DB, participant NewParticipant, now time.Time) (*Participant, error) {
+func (s *dbStore) CreateOrUpdate(ctx context.Context, participant NewParticipant, now time.Time) (*Participant, error) {
 	ctx, span := trace.StartSpan(ctx, "internal.participant.Create")
 	defer span.End()
 
@@ -48,7 +75,7 @@ func CreateOrUpdate(ctx context.Context, db *sqlx.DB, participant NewParticipant
 		(participant_id, name, added_at, updated_at)
 		values ($1, $2, $3, $4)`
 
-	res, err := db.ExecContext(ctx, updateQ,
+	res, err := s.db.ExecContext(ctx, updateQ,
 		p.UpdatedAt, p.Name,
 	)
 	if err != nil {
@@ -56,14 +83,14 @@ func CreateOrUpdate(ctx context.Context, db *sqlx.DB, participant NewParticipant
 	}
 
 	upd, err := res.RowsAffected()
-	if err == nil {
+	if err != nil {
 		return nil, errors.Wrap(err, "updating participant")
 	}
 	if upd > 0 {
 		return &p, nil
 	}
 
-	_, err = db.ExecContext(ctx, insertQ,
+	_, err = s.db.ExecContext(ctx, insertQ,
 		p.ID, p.Name, p.AddedAt, p.UpdatedAt,
 	)
 	if err != nil {
@@ -73,16 +100,146 @@ func CreateOrUpdate(ctx context.Context, db *sqlx.DB, participant NewParticipant
 	return &p, nil
 }
 
-func DeleteByName(ctx context.Context, db *sqlx.DB, name string) error {
+// DeleteByName deletes the named participant, the ones it leads are left
+// without a lead.
+func (s *dbStore) DeleteByName(ctx context.Context, name string) error {
 	ctx, span := trace.StartSpan(ctx, "internal.participant.DeleteByName")
 	defer span.End()
 
+	const leadQ = `update participants
+		set lead_id = ''
+		where lead_id in (select cast(participant_id as text) from participants where name = $1)`
 	const q = `delete from participants
 		where name = $1`
 
-	if _, err := db.ExecContext(ctx, q, name); err != nil {
+	if _, err := s.db.ExecContext(ctx, leadQ, name); err != nil {
+		return errors.Wrapf(err, "clearing lead %s", name)
+	}
+
+	if _, err := s.db.ExecContext(ctx, q, name); err != nil {
 		return errors.Wrapf(err, "deleting participant by name %s", name)
 	}
 
 	return nil
 }
+
+// SetGroups replaces the groups of the named participant.
+func (s *dbStore) SetGroups(ctx context.Context, name string, groups []string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.participant.SetGroups")
+	defer span.End()
+
+	const q = `update participants
+		set group_names = $1, updated_at = $2
+		where name = $3`
+
+	res, err := s.db.ExecContext(ctx, q, FormatGroups(groups), now.UTC(), name)
+	if err != nil {
+		return errors.Wrapf(err, "setting groups of participant %s", name)
+	}
+
+	upd, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "setting groups of participant %s", name)
+	}
+	if upd == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// SetAbsence sets the absence of the named participant, nil moments clear
+// it.
+func (s *dbStore) SetAbsence(ctx context.Context, name string, from *time.Time, until *time.Time, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.participant.SetAbsence")
+	defer span.End()
+
+	const q = `update participants
+		set away_from = $1, away_until = $2, updated_at = $3
+		where name = $4`
+
+	res, err := s.db.ExecContext(ctx, q, utc(from), utc(until), now.UTC(), name)
+	if err != nil {
+		return errors.Wrapf(err, "setting absence of participant %s", name)
+	}
+
+	upd, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "setting absence of participant %s", name)
+	}
+	if upd == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Link ties the named participant to a Telegram user and renames it to
+// newName, the name the user goes by now.
+func (s *dbStore) Link(ctx context.Context, name string, userID int64, newName string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.participant.Link")
+	defer span.End()
+
+	const q = `update participants
+		set user_id = $1, name = $2, updated_at = $3
+		where name = $4`
+
+	return s.update(ctx, q, name, userID, newName, now.UTC(), name)
+}
+
+// SetTimezone sets the time zone of the named participant, an empty one
+// falls back to the time zone of the reminder.
+func (s *dbStore) SetTimezone(ctx context.Context, name string, timezone string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.participant.SetTimezone")
+	defer span.End()
+
+	const q = `update participants
+		set timezone = $1, updated_at = $2
+		where name = $3`
+
+	return s.update(ctx, q, name, timezone, now.UTC(), name)
+}
+
+// SetLead sets the ID of the participant reminds of the named one are
+// escalated to, an empty one clears it. The lead stays the same participant
+// when it is renamed.
+func (s *dbStore) SetLead(ctx context.Context, name string, leadID string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.participant.SetLead")
+	defer span.End()
+
+	const q = `update participants
+		set lead_id = $1, updated_at = $2
+		where name = $3`
+
+	return s.update(ctx, q, name, leadID, now.UTC(), name)
+}
+
+// update runs a query changing the named participant, or returns
+// ErrNotFound if there is none.
+func (s *dbStore) update(ctx context.Context, q string, name string, args ...interface{}) error {
+	res, err := s.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return errors.Wrapf(err, "updating participant %s", name)
+	}
+
+	upd, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "updating participant %s", name)
+	}
+	if upd == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// utc converts an optional moment to UTC, the database keeps timestamps
+// without a time zone.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	u := t.UTC()
+	return &u
+}
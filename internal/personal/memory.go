@@ -0,0 +1,147 @@
+package personal
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps reminders in process memory. It is meant for tests and
+// short-lived deployments, nothing survives a restart.
+type memoryStore struct {
+	mu        sync.Mutex
+	reminders map[string]Reminder
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() Store {
+	return &memoryStore{reminders: make(map[string]Reminder)}
+}
+
+func (s *memoryStore) Create(ctx context.Context, nr NewReminder, now time.Time) (*Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := Reminder{
+		ID:        uuid.New().String(),
+		ChatID:    nr.ChatID,
+		UserID:    nr.UserID,
+		MessageID: nr.MessageID,
+		Text:      nr.Text,
+		DueAt:     nr.DueAt.UTC(),
+		Status:    StatusPending,
+		CreatedAt: now.UTC(),
+		UpdatedAt: now.UTC(),
+	}
+	s.reminders[r.ID] = r
+
+	return &r, nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, id string) (*Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.reminders[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &r, nil
+}
+
+func (s *memoryStore) ListByUser(ctx context.Context, userID int64) ([]Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reminders []Reminder
+	for _, r := range s.reminders {
+		if r.UserID == userID && r.Status == StatusPending {
+			reminders = append(reminders, r)
+		}
+	}
+
+	sortByDueAt(reminders)
+
+	return reminders, nil
+}
+
+func (s *memoryStore) ListPending(ctx context.Context, limit int) ([]Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reminders []Reminder
+	for _, r := range s.reminders {
+		if r.Status == StatusPending {
+			reminders = append(reminders, r)
+		}
+	}
+
+	sortByDueAt(reminders)
+
+	if len(reminders) > limit {
+		reminders = reminders[:limit]
+	}
+
+	return reminders, nil
+}
+
+func (s *memoryStore) Cancel(ctx context.Context, id string, now time.Time) (bool, error) {
+	return s.finish(id, StatusCancelled, "", now), nil
+}
+
+func (s *memoryStore) MarkSent(ctx context.Context, id string, now time.Time) (bool, error) {
+	return s.finish(id, StatusSent, "", now), nil
+}
+
+func (s *memoryStore) Retry(ctx context.Context, id string, lastError string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.reminders[id]
+	if !ok || r.Status != StatusPending {
+		return nil
+	}
+
+	r.Attempts++
+	r.LastError = lastError
+	r.UpdatedAt = now.UTC()
+	s.reminders[id] = r
+
+	return nil
+}
+
+func (s *memoryStore) Fail(ctx context.Context, id string, lastError string, now time.Time) error {
+	s.finish(id, StatusFailed, lastError, now)
+	return nil
+}
+
+// finish moves a pending reminder to the status and reports whether it was
+// pending.
+func (s *memoryStore) finish(id string, status Status, lastError string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.reminders[id]
+	if !ok || r.Status != StatusPending {
+		return false
+	}
+
+	r.Status = status
+	if lastError != "" {
+		r.LastError = lastError
+	}
+	r.UpdatedAt = now.UTC()
+	s.reminders[id] = r
+
+	return true
+}
+
+func sortByDueAt(reminders []Reminder) {
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].DueAt.Before(reminders[j].DueAt)
+	})
+}
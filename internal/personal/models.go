@@ -0,0 +1,36 @@
+package personal
+
+import "time"
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusSent      Status = "sent" // Queued in the outbox.
+	StatusCancelled Status = "cancelled"
+	StatusFailed    Status = "failed"
+)
+
+// Reminder is a one-off reminder a user set for themselves, delivered as a
+// reply to the message that set it.
+type Reminder struct {
+	ID        string    `db:"personal_reminder_id" json:"id"` // Unique identifier.
+	ChatID    string    `db:"chat_id" json:"chat_id"`         // Chat the reminder was set in.
+	UserID    int64     `db:"user_id" json:"user_id"`         // Telegram ID of the user who set it.
+	MessageID int       `db:"message_id" json:"message_id"`   // Message the reminder replies to.
+	Text      string    `db:"text" json:"text"`               // What to remind of.
+	DueAt     time.Time `db:"due_at" json:"due_at"`           // When to remind.
+	Status    Status    `db:"status" json:"status"`           // Delivery status.
+	Attempts  int       `db:"attempts" json:"attempts"`       // Number of failed delivery attempts.
+	LastError string    `db:"last_error" json:"last_error"`   // Error of the last failed attempt.
+	CreatedAt time.Time `db:"created_at" json:"created_at"`   // When the reminder was set.
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`   // When the reminder record was last modified.
+}
+
+type NewReminder struct {
+	ChatID    string    `json:"chat_id" validate:"required"`
+	UserID    int64     `json:"user_id" validate:"required"`
+	MessageID int       `json:"message_id"`
+	Text      string    `json:"text" validate:"required"`
+	DueAt     time.Time `json:"due_at" validate:"required"`
+}
@@ -0,0 +1,166 @@
+package personal
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+)
+
+var (
+	// clockRx matches a time of day, e.g. "9:30" or "10:00".
+	clockRx = regexp.MustCompile(`^\d{1,2}:\d{2}$`)
+
+	// daysRx matches a number of days or weeks, e.g. "2d" or "1w".
+	daysRx = regexp.MustCompile(`^(\d+)([dw])$`)
+)
+
+// units maps the accepted names of duration units to their length, days and
+// weeks being counted in calendar days.
+var units = map[string]time.Duration{
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// weekdays maps the accepted names of weekdays, full and abbreviated.
+var weekdays = map[string]time.Weekday{}
+
+func init() {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		weekdays[name] = d
+		weekdays[name[:3]] = d
+	}
+}
+
+// Parse reads when to remind and of what from the text of /remindme, e.g.
+// "in 2h check the deploy", "in 30 minutes stand up" or "tomorrow 10:00 call
+// vendor". The day is one of "today", "tomorrow", a weekday or a date in
+// format "2006-01-02" and may be left out for the next time the clock shows
+// the time of day. Days are calendar days in loc, so "in 1d" keeps the time
+// of day across a daylight saving change. The moment must be after now.
+func Parse(raw string, now time.Time, loc *time.Location) (time.Time, string, error) {
+	fields := strings.Fields(raw)
+	if len(fields) == 0 {
+		return time.Time{}, "", errors.New("missing when to remind")
+	}
+
+	now = now.In(loc)
+
+	var (
+		at   time.Time
+		rest []string
+		err  error
+	)
+	if strings.EqualFold(fields[0], "in") {
+		at, rest, err = parseIn(fields[1:], now)
+	} else {
+		at, rest, err = parseAt(fields, now)
+	}
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	if !at.After(now) {
+		return time.Time{}, "", errors.Errorf("%s has already passed", at.Format("Mon 2 Jan 2006 15:04"))
+	}
+
+	text := strings.Join(rest, " ")
+	if text == "" {
+		return time.Time{}, "", errors.New("missing what to remind of")
+	}
+
+	return at, text, nil
+}
+
+// parseIn reads a delay after "in", e.g. "2h", "1h30m", "2d" or "3 hours",
+// and returns the moment it ends and the remaining fields.
+func parseIn(fields []string, now time.Time) (time.Time, []string, error) {
+	if len(fields) == 0 {
+		return time.Time{}, nil, errors.New(`missing the delay after "in", e.g. "in 2h"`)
+	}
+
+	raw := strings.ToLower(fields[0])
+
+	// "3 hours"
+	if n, err := strconv.Atoi(raw); err == nil && len(fields) > 1 {
+		unit, ok := units[strings.ToLower(fields[1])]
+		if !ok {
+			return time.Time{}, nil, errors.Errorf("unknown unit %q, use minutes, hours, days or weeks", fields[1])
+		}
+		return add(now, n, unit), fields[2:], nil
+	}
+
+	// "2d" or "1w"
+	if match := daysRx.FindStringSubmatch(raw); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		return add(now, n, units[match[2]]), fields[1:], nil
+	}
+
+	// "2h" or "1h30m"
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return time.Time{}, nil, errors.Errorf("invalid delay %q, expected e.g. 30m, 2h or 1d", fields[0])
+	}
+
+	return now.Add(d), fields[1:], nil
+}
+
+// add returns the moment n units after now, counting days and weeks in
+// calendar days.
+func add(now time.Time, n int, unit time.Duration) time.Time {
+	if unit%(24*time.Hour) == 0 {
+		return now.AddDate(0, 0, n*int(unit/(24*time.Hour)))
+	}
+
+	return now.Add(time.Duration(n) * unit)
+}
+
+// parseAt reads an optional day followed by an optional "at" and a time of
+// day, and returns the moment and the remaining fields. Without a day the
+// time of day is the next one to come, and a weekday is the next one whose
+// time of day is still to come.
+func parseAt(fields []string, now time.Time) (time.Time, []string, error) {
+	day, next := now, 1
+
+	raw := strings.ToLower(fields[0])
+	if d, err := time.ParseInLocation("2006-01-02", raw, now.Location()); err == nil {
+		day, next = d, 0
+		fields = fields[1:]
+	} else if wd, ok := weekdays[raw]; ok {
+		day, next = now.AddDate(0, 0, (int(wd)-int(now.Weekday())+7)%7), 7
+		fields = fields[1:]
+	} else if raw == "today" {
+		next = 0
+		fields = fields[1:]
+	} else if raw == "tomorrow" {
+		day, next = now.AddDate(0, 0, 1), 0
+		fields = fields[1:]
+	}
+
+	if len(fields) > 0 && strings.EqualFold(fields[0], "at") {
+		fields = fields[1:]
+	}
+
+	if len(fields) == 0 || !clockRx.MatchString(fields[0]) {
+		return time.Time{}, nil, errors.New(`missing the time, e.g. "in 2h" or "tomorrow 10:00"`)
+	}
+
+	hour, min, err := reminder.ParseRemindTime(fields[0])
+	if err != nil {
+		return time.Time{}, nil, errors.Errorf("invalid time %q", fields[0])
+	}
+
+	at := time.Date(day.Year(), day.Month(), day.Day(), hour, min, 0, 0, now.Location())
+	if !at.After(now) && next > 0 {
+		at = time.Date(day.Year(), day.Month(), day.Day()+next, hour, min, 0, 0, now.Location())
+	}
+
+	return at, fields[1:], nil
+}
@@ -0,0 +1,64 @@
+package personal_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/personal"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestParse validates when and what to remind of are read from the text of
+// /remindme, and texts missing either are refused.
+func TestParse(t *testing.T) {
+	// Monday 2 March 2020.
+	now := time.Date(2020, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		raw  string
+		at   time.Time
+		text string
+		err  string
+	}{
+		{raw: "in 2h check the deploy", at: now.Add(2 * time.Hour), text: "check the deploy"},
+		{raw: "in 3 hours check the deploy", at: now.Add(3 * time.Hour), text: "check the deploy"},
+		{raw: "tomorrow 10:00 call vendor", at: time.Date(2020, 3, 3, 10, 0, 0, 0, time.UTC), text: "call vendor"},
+		{raw: "friday at 9:30 demo", at: time.Date(2020, 3, 6, 9, 30, 0, 0, time.UTC), text: "demo"},
+		{raw: "monday 10:00 plan", at: time.Date(2020, 3, 9, 10, 0, 0, 0, time.UTC), text: "plan"},
+		{raw: "10:00 stand up", at: time.Date(2020, 3, 3, 10, 0, 0, 0, time.UTC), text: "stand up"},
+		{raw: "today 10:00 stand up", err: "has already passed"},
+		{raw: "2020-03-01 10:00 stand up", err: "has already passed"},
+		{raw: "friday x", err: "missing the time"},
+		{raw: "friday", err: "missing the time"},
+		{raw: "in 2h", err: "missing what to remind of"},
+		{raw: "tomorrow 10:00", err: "missing what to remind of"},
+		{raw: "in", err: "missing the delay"},
+		{raw: "in 2 parsecs x", err: "unknown unit"},
+		{raw: "in soon x", err: "invalid delay"},
+		{raw: "today 25:00 x", err: "invalid time"},
+	}
+
+	t.Log("Given the text of /remindme.")
+	{
+		for _, tc := range tt {
+			at, text, err := personal.Parse(tc.raw, now, time.UTC)
+
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("\t%s\tShould refuse %q : got %v, want %q.", tests.Failed, tc.raw, err, tc.err)
+				}
+				t.Logf("\t%s\tShould refuse %q.", tests.Success, tc.raw)
+				continue
+			}
+
+			if err != nil {
+				t.Fatalf("\t%s\tShould be able to parse %q : %s.", tests.Failed, tc.raw, err)
+			}
+			if !at.Equal(tc.at) || text != tc.text {
+				t.Fatalf("\t%s\tShould read %q : got %s %q, want %s %q.", tests.Failed, tc.raw, at, text, tc.at, tc.text)
+			}
+			t.Logf("\t%s\tShould read %q.", tests.Success, tc.raw)
+		}
+	}
+}
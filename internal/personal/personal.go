@@ -0,0 +1,215 @@
+// Package personal keeps the one-off reminders users set for themselves with
+// /remindme, apart from the recurring team reminder.
+package personal
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when a specific Reminder is requested but does not exist.
+	ErrNotFound = errors.New("Personal reminder not found")
+)
+
+// Store is the repository of personal reminders.
+type Store interface {
+	// Create records a pending reminder.
+	Create(ctx context.Context, nr NewReminder, now time.Time) (*Reminder, error)
+
+	// Get returns the reminder with the ID.
+	Get(ctx context.Context, id string) (*Reminder, error)
+
+	// ListByUser returns the pending reminders of the user, the earliest
+	// first.
+	ListByUser(ctx context.Context, userID int64) ([]Reminder, error)
+
+	// ListPending returns the reminders that were not delivered yet, the
+	// earliest first.
+	ListPending(ctx context.Context, limit int) ([]Reminder, error)
+
+	// Cancel cancels a pending reminder and reports whether it was pending.
+	Cancel(ctx context.Context, id string, now time.Time) (bool, error)
+
+	// MarkSent marks a pending reminder as sent and reports whether it was
+	// pending, so only one delivery queues its message.
+	MarkSent(ctx context.Context, id string, now time.Time) (bool, error)
+
+	// Retry records a failed delivery attempt, the reminder stays pending.
+	Retry(ctx context.Context, id string, lastError string, now time.Time) error
+
+	// Fail marks a pending reminder as undeliverable.
+	Fail(ctx context.Context, id string, lastError string, now time.Time) error
+}
+
+// dbStore keeps reminders in the personal_reminders table of a Postgres or
+// SQLite database.
+type dbStore struct {
+	db sqlx.ExtContext
+}
+
+// NewDBStore returns a Store backed by the personal_reminders table of db.
+func NewDBStore(db sqlx.ExtContext) Store {
+	return &dbStore{db: db}
+}
+
+func (s *dbStore) Create(ctx context.Context, nr NewReminder, now time.Time) (*Reminder, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.personal.Create")
+	defer span.End()
+
+	r := Reminder{
+		ID:        uuid.New().String(),
+		ChatID:    nr.ChatID,
+		UserID:    nr.UserID,
+		MessageID: nr.MessageID,
+		Text:      nr.Text,
+		DueAt:     nr.DueAt.UTC(),
+		Status:    StatusPending,
+		CreatedAt: now.UTC(),
+		UpdatedAt: now.UTC(),
+	}
+
+	const q = `insert into personal_reminders
+		(personal_reminder_id, chat_id, user_id, message_id, text, due_at, status, attempts, last_error, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := s.db.ExecContext(ctx, q,
+		r.ID, r.ChatID, r.UserID, r.MessageID, r.Text, r.DueAt,
+		r.Status, r.Attempts, r.LastError, r.CreatedAt, r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting personal reminder")
+	}
+
+	return &r, nil
+}
+
+func (s *dbStore) Get(ctx context.Context, id string) (*Reminder, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.personal.Get")
+	defer span.End()
+
+	var r Reminder
+	const q = `select * from personal_reminders where personal_reminder_id = $1`
+
+	if err := sqlx.GetContext(ctx, s.db, &r, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrapf(err, "selecting personal reminder %q", id)
+	}
+
+	return &r, nil
+}
+
+func (s *dbStore) ListByUser(ctx context.Context, userID int64) ([]Reminder, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.personal.ListByUser")
+	defer span.End()
+
+	var reminders []Reminder
+	const q = `select * from personal_reminders
+		where user_id = $1 and status = $2
+		order by due_at`
+
+	if err := sqlx.SelectContext(ctx, s.db, &reminders, q, userID, StatusPending); err != nil {
+		return nil, errors.Wrap(err, "selecting personal reminders of user")
+	}
+
+	return reminders, nil
+}
+
+func (s *dbStore) ListPending(ctx context.Context, limit int) ([]Reminder, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.personal.ListPending")
+	defer span.End()
+
+	var reminders []Reminder
+	const q = `select * from personal_reminders
+		where status = $1
+		order by due_at
+		limit $2`
+
+	if err := sqlx.SelectContext(ctx, s.db, &reminders, q, StatusPending, limit); err != nil {
+		return nil, errors.Wrap(err, "selecting pending personal reminders")
+	}
+
+	return reminders, nil
+}
+
+func (s *dbStore) Cancel(ctx context.Context, id string, now time.Time) (bool, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.personal.Cancel")
+	defer span.End()
+
+	const q = `update personal_reminders
+		set status = $1, updated_at = $2
+		where personal_reminder_id = $3 and status = $4`
+
+	res, err := s.db.ExecContext(ctx, q, StatusCancelled, now.UTC(), id, StatusPending)
+	if err != nil {
+		return false, errors.Wrap(err, "cancelling personal reminder")
+	}
+
+	upd, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cancelling personal reminder")
+	}
+
+	return upd > 0, nil
+}
+
+func (s *dbStore) MarkSent(ctx context.Context, id string, now time.Time) (bool, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.personal.MarkSent")
+	defer span.End()
+
+	const q = `update personal_reminders
+		set status = $1, updated_at = $2
+		where personal_reminder_id = $3 and status = $4`
+
+	res, err := s.db.ExecContext(ctx, q, StatusSent, now.UTC(), id, StatusPending)
+	if err != nil {
+		return false, errors.Wrap(err, "marking personal reminder as sent")
+	}
+
+	upd, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "marking personal reminder as sent")
+	}
+
+	return upd > 0, nil
+}
+
+func (s *dbStore) Retry(ctx context.Context, id string, lastError string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.personal.Retry")
+	defer span.End()
+
+	const q = `update personal_reminders
+		set attempts = attempts + 1, last_error = $1, updated_at = $2
+		where personal_reminder_id = $3 and status = $4`
+
+	if _, err := s.db.ExecContext(ctx, q, lastError, now.UTC(), id, StatusPending); err != nil {
+		return errors.Wrap(err, "recording personal reminder attempt")
+	}
+
+	return nil
+}
+
+func (s *dbStore) Fail(ctx context.Context, id string, lastError string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.personal.Fail")
+	defer span.End()
+
+	const q = `update personal_reminders
+		set status = $1, last_error = $2, updated_at = $3
+		where personal_reminder_id = $4 and status = $5`
+
+	if _, err := s.db.ExecContext(ctx, q, StatusFailed, lastError, now.UTC(), id, StatusPending); err != nil {
+		return errors.Wrap(err, "failing personal reminder")
+	}
+
+	return nil
+}
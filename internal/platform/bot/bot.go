@@ -8,10 +8,12 @@ import (
 
 type Config struct {
 	Token string
+	URL   string // Bot API server, the public Telegram one when empty.
 }
 
 func Create(cfg Config) (*tb.Bot, error) {
 	telebot, err := tb.NewBot(tb.Settings{
+		URL:    cfg.URL,
 		Token:  cfg.Token,
 		Poller: &tb.LongPoller{Timeout: 10 * time.Second},
 	})
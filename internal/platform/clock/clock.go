@@ -0,0 +1,180 @@
+// Package clock abstracts the passage of time so code that depends on it
+// can be driven by a fake clock in tests.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+	NewTimer(d time.Duration) Timer
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Timer fires once after its duration, like time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the wall clock backed by the time package.
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+func (Real) NewTicker(d time.Duration) Ticker {
+	return &realTicker{ticker: time.NewTicker(d)}
+}
+
+func (Real) NewTimer(d time.Duration) Timer {
+	return &realTimer{timer: time.NewTimer(d)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t *realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t *realTimer) Stop() {
+	t.timer.Stop()
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t *realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t *realTicker) Stop() {
+	t.ticker.Stop()
+}
+
+// Fake is a manually advanced clock.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// NewFake returns a fake clock frozen at now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTicker{
+		clock:    f,
+		c:        make(chan time.Time, 1),
+		interval: d,
+		next:     f.now.Add(d),
+	}
+	f.tickers = append(f.tickers, t)
+
+	return t
+}
+
+// NewTimer returns a timer firing once the clock is advanced by d. A timer
+// with a non-positive duration fires right away.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTicker{
+		clock: f,
+		c:     make(chan time.Time, 1),
+		next:  f.now.Add(d),
+	}
+
+	if d <= 0 {
+		t.c <- f.now
+		return t
+	}
+
+	f.tickers = append(f.tickers, t)
+
+	return t
+}
+
+// Advance moves the clock forward and fires every ticker and timer that
+// became due. Like time.Ticker, ticks are dropped when the receiver falls
+// behind.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+
+	tickers := f.tickers[:0]
+	for _, t := range f.tickers {
+		for !t.next.After(f.now) {
+			select {
+			case t.c <- t.next:
+			default:
+			}
+
+			// A timer is a ticker firing once.
+			if t.interval == 0 {
+				break
+			}
+			t.next = t.next.Add(t.interval)
+		}
+
+		if t.interval != 0 || t.next.After(f.now) {
+			tickers = append(tickers, t)
+		}
+	}
+	f.tickers = tickers
+}
+
+// Set moves the clock to now, which must not be earlier than the current time.
+func (f *Fake) Set(now time.Time) {
+	f.Advance(now.Sub(f.Now()))
+}
+
+// fakeTicker is a ticker of the fake clock, or a timer if interval is zero.
+type fakeTicker struct {
+	clock    *Fake
+	c        chan time.Time
+	interval time.Duration
+	next     time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, ft := range t.clock.tickers {
+		if ft == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
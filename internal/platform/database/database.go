@@ -5,19 +5,40 @@ import (
 	"net/url"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // The database driver in use.
+	_ "github.com/lib/pq"           // The database driver in use.
+	_ "github.com/mattn/go-sqlite3" // The embedded database driver.
+	"github.com/pkg/errors"
 	"go.opencensus.io/trace"
 )
 
+// Supported database drivers.
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite3"
+)
+
 type Config struct {
+	Driver     string
 	User       string
 	Password   string
 	Host       string
 	Name       string
 	DisableTLS bool
+	Path       string // Database file, used by SQLite only.
 }
 
 func Open(cfg Config) (*sqlx.DB, error) {
+	switch cfg.Driver {
+	case "", Postgres:
+		return openPostgres(cfg)
+	case SQLite:
+		return openSQLite(cfg)
+	default:
+		return nil, errors.Errorf("unknown database driver: %s", cfg.Driver)
+	}
+}
+
+func openPostgres(cfg Config) (*sqlx.DB, error) {
 	// Define SSL mode.
 	sslMode := "require"
 	if cfg.DisableTLS {
@@ -38,7 +59,24 @@ func Open(cfg Config) (*sqlx.DB, error) {
 		RawQuery: q.Encode(),
 	}
 
-	return sqlx.Open("postgres", u.String())
+	return sqlx.Open(Postgres, u.String())
+}
+
+func openSQLite(cfg Config) (*sqlx.DB, error) {
+	q := make(url.Values)
+	q.Set("_busy_timeout", "5000")
+	q.Set("_loc", "UTC")
+
+	db, err := sqlx.Open(SQLite, "file:"+cfg.Path+"?"+q.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, sharing one connection avoids
+	// "database is locked" errors between concurrent handlers.
+	db.SetMaxOpenConns(1)
+
+	return db, nil
 }
 
 func StatusCheck(ctx context.Context, db *sqlx.DB) error {
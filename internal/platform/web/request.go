@@ -0,0 +1,21 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Decode reads the body of an HTTP request looking for a JSON document. The
+// body is decoded into the provided value, unknown fields are rejected.
+func Decode(r *http.Request, val interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(val); err != nil {
+		return NewRequestError(errors.Wrap(err, "decoding request"), http.StatusBadRequest)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Respond converts a Go value to JSON and sends it to the client. No body is
+// sent for a nil value or http.StatusNoContent.
+func Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statusCode int) error {
+	if v, ok := ctx.Value(KeyValues).(*Values); ok {
+		v.StatusCode = statusCode
+	}
+
+	if data == nil || statusCode == http.StatusNoContent {
+		w.WriteHeader(statusCode)
+		return nil
+	}
+
+	res, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, "marshalling response")
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	if _, err := w.Write(res); err != nil {
+		return errors.Wrap(err, "writing response")
+	}
+
+	return nil
+}
+
+// RespondError sends an error response back to the client. The message of
+// an Error is shown to the client, any other error is reported as an
+// internal one.
+func RespondError(ctx context.Context, w http.ResponseWriter, err error) error {
+	if webErr, ok := errors.Cause(err).(*Error); ok {
+		er := ErrorResponse{
+			Error: webErr.Err.Error(),
+		}
+		return Respond(ctx, w, er, webErr.Status)
+	}
+
+	er := ErrorResponse{
+		Error: http.StatusText(http.StatusInternalServerError),
+	}
+	return Respond(ctx, w, er, http.StatusInternalServerError)
+}
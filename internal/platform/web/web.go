@@ -0,0 +1,139 @@
+// Package web is a small framework for the HTTP API of the bot: routing
+// with path parameters, middleware, and JSON requests and responses.
+package web
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+// ctxKey represents the type of value for the context key.
+type ctxKey int
+
+// KeyValues is how request values are stored and retrieved.
+const KeyValues ctxKey = 1
+
+// Values represent state for each request.
+type Values struct {
+	Now        time.Time
+	StatusCode int
+}
+
+// Handler is the signature of the functions handling requests. The path
+// parameters are the segments of the route pattern starting with ":".
+type Handler func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error
+
+// Middleware runs some code before and/or after another Handler.
+type Middleware func(Handler) Handler
+
+// route is a registered handler with the pattern split into segments.
+type route struct {
+	method   string
+	segments []string
+	handler  Handler
+}
+
+// App is the entrypoint into the API, it routes the requests to the
+// registered handlers wrapped in the middleware.
+type App struct {
+	routes []route
+	mw     []Middleware
+}
+
+// NewApp creates an App wrapping every handler in mw, the first one being
+// the outermost.
+func NewApp(mw ...Middleware) *App {
+	return &App{mw: mw}
+}
+
+// Handle registers the handler for the method and the path pattern, e.g.
+// "/v1/reminders/:id". The middleware given here runs inside the one of the
+// App.
+func (a *App) Handle(method string, pattern string, handler Handler, mw ...Middleware) {
+	handler = wrapMiddleware(mw, handler)
+	handler = wrapMiddleware(a.mw, handler)
+
+	a.routes = append(a.routes, route{
+		method:   method,
+		segments: split(pattern),
+		handler:  handler,
+	})
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, span := trace.StartSpan(r.Context(), "internal.platform.web")
+	defer span.End()
+
+	v := Values{
+		Now: time.Now(),
+	}
+	ctx = context.WithValue(ctx, KeyValues, &v)
+
+	segments := split(r.URL.Path)
+
+	allowed := false
+	for _, rt := range a.routes {
+		params, ok := rt.match(segments)
+		if !ok {
+			continue
+		}
+		if rt.method != r.Method {
+			allowed = true
+			continue
+		}
+
+		// Errors are handled by the middleware, whatever gets here was
+		// already responded to.
+		rt.handler(ctx, w, r.WithContext(ctx), params)
+		return
+	}
+
+	if allowed {
+		RespondError(ctx, w, NewRequestError(ErrMethodNotAllowed, http.StatusMethodNotAllowed))
+		return
+	}
+
+	RespondError(ctx, w, NewRequestError(ErrNotFound, http.StatusNotFound))
+}
+
+// match returns the path parameters if the segments of a path match the
+// route.
+func (rt route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, s := range rt.segments {
+		if strings.HasPrefix(s, ":") {
+			params[s[1:]] = segments[i]
+			continue
+		}
+		if s != segments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// wrapMiddleware wraps handler in mw, the first middleware being the
+// outermost.
+func wrapMiddleware(mw []Middleware, handler Handler) Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		if mw[i] != nil {
+			handler = mw[i](handler)
+		}
+	}
+
+	return handler
+}
+
+func split(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
@@ -0,0 +1,98 @@
+package reminder
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Escalation actions taken for a participant who didn't acknowledge a
+// remind.
+const (
+	EscalateDM   = "dm"   // Message the participant directly.
+	EscalateLead = "lead" // Message the lead of the participant.
+	EscalateChat = "chat" // Post in another chat, e.g. the management one.
+)
+
+// EscalationStep is an action taken once a remind is not acknowledged for
+// the delay since it was due.
+type EscalationStep struct {
+	Delay  time.Duration
+	Action string
+	ChatID string // Chat to post in for EscalateChat.
+}
+
+// ParseEscalation reads a comma separated list of escalation steps, each a
+// delay followed by an action, e.g. "2h dm, 4h lead, 8h chat -1001234". The
+// steps are sorted by the delay. An empty list is no escalation.
+func ParseEscalation(raw string) ([]EscalationStep, error) {
+	var steps []EscalationStep
+
+	for _, item := range strings.Split(raw, ",") {
+		fields := strings.Fields(item)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, errors.Errorf("invalid escalation step %q, expected a delay and an action", strings.TrimSpace(item))
+		}
+
+		delay, err := time.ParseDuration(fields[0])
+		if err != nil || delay <= 0 {
+			return nil, errors.Errorf("invalid escalation delay %q, expected e.g. 2h or 30m", fields[0])
+		}
+
+		step := EscalationStep{
+			Delay:  delay,
+			Action: strings.ToLower(fields[1]),
+		}
+
+		switch {
+		case (step.Action == EscalateDM || step.Action == EscalateLead) && len(fields) == 2:
+		case step.Action == EscalateChat && len(fields) == 3:
+			if _, err := strconv.ParseInt(fields[2], 10, 64); err != nil {
+				return nil, errors.Errorf("invalid chat id %q", fields[2])
+			}
+			step.ChatID = fields[2]
+		default:
+			return nil, errors.Errorf(`invalid escalation step %q, expected "dm", "lead" or "chat <chat id>"`, strings.TrimSpace(item))
+		}
+
+		steps = append(steps, step)
+	}
+
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Delay < steps[j].Delay
+	})
+
+	return steps, nil
+}
+
+// FormatEscalation writes steps in the format of ParseEscalation.
+func FormatEscalation(steps []EscalationStep) string {
+	items := make([]string, len(steps))
+	for i, s := range steps {
+		items[i] = formatDelay(s.Delay) + " " + s.Action
+		if s.ChatID != "" {
+			items[i] += " " + s.ChatID
+		}
+	}
+
+	return strings.Join(items, ", ")
+}
+
+// formatDelay writes whole hours and minutes the short way, "2h" rather
+// than "2h0m0s".
+func formatDelay(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	default:
+		return d.String()
+	}
+}
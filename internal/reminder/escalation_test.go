@@ -0,0 +1,57 @@
+package reminder_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestParseEscalation validates escalation steps are read sorted by their
+// delay and written back the short way, and invalid steps are refused.
+func TestParseEscalation(t *testing.T) {
+	tt := []struct {
+		raw  string
+		want string
+		err  string
+	}{
+		{raw: "", want: ""},
+		{raw: "2h dm", want: "2h dm"},
+		{raw: "2h dm, 4h lead, 8h chat -1001234", want: "2h dm, 4h lead, 8h chat -1001234"},
+		{raw: "8h CHAT -1001234, 2h DM,, 90m lead", want: "90m lead, 2h dm, 8h chat -1001234"},
+		{raw: "120m dm, 1h30m lead", want: "90m lead, 2h dm"},
+		{raw: "45s dm", want: "45s dm"},
+		{raw: "2h", err: "expected a delay and an action"},
+		{raw: "soon dm", err: "invalid escalation delay"},
+		{raw: "0h dm", err: "invalid escalation delay"},
+		{raw: "-1h dm", err: "invalid escalation delay"},
+		{raw: "2h email", err: "expected \"dm\", \"lead\" or \"chat <chat id>\""},
+		{raw: "2h dm -1001234", err: "expected \"dm\", \"lead\" or \"chat <chat id>\""},
+		{raw: "2h chat", err: "expected \"dm\", \"lead\" or \"chat <chat id>\""},
+		{raw: "2h chat team", err: "invalid chat id"},
+	}
+
+	t.Log("Given the steps of /escalation.")
+	{
+		for _, tc := range tt {
+			steps, err := reminder.ParseEscalation(tc.raw)
+
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("\t%s\tShould refuse %q : got %v, want %q.", tests.Failed, tc.raw, err, tc.err)
+				}
+				t.Logf("\t%s\tShould refuse %q.", tests.Success, tc.raw)
+				continue
+			}
+
+			if err != nil {
+				t.Fatalf("\t%s\tShould be able to parse %q : %s.", tests.Failed, tc.raw, err)
+			}
+			if got := reminder.FormatEscalation(steps); got != tc.want {
+				t.Fatalf("\t%s\tShould read %q : got %q, want %q.", tests.Failed, tc.raw, got, tc.want)
+			}
+			t.Logf("\t%s\tShould read %q.", tests.Success, tc.raw)
+		}
+	}
+}
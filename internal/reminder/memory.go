@@ -0,0 +1,73 @@
+package reminder
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryStore keeps schedules in process memory. It is meant for tests and
+// short-lived deployments, nothing survives a restart.
+type memoryStore struct {
+	mu        sync.RWMutex
+	schedules map[string]Schedule
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() Store {
+	return &memoryStore{schedules: make(map[string]Schedule)}
+}
+
+func (s *memoryStore) List(ctx context.Context) ([]Schedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schedules := make([]Schedule, 0, len(s.schedules))
+	for _, sch := range s.schedules {
+		schedules = append(schedules, sch)
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+
+	return schedules, nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, id string) (*Schedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sch, ok := s.schedules[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &sch, nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, sch Schedule, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.schedules[sch.ID]; ok {
+		sch.CreatedAt = old.CreatedAt
+	} else {
+		sch.CreatedAt = now.UTC()
+	}
+	sch.UpdatedAt = now.UTC()
+
+	s.schedules[sch.ID] = sch
+
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.schedules, id)
+
+	return nil
+}
@@ -1,15 +1,86 @@
 package reminder
 
-import "time"
+import (
+	"context"
+	"sync"
+	"time"
 
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+	"github.com/tmowka/telegram-reminder-bot/internal/scheduler"
+)
+
+// Reminder fires at the remind time of every interval, except for the
+// skipped reminds. It is safe for concurrent use, the state is read through
+// Snapshot.
 type Reminder struct {
+	mu             sync.Mutex
+	weekdaysToSkip map[time.Weekday]struct{}
+	holidays       map[string]struct{}
+	interval       time.Duration
+	location       *time.Location
+	remindTime     time.Time
+	rawRemindTime  string
+	pausedUntil    time.Time
+	snoozedUntil   time.Time
+
+	id        string
+	clock     clock.Clock
+	scheduler *scheduler.Scheduler
+	run       *run
+}
+
+// run is a started reminder, from Start until it is stopped.
+type run struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	remindChan chan time.Time
+	stopped    bool
+	sending    sync.WaitGroup
+	done       chan struct{}
+}
+
+// Snapshot is a copy of the state of a Reminder at some moment.
+type Snapshot struct {
 	WeekdaysToSkip map[time.Weekday]struct{}
+	Holidays       map[string]struct{}
 	Interval       time.Duration
 	Location       *time.Location
 	RemindTime     time.Time
+	PausedUntil    time.Time
+	SnoozedUntil   time.Time
 	Started        bool
+}
+
+// Schedule is the persisted definition of a reminder.
+type Schedule struct {
+	ID             string     `db:"reminder_id" json:"id"`                        // Unique identifier.
+	RemindTime     string     `db:"remind_time" json:"remind_time"`               // Time of the remind in format "HH:MM".
+	WeekdaysToSkip string     `db:"weekdays_to_skip" json:"weekdays_to_skip"`     // Weekdays to skip in format "0,1,2" (0 - is Sunday).
+	Message        string     `db:"message" json:"message"`                       // Remind message.
+	Groups         string     `db:"group_names" json:"groups,omitempty"`          // Participant groups to mention in format "backend,qa", everyone if empty.
+	Escalation     string     `db:"escalation" json:"escalation,omitempty"`       // Escalation steps in format "2h dm, 4h lead, 8h chat -1001234".
+	PausedUntil    *time.Time `db:"paused_until" json:"paused_until,omitempty"`   // Reminds due before this moment are skipped.
+	SnoozedUntil   *time.Time `db:"snoozed_until" json:"snoozed_until,omitempty"` // The next remind is held back until this moment.
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`                 // When the reminder was added.
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`                 // When the reminder record was last modified.
+}
+
+// NewReminder is what a reminder is created from.
+type NewReminder struct {
+	RemindTime     string `json:"remind_time"`      // Time of the remind in format "HH:MM".
+	WeekdaysToSkip string `json:"weekdays_to_skip"` // Weekdays to skip in format "0,1,2" (0 - is Sunday).
+	Message        string `json:"message"`          // Remind message, the default one if empty.
+	Groups         string `json:"groups"`           // Participant groups to mention in format "backend,qa", everyone if empty.
+	Escalation     string `json:"escalation"`       // Escalation steps in format "2h dm, 4h lead, 8h chat -1001234".
+}
 
-	ticker     *time.Ticker
-	clearChan  chan struct{}
-	remindChan chan struct{}
+// UpdateReminder defines what information may be provided to modify an
+// existing reminder. All fields are optional so clients can send just the
+// fields they want changed.
+type UpdateReminder struct {
+	RemindTime     *string `json:"remind_time"`
+	WeekdaysToSkip *string `json:"weekdays_to_skip"`
+	Message        *string `json:"message"`
+	Groups         *string `json:"groups"`
+	Escalation     *string `json:"escalation"`
 }
@@ -2,119 +2,458 @@ package reminder
 
 import (
 	"context"
-	"go.opencensus.io/trace"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+	"github.com/tmowka/telegram-reminder-bot/internal/scheduler"
 )
 
-func New(interval time.Duration, location *time.Location) *Reminder {
+const dateLayout = "2006-01-02"
+
+// maxLookahead bounds the search for the next remind, one year of daily
+// reminds.
+const maxLookahead = 366
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotStarted is used when the next remind is requested from a stopped reminder.
+	ErrNotStarted = errors.New("Reminder is not started")
+
+	// ErrStarted is used when a running reminder is started again.
+	ErrStarted = errors.New("Reminder is already started")
+
+	// ErrNoRemind is used when every remind is skipped.
+	ErrNoRemind = errors.New("Reminder never fires")
+)
+
+// New returns a stopped reminder. Once started it is run by sched under id,
+// which must be unique among the jobs of sched.
+func New(clk clock.Clock, sched *scheduler.Scheduler, id string, interval time.Duration, location *time.Location) *Reminder {
 	return &Reminder{
-		WeekdaysToSkip: make(map[time.Weekday]struct{}),
-		Interval:       interval,
-		Location:       location,
-		clearChan:      make(chan struct{}, 1),
+		id:             id,
+		clock:          clk,
+		scheduler:      sched,
+		weekdaysToSkip: make(map[time.Weekday]struct{}),
+		holidays:       make(map[string]struct{}),
+		interval:       interval,
+		location:       location,
 	}
 }
 
-func (r *Reminder) Start(rawRemindTime string) (chan struct{}, error) {
-	_, span := trace.StartSpan(context.Background(), "reminder.Reminder.Start")
+// Start runs the reminder at the given remind time in format "HH:MM". The
+// returned channel receives the time a remind was due at whenever one fires,
+// so a fired remind can be told apart from any other, and is closed once the
+// reminder is stopped or ctx is done. Starting a running reminder fails with
+// ErrStarted, use Reschedule to move it.
+func (r *Reminder) Start(ctx context.Context, rawRemindTime string) (<-chan time.Time, error) {
+	_, span := trace.StartSpan(ctx, "reminder.Reminder.Start")
 	defer span.End()
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.run != nil {
+		return nil, ErrStarted
+	}
+
 	remindTime, err := r.parseTime(rawRemindTime)
 	if err != nil {
 		return nil, errors.Wrap(err, "no remind time set")
 	}
 
-	r.RemindTime = remindTime
+	ctx, cancel := context.WithCancel(ctx)
+	rn := &run{
+		ctx:        ctx,
+		cancel:     cancel,
+		remindChan: make(chan time.Time, 1),
+		done:       make(chan struct{}),
+	}
+
+	r.remindTime = remindTime
+	r.rawRemindTime = rawRemindTime
+	r.run = rn
+	r.schedule()
+
+	go r.wait(rn)
+
+	return rn.remindChan, nil
+}
+
+// Reschedule moves a running reminder to another remind time in format
+// "HH:MM". Rescheduling to the current remind time does nothing, so a remind
+// that is due right now is not moved to the next interval.
+func (r *Reminder) Reschedule(rawRemindTime string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	if r.Started {
-		return r.remindChan, nil
+	if r.run == nil {
+		return ErrNotStarted
 	}
 
-	r.remindChan = make(chan struct{}, 1)
-	r.ticker = time.NewTicker(time.Second)
-	r.Started = true
+	if rawRemindTime == r.rawRemindTime {
+		return nil
+	}
 
-	go func() {
-		for {
-			select {
-			case <-r.ticker.C:
-				r.processTick()
-			case <-r.clearChan:
-				r.processClean()
-			}
-		}
-	}()
+	remindTime, err := r.parseTime(rawRemindTime)
+	if err != nil {
+		return errors.Wrap(err, "no remind time set")
+	}
+
+	r.remindTime = remindTime
+	r.rawRemindTime = rawRemindTime
+	r.schedule()
 
-	return r.remindChan, nil
+	return nil
 }
 
+// Stop stops a running reminder and waits until the last remind is
+// delivered or dropped. Stopping a stopped reminder does nothing.
 func (r *Reminder) Stop() error {
 	_, span := trace.StartSpan(context.Background(), "reminder.Reminder.Stop")
 	defer span.End()
 
-	r.clearChan <- struct{}{}
+	r.mu.Lock()
+	rn := r.run
+	r.mu.Unlock()
+
+	if rn == nil {
+		return nil
+	}
+
+	rn.cancel()
+	<-rn.done
 
 	return nil
 }
 
-func (r *Reminder) SetWeekdaysToSkip(rawWeekdaysToSkip string) error {
-	rawDays := strings.Split(rawWeekdaysToSkip, ",")
+// wait tears the run down once its context is done.
+func (r *Reminder) wait(rn *run) {
+	<-rn.ctx.Done()
 
-	for _, rawDay := range rawDays {
-		weekday, err := strconv.Atoi(strings.TrimSpace(rawDay))
-		if err == nil {
-			r.WeekdaysToSkip[time.Weekday(weekday)] = struct{}{}
-		}
+	r.mu.Lock()
+	if r.run == rn {
+		r.run = nil
+		r.scheduler.Remove(r.id)
 	}
+	rn.stopped = true
+	r.mu.Unlock()
 
-	return nil
+	// No remind is sent after stopped is set, so the channel can be closed
+	// once the ones in flight are done.
+	rn.sending.Wait()
+	close(rn.remindChan)
+	close(rn.done)
 }
 
-func (r *Reminder) processTick() {
-	now := time.Now()
+// fire is the scheduler job of the run, it sends the remind if one is due
+// and schedules the next one.
+func (r *Reminder) fire(rn *run, now time.Time) {
+	r.mu.Lock()
+	if rn.stopped {
+		r.mu.Unlock()
+		return
+	}
+
+	dueAt, due := r.due(now)
+	r.schedule()
+
+	if due {
+		rn.sending.Add(1)
+	}
+	r.mu.Unlock()
 
-	if _, skip := r.WeekdaysToSkip[r.RemindTime.Weekday()]; skip {
-		r.RemindTime = r.RemindTime.Add(r.Interval)
+	if !due {
 		return
 	}
+	defer rn.sending.Done()
 
-	if now.Unix() >= r.RemindTime.Unix() {
-		r.remindChan <- struct{}{}
-		r.RemindTime = r.RemindTime.Add(r.Interval)
+	select {
+	case rn.remindChan <- dueAt:
+	case <-rn.ctx.Done():
 	}
 }
 
-func (r *Reminder) processClean() {
-	if r.Started {
-		defer close(r.remindChan)
-		r.ticker.Stop()
-		r.Started = false
+// schedule sets the scheduler job to the next remind. It must be called with
+// the lock held whenever the reminds may have changed.
+func (r *Reminder) schedule() {
+	if r.run == nil {
+		return
+	}
+
+	times, err := r.upcoming(1)
+	if err != nil {
+		r.scheduler.Remove(r.id)
+		return
 	}
+
+	rn := r.run
+	r.scheduler.Set(r.id, times[0], func(now time.Time) {
+		r.fire(rn, now)
+	})
 }
 
-func (r *Reminder) parseTime(rawRemindTime string) (time.Time, error) {
-	emptyTime := time.Time{}
-	hmArr := strings.Split(rawRemindTime, ":")
+// Snapshot returns a copy of the reminder state.
+func (r *Reminder) Snapshot() Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	if len(hmArr) != 2 {
-		return emptyTime, errors.New("invalid remind time format")
+	snap := Snapshot{
+		WeekdaysToSkip: make(map[time.Weekday]struct{}, len(r.weekdaysToSkip)),
+		Holidays:       make(map[string]struct{}, len(r.holidays)),
+		Interval:       r.interval,
+		Location:       r.location,
+		RemindTime:     r.remindTime,
+		PausedUntil:    r.pausedUntil,
+		SnoozedUntil:   r.snoozedUntil,
+		Started:        r.run != nil,
 	}
 
-	hour, err := strconv.Atoi(hmArr[0])
+	for d := range r.weekdaysToSkip {
+		snap.WeekdaysToSkip[d] = struct{}{}
+	}
+	for date := range r.holidays {
+		snap.Holidays[date] = struct{}{}
+	}
+
+	return snap
+}
+
+// SetLocation moves the reminder to another time zone, keeping the wall
+// clock remind time.
+func (r *Reminder) SetLocation(location *time.Location) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.location.String() == location.String() {
+		return
+	}
+
+	r.location = location
+
+	if r.run != nil {
+		if remindTime, err := r.parseTime(r.rawRemindTime); err == nil {
+			r.remindTime = remindTime
+		}
+	}
+	r.schedule()
+}
+
+// Pause skips the reminds due before until. A zero time resumes the reminder.
+func (r *Reminder) Pause(until time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pausedUntil = until
+	r.schedule()
+}
+
+// Snooze holds the next remind back until the given moment. A zero time
+// cancels the snooze.
+func (r *Reminder) Snooze(until time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.snoozedUntil = until
+	r.schedule()
+}
+
+// SnoozeNext holds the next remind back by d and returns when it fires.
+func (r *Reminder) SnoozeNext(d time.Duration) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	times, err := r.upcoming(1)
 	if err != nil {
-		return emptyTime, errors.Wrap(err, `invalid remind "hour" value`)
+		return time.Time{}, err
 	}
 
-	min, err := strconv.Atoi(hmArr[1])
+	r.snoozedUntil = times[0].Add(d)
+	r.schedule()
+
+	return r.snoozedUntil, nil
+}
+
+// SkipNext skips the next remind and returns when it was due.
+func (r *Reminder) SkipNext() (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.snoozedUntil = time.Time{}
+
+	times, err := r.upcoming(1)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	r.pausedUntil = times[0].Add(time.Second)
+	r.schedule()
+
+	return times[0], nil
+}
+
+// Next returns when the reminder fires next.
+func (r *Reminder) Next() (time.Time, error) {
+	times, err := r.Upcoming(1)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return times[0], nil
+}
+
+// Upcoming returns up to n next moments the reminder fires at, taking
+// weekdays to skip, holidays, the pause and the snooze into account. Fewer
+// are returned if the rest are more than a year ahead.
+func (r *Reminder) Upcoming(n int) ([]time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.upcoming(n)
+}
+
+func (r *Reminder) upcoming(n int) ([]time.Time, error) {
+	if r.run == nil {
+		return nil, ErrNotStarted
+	}
+
+	var times []time.Time
+
+	remindTime := r.remindTime
+	for i := 0; i < maxLookahead && len(times) < n; i++ {
+		t := remindTime
+		remindTime = r.advance(remindTime)
+
+		if r.skipped(t) {
+			continue
+		}
+
+		if len(times) == 0 && t.Before(r.snoozedUntil) {
+			t = r.snoozedUntil
+		} else if len(times) > 0 && !t.After(times[len(times)-1]) {
+			// Passed by the snoozed remind.
+			continue
+		}
+
+		times = append(times, t)
+	}
+
+	if len(times) == 0 {
+		return nil, ErrNoRemind
+	}
+
+	return times, nil
+}
+
+// SetHolidays replaces the dates to skip, given in format
+// "2006-01-02,2006-01-03" in the location of the reminder.
+func (r *Reminder) SetHolidays(rawHolidays string) error {
+	holidays := make(map[string]struct{})
+
+	for _, rawDate := range strings.Split(rawHolidays, ",") {
+		rawDate = strings.TrimSpace(rawDate)
+		if rawDate == "" {
+			continue
+		}
+
+		date, err := time.Parse(dateLayout, rawDate)
+		if err != nil {
+			return errors.Errorf("invalid holiday %q, expected format 2006-01-02", rawDate)
+		}
+
+		holidays[date.Format(dateLayout)] = struct{}{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.holidays = holidays
+	r.schedule()
+
+	return nil
+}
+
+// SetWeekdaysToSkip replaces the weekdays to skip with the ones listed in
+// the format of ParseWeekdays. They are left as is on an error.
+func (r *Reminder) SetWeekdaysToSkip(rawWeekdaysToSkip string) error {
+	days, err := ParseWeekdays(rawWeekdaysToSkip)
 	if err != nil {
-		return emptyTime, errors.Wrap(err, `invalid remind "minute" value`)
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.weekdaysToSkip = days
+	r.schedule()
+
+	return nil
+}
+
+// due advances the remind time past the reminds due by now and returns the
+// one that has to fire, if any. Only the latest fires when several were
+// missed. A snoozed remind keeps the time it was originally due at.
+func (r *Reminder) due(now time.Time) (time.Time, bool) {
+	if now.Before(r.snoozedUntil) {
+		return time.Time{}, false
 	}
+	r.snoozedUntil = time.Time{}
 
-	now := time.Now()
+	var dueAt time.Time
+	fire := false
+	for !r.remindTime.After(now) {
+		if !r.skipped(r.remindTime) {
+			dueAt = r.remindTime
+			fire = true
+		}
+		r.remindTime = r.advance(r.remindTime)
+	}
+
+	return dueAt, fire
+}
+
+// skipped reports whether the remind due at t falls on a weekday to skip or
+// a holiday in the location of the reminder, or within the pause.
+func (r *Reminder) skipped(t time.Time) bool {
+	local := t.In(r.location)
+
+	if _, skip := r.weekdaysToSkip[local.Weekday()]; skip {
+		return true
+	}
+
+	if _, holiday := r.holidays[local.Format(dateLayout)]; holiday {
+		return true
+	}
+
+	return t.Before(r.pausedUntil)
+}
+
+// advance returns the remind following the one due at t. Intervals of whole
+// days keep the wall clock time in the location of the reminder across
+// daylight saving time changes.
+func (r *Reminder) advance(t time.Time) time.Time {
+	if r.interval%(24*time.Hour) != 0 {
+		return t.Add(r.interval)
+	}
+
+	days := int(r.interval / (24 * time.Hour))
+
+	return t.In(r.location).AddDate(0, 0, days).UTC()
+}
+
+func (r *Reminder) parseTime(rawRemindTime string) (time.Time, error) {
+	emptyTime := time.Time{}
+
+	hour, min, err := ParseRemindTime(rawRemindTime)
+	if err != nil {
+		return emptyTime, err
+	}
+
+	now := r.clock.Now()
 	remindTime := time.Date(
 		now.Year(),
 		now.Month(),
@@ -123,12 +462,37 @@ func (r *Reminder) parseTime(rawRemindTime string) (time.Time, error) {
 		min,
 		0,
 		0,
-		r.Location,
+		r.location,
 	).UTC()
 
 	for remindTime.Unix() < now.Unix() {
-		remindTime = remindTime.Add(r.Interval)
+		remindTime = r.advance(remindTime)
 	}
 
 	return remindTime, nil
 }
+
+// ParseRemindTime parses a remind time in format "HH:MM".
+func ParseRemindTime(rawRemindTime string) (int, int, error) {
+	hmArr := strings.Split(rawRemindTime, ":")
+
+	if len(hmArr) != 2 {
+		return 0, 0, errors.New("invalid remind time format")
+	}
+
+	hour, err := strconv.Atoi(hmArr[0])
+	if err != nil {
+		return 0, 0, errors.Wrap(err, `invalid remind "hour" value`)
+	}
+
+	min, err := strconv.Atoi(hmArr[1])
+	if err != nil {
+		return 0, 0, errors.Wrap(err, `invalid remind "minute" value`)
+	}
+
+	if hour < 0 || hour > 23 || min < 0 || min > 59 {
+		return 0, 0, errors.New("remind time out of range")
+	}
+
+	return hour, min, nil
+}
@@ -0,0 +1,295 @@
+package reminder_test
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/scheduler"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// monday is 2 March 2020 09:00 UTC.
+var monday = time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC)
+
+// TestConcurrentAccess validates the state of a running reminder can be
+// read and changed from other goroutines while its reminds fire. Run it with
+// -race.
+func TestConcurrentAccess(t *testing.T) {
+	clk := clock.NewFake(monday)
+	sched := scheduler.New(clk)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go sched.Run(ctx)
+
+	r := reminder.New(clk, sched, reminder.DefaultID, 24*time.Hour, time.UTC)
+
+	remindChan, err := r.Start(ctx, "10:00")
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to start the reminder : %s.", tests.Failed, err)
+	}
+
+	var mu sync.Mutex
+	var reminds []time.Time
+	drained := make(chan struct{})
+	go func() {
+		for dueAt := range remindChan {
+			mu.Lock()
+			reminds = append(reminds, dueAt)
+			mu.Unlock()
+		}
+		close(drained)
+	}()
+
+	t.Log("Given a running reminder changed and read while its reminds fire.")
+	{
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+
+					snap := r.Snapshot()
+					if !snap.Started {
+						t.Errorf("\t%s\tShould see the reminder started.", tests.Failed)
+						return
+					}
+					if _, err := r.Upcoming(3); err != nil {
+						t.Errorf("\t%s\tShould be able to get the upcoming reminds : %s.", tests.Failed, err)
+						return
+					}
+				}
+			}()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			skips := []string{"0,6", "", "3"}
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				if err := r.SetWeekdaysToSkip(skips[i%len(skips)]); err != nil {
+					t.Errorf("\t%s\tShould be able to set weekdays to skip : %s.", tests.Failed, err)
+					return
+				}
+				if err := r.SetHolidays("2020-03-10"); err != nil {
+					t.Errorf("\t%s\tShould be able to set holidays : %s.", tests.Failed, err)
+					return
+				}
+				r.SetLocation(time.UTC)
+
+				// Every change wakes the scheduler up, give it a moment.
+				time.Sleep(100 * time.Microsecond)
+			}
+		}()
+
+		// A week of reminds.
+		tests.Advance(clk, 7*24*time.Hour, 3*time.Hour)
+
+		close(stop)
+		wg.Wait()
+
+		// The skipped days changed all along, so only leave the weekends out
+		// for the last day.
+		if err := r.SetWeekdaysToSkip("0,6"); err != nil {
+			t.Fatalf("\t%s\tShould be able to set weekdays to skip : %s.", tests.Failed, err)
+		}
+		upcoming, err := r.Upcoming(1)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to get the upcoming reminds : %s.", tests.Failed, err)
+		}
+		if want := time.Date(2020, 3, 9, 10, 0, 0, 0, time.UTC); !upcoming[0].Equal(want) {
+			t.Fatalf("\t%s\tShould apply the last weekdays to skip : got %s, want %s.", tests.Failed, upcoming[0], want)
+		}
+		t.Logf("\t%s\tShould apply the last weekdays to skip.", tests.Success)
+
+		if err := r.Stop(); err != nil {
+			t.Fatalf("\t%s\tShould be able to stop the reminder : %s.", tests.Failed, err)
+		}
+		<-drained
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if len(reminds) == 0 || len(reminds) > 7 {
+			t.Fatalf("\t%s\tShould fire at most once a day : got %d reminds.", tests.Failed, len(reminds))
+		}
+		for i, dueAt := range reminds {
+			if dueAt.Hour() != 10 || dueAt.Minute() != 0 {
+				t.Fatalf("\t%s\tShould fire at the remind time : got %s.", tests.Failed, dueAt)
+			}
+			if i > 0 && !dueAt.After(reminds[i-1]) {
+				t.Fatalf("\t%s\tShould fire every remind once : got %s after %s.", tests.Failed, dueAt, reminds[i-1])
+			}
+		}
+		t.Logf("\t%s\tShould fire every remind once at the remind time.", tests.Success)
+
+		if snap := r.Snapshot(); snap.Started {
+			t.Fatalf("\t%s\tShould be stopped.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould be stopped.", tests.Success)
+	}
+}
+
+// TestStartStopLeaks validates starting and stopping a reminder over and
+// over, or cancelling the context it runs with, leaves no goroutine behind.
+func TestStartStopLeaks(t *testing.T) {
+	clk := clock.NewFake(monday)
+	sched := scheduler.New(clk)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go sched.Run(ctx)
+
+	r := reminder.New(clk, sched, reminder.DefaultID, 24*time.Hour, time.UTC)
+
+	// Let the scheduler settle before counting.
+	time.Sleep(10 * time.Millisecond)
+	base := runtime.NumGoroutine()
+
+	settled := func() bool {
+		return runtime.NumGoroutine() <= base
+	}
+
+	t.Log("Given a reminder started and stopped over and over.")
+	{
+		for i := 0; i < 100; i++ {
+			remindChan, err := r.Start(ctx, "10:00")
+			if err != nil {
+				t.Fatalf("\t%s\tShould be able to start the reminder : %s.", tests.Failed, err)
+			}
+			if _, err := r.Start(ctx, "10:00"); err != reminder.ErrStarted {
+				t.Fatalf("\t%s\tShould not start a running reminder twice : got %v.", tests.Failed, err)
+			}
+			if err := r.Stop(); err != nil {
+				t.Fatalf("\t%s\tShould be able to stop the reminder : %s.", tests.Failed, err)
+			}
+			if _, ok := <-remindChan; ok {
+				t.Fatalf("\t%s\tShould close the remind channel once stopped.", tests.Failed)
+			}
+		}
+
+		if !tests.Wait(time.Second, settled) {
+			t.Fatalf("\t%s\tShould not leak goroutines : got %d, want %d.", tests.Failed, runtime.NumGoroutine(), base)
+		}
+		t.Logf("\t%s\tShould not leak goroutines.", tests.Success)
+	}
+
+	t.Log("Given a reminder whose context is cancelled over and over.")
+	{
+		for i := 0; i < 100; i++ {
+			runCtx, runCancel := context.WithCancel(ctx)
+			remindChan, err := r.Start(runCtx, "10:00")
+			if err != nil {
+				runCancel()
+				t.Fatalf("\t%s\tShould be able to start the reminder : %s.", tests.Failed, err)
+			}
+			runCancel()
+			if _, ok := <-remindChan; ok {
+				t.Fatalf("\t%s\tShould close the remind channel once the context is done.", tests.Failed)
+			}
+		}
+
+		if !tests.Wait(time.Second, settled) {
+			t.Fatalf("\t%s\tShould not leak goroutines : got %d, want %d.", tests.Failed, runtime.NumGoroutine(), base)
+		}
+		t.Logf("\t%s\tShould not leak goroutines.", tests.Success)
+
+		if snap := r.Snapshot(); snap.Started {
+			t.Fatalf("\t%s\tShould be stopped.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould be stopped.", tests.Success)
+	}
+}
+
+// TestUpcoming validates the upcoming reminds leave the weekdays to skip and
+// the holidays out, and keep the remind time across daylight saving time
+// changes.
+func TestUpcoming(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("loading location: %s", err)
+	}
+
+	// Wednesday 25 March 2020 12:00 in Berlin, the clocks go forward on
+	// Sunday 29 March.
+	clk := clock.NewFake(time.Date(2020, 3, 25, 12, 0, 0, 0, berlin))
+	sched := scheduler.New(clk)
+
+	r := reminder.New(clk, sched, reminder.DefaultID, 24*time.Hour, berlin)
+
+	t.Log("Given a reminder that is not started.")
+	{
+		if _, err := r.Upcoming(5); err != reminder.ErrNotStarted {
+			t.Fatalf("\t%s\tShould have no upcoming reminds : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould have no upcoming reminds.", tests.Success)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := r.Start(ctx, "10:00"); err != nil {
+		t.Fatalf("\t%s\tShould be able to start the reminder : %s.", tests.Failed, err)
+	}
+	defer r.Stop()
+
+	t.Log("Given a reminder skipping weekends and a holiday across the change to summer time.")
+	{
+		if err := r.SetWeekdaysToSkip("sat,sun"); err != nil {
+			t.Fatalf("\t%s\tShould be able to set weekdays to skip : %s.", tests.Failed, err)
+		}
+		if err := r.SetHolidays("2020-03-31"); err != nil {
+			t.Fatalf("\t%s\tShould be able to set holidays : %s.", tests.Failed, err)
+		}
+
+		upcoming, err := r.Upcoming(4)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to get the upcoming reminds : %s.", tests.Failed, err)
+		}
+
+		want := []time.Time{
+			time.Date(2020, 3, 26, 9, 0, 0, 0, time.UTC),
+			time.Date(2020, 3, 27, 9, 0, 0, 0, time.UTC),
+			time.Date(2020, 3, 30, 8, 0, 0, 0, time.UTC),
+			time.Date(2020, 4, 1, 8, 0, 0, 0, time.UTC),
+		}
+		if len(upcoming) != len(want) {
+			t.Fatalf("\t%s\tShould return %d reminds : got %s.", tests.Failed, len(want), upcoming)
+		}
+		for i := range want {
+			if !upcoming[i].Equal(want[i]) {
+				t.Fatalf("\t%s\tShould remind at 10:00 in Berlin on workdays : got %s, want %s.", tests.Failed, upcoming[i], want[i])
+			}
+		}
+		t.Logf("\t%s\tShould remind at 10:00 in Berlin on workdays but the holiday.", tests.Success)
+
+		if err := r.SetWeekdaysToSkip("0-6"); err != nil {
+			t.Fatalf("\t%s\tShould be able to set weekdays to skip : %s.", tests.Failed, err)
+		}
+		if _, err := r.Upcoming(1); err != reminder.ErrNoRemind {
+			t.Fatalf("\t%s\tShould never fire with every weekday skipped : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould never fire with every weekday skipped.", tests.Success)
+	}
+}
@@ -0,0 +1,136 @@
+package reminder
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// DefaultID identifies the team reminder managed by the chat commands.
+const DefaultID = "00000000-0000-0000-0000-000000000001"
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when a specific Schedule is requested but does not exist.
+	ErrNotFound = errors.New("Reminder not found")
+)
+
+// Store is the repository of reminder schedules.
+type Store interface {
+	List(ctx context.Context) ([]Schedule, error)
+	Get(ctx context.Context, id string) (*Schedule, error)
+	Save(ctx context.Context, s Schedule, now time.Time) error
+	Delete(ctx context.Context, id string) error
+}
+
+// dbStore keeps schedules in the reminders table of a Postgres or SQLite
+// database.
+type dbStore struct {
+	db sqlx.ExtContext
+}
+
+// NewDBStore returns a Store backed by the reminders table of db.
+func NewDBStore(db sqlx.ExtContext) Store {
+	return &dbStore{db: db}
+}
+
+func (s *dbStore) List(ctx context.Context) ([]Schedule, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.reminder.List")
+	defer span.End()
+
+	var schedules []Schedule
+	const q = `select * from reminders
+		order by created_at`
+
+	if err := sqlx.SelectContext(ctx, s.db, &schedules, q); err != nil {
+		return nil, errors.Wrap(err, "selecting reminders")
+	}
+
+	return schedules, nil
+}
+
+func (s *dbStore) Get(ctx context.Context, id string) (*Schedule, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.reminder.Get")
+	defer span.End()
+
+	var sch Schedule
+	const q = `select * from reminders
+		where reminder_id = $1`
+
+	if err := sqlx.GetContext(ctx, s.db, &sch, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrapf(err, "selecting reminder %s", id)
+	}
+
+	return &sch, nil
+}
+
+// Save updates the schedule with the same ID or inserts it when there is
+// none yet.
+func (s *dbStore) Save(ctx context.Context, sch Schedule, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.reminder.Save")
+	defer span.End()
+
+	const updateQ = `update reminders
+		set remind_time = $1, weekdays_to_skip = $2, message = $3, group_names = $4, escalation = $5, paused_until = $6, snoozed_until = $7, updated_at = $8
+		where reminder_id = $9`
+	const insertQ = `insert into reminders
+		(reminder_id, remind_time, weekdays_to_skip, message, group_names, escalation, paused_until, snoozed_until, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	res, err := s.db.ExecContext(ctx, updateQ,
+		sch.RemindTime, sch.WeekdaysToSkip, sch.Message, sch.Groups, sch.Escalation, utc(sch.PausedUntil), utc(sch.SnoozedUntil), now.UTC(), sch.ID,
+	)
+	if err != nil {
+		return errors.Wrap(err, "updating reminder")
+	}
+
+	upd, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "updating reminder")
+	}
+	if upd > 0 {
+		return nil
+	}
+
+	_, err = s.db.ExecContext(ctx, insertQ,
+		sch.ID, sch.RemindTime, sch.WeekdaysToSkip, sch.Message, sch.Groups, sch.Escalation, utc(sch.PausedUntil), utc(sch.SnoozedUntil), now.UTC(), now.UTC(),
+	)
+	if err != nil {
+		return errors.Wrap(err, "inserting reminder")
+	}
+
+	return nil
+}
+
+func (s *dbStore) Delete(ctx context.Context, id string) error {
+	ctx, span := trace.StartSpan(ctx, "internal.reminder.Delete")
+	defer span.End()
+
+	const q = `delete from reminders
+		where reminder_id = $1`
+
+	if _, err := s.db.ExecContext(ctx, q, id); err != nil {
+		return errors.Wrapf(err, "deleting reminder %s", id)
+	}
+
+	return nil
+}
+
+// utc converts an optional moment to UTC, the database keeps timestamps
+// without a time zone.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	u := t.UTC()
+	return &u
+}
@@ -0,0 +1,129 @@
+package reminder
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// weekdayNames maps the accepted names of weekdays, full and abbreviated.
+var weekdayNames = map[string]time.Weekday{}
+
+func init() {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		weekdayNames[name] = d
+		weekdayNames[name[:3]] = d
+	}
+}
+
+// ParseWeekdays reads a comma separated list of weekdays. A weekday is given
+// by its number (0 - is Sunday) or its English name, full or abbreviated,
+// and a range of them by two weekdays joined with a dash. Ranges may wrap
+// around the week, so "sat-sun" is Saturday and Sunday. An empty list is
+// an empty set.
+func ParseWeekdays(raw string) (map[time.Weekday]struct{}, error) {
+	days := make(map[time.Weekday]struct{})
+
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		bounds := strings.Split(item, "-")
+		if len(bounds) > 2 {
+			return nil, errors.Errorf("invalid weekday range %q", item)
+		}
+
+		from, err := parseWeekday(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+
+		to := from
+		if len(bounds) == 2 {
+			if to, err = parseWeekday(bounds[1]); err != nil {
+				return nil, err
+			}
+		}
+
+		for d := from; ; d = (d + 1) % 7 {
+			days[d] = struct{}{}
+			if d == to {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+func parseWeekday(raw string) (time.Weekday, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+
+	if d, ok := weekdayNames[raw]; ok {
+		return d, nil
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 || n > 6 {
+		return 0, errors.Errorf("invalid weekday %q, use 0-6 (0 - is Sunday) or names like mon", raw)
+	}
+
+	return time.Weekday(n), nil
+}
+
+// FormatWeekdays writes weekdays in format "0,1,2", the one schedules are
+// stored in.
+func FormatWeekdays(days map[time.Weekday]struct{}) string {
+	list := sortWeekdays(days)
+
+	s := make([]string, len(list))
+	for i, d := range list {
+		s[i] = strconv.Itoa(int(d))
+	}
+
+	return strings.Join(s, ",")
+}
+
+// WeekdayNames lists the names of weekdays starting from Monday.
+func WeekdayNames(days map[time.Weekday]struct{}) []string {
+	list := sortWeekdays(days)
+
+	names := make([]string, len(list))
+	for i, d := range list {
+		names[i] = d.String()
+	}
+
+	return names
+}
+
+// InvertWeekdays returns the weekdays missing from days.
+func InvertWeekdays(days map[time.Weekday]struct{}) map[time.Weekday]struct{} {
+	inverse := make(map[time.Weekday]struct{})
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if _, ok := days[d]; !ok {
+			inverse[d] = struct{}{}
+		}
+	}
+
+	return inverse
+}
+
+// sortWeekdays orders weekdays the way a working week goes, Sunday last.
+func sortWeekdays(days map[time.Weekday]struct{}) []time.Weekday {
+	list := make([]time.Weekday, 0, len(days))
+	for d := range days {
+		list = append(list, d)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return (list[i]+6)%7 < (list[j]+6)%7
+	})
+
+	return list
+}
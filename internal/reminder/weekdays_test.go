@@ -0,0 +1,61 @@
+package reminder_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+// TestParseWeekdays validates weekdays are read by name, number and range,
+// written in the stored format and inverted, and invalid ones are refused.
+func TestParseWeekdays(t *testing.T) {
+	tt := []struct {
+		raw     string
+		want    string // Stored format.
+		inverse string // Stored format of the missing weekdays.
+		err     string
+	}{
+		{raw: "", want: "", inverse: "1,2,3,4,5,6,0"},
+		{raw: "sat,sun", want: "6,0", inverse: "1,2,3,4,5"},
+		{raw: "Saturday, SUNDAY", want: "6,0", inverse: "1,2,3,4,5"},
+		{raw: "mon-fri", want: "1,2,3,4,5", inverse: "6,0"},
+		{raw: "fri-mon", want: "1,5,6,0", inverse: "2,3,4"},
+		{raw: "wed-wed", want: "3", inverse: "1,2,4,5,6,0"},
+		{raw: "0,6", want: "6,0", inverse: "1,2,3,4,5"},
+		{raw: "1-5", want: "1,2,3,4,5", inverse: "6,0"},
+		{raw: "mon, 1, monday, mon-tue", want: "1,2", inverse: "3,4,5,6,0"},
+		{raw: "7", err: `invalid weekday "7"`},
+		{raw: "-1", err: `invalid weekday ""`},
+		{raw: "mon-", err: `invalid weekday ""`},
+		{raw: "funday", err: `invalid weekday "funday"`},
+		{raw: "mon-wed-fri", err: `invalid weekday range "mon-wed-fri"`},
+	}
+
+	t.Log("Given the weekdays of /setweekdaystoskip.")
+	{
+		for _, tc := range tt {
+			days, err := reminder.ParseWeekdays(tc.raw)
+
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("\t%s\tShould refuse %q : got %v, want %q.", tests.Failed, tc.raw, err, tc.err)
+				}
+				t.Logf("\t%s\tShould refuse %q.", tests.Success, tc.raw)
+				continue
+			}
+
+			if err != nil {
+				t.Fatalf("\t%s\tShould be able to parse %q : %s.", tests.Failed, tc.raw, err)
+			}
+			if got := reminder.FormatWeekdays(days); got != tc.want {
+				t.Fatalf("\t%s\tShould read %q : got %q, want %q.", tests.Failed, tc.raw, got, tc.want)
+			}
+			if got := reminder.FormatWeekdays(reminder.InvertWeekdays(days)); got != tc.inverse {
+				t.Fatalf("\t%s\tShould invert %q : got %q, want %q.", tests.Failed, tc.raw, got, tc.inverse)
+			}
+			t.Logf("\t%s\tShould read %q.", tests.Success, tc.raw)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package scheduler runs jobs at given moments. It sleeps until the earliest
+// of them on a single timer instead of polling, and is woken whenever the
+// jobs change.
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+)
+
+// Func is a job. It is called with the time it was run at.
+type Func func(now time.Time)
+
+// Scheduler runs every job once at its moment. A recurring job sets itself
+// again for its next moment when it runs.
+type Scheduler struct {
+	clock clock.Clock
+
+	mu   sync.Mutex
+	jobs jobHeap
+	byID map[string]*job
+	wake chan struct{}
+}
+
+type job struct {
+	id    string
+	at    time.Time
+	fn    Func
+	index int
+}
+
+// New returns a Scheduler with no jobs. Jobs are not run until Run is
+// called.
+func New(clk clock.Clock) *Scheduler {
+	return &Scheduler{
+		clock: clk,
+		byID:  make(map[string]*job),
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// Set schedules fn to run at the given moment under id, replacing the job
+// already scheduled under it. A moment in the past runs the job right away.
+func (s *Scheduler) Set(id string, at time.Time, fn Func) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if j, ok := s.byID[id]; ok {
+		j.at, j.fn = at, fn
+		heap.Fix(&s.jobs, j.index)
+	} else {
+		j := &job{id: id, at: at, fn: fn}
+		heap.Push(&s.jobs, j)
+		s.byID[id] = j
+	}
+
+	s.notify()
+}
+
+// Remove unschedules the job set under id, if any.
+func (s *Scheduler) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.byID[id]
+	if !ok {
+		return
+	}
+
+	heap.Remove(&s.jobs, j.index)
+	delete(s.byID, id)
+
+	s.notify()
+}
+
+// When returns the moment the job set under id runs at.
+func (s *Scheduler) When(id string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.byID[id]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return j.at, true
+}
+
+// Len returns the number of scheduled jobs.
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.jobs)
+}
+
+// Run runs the jobs as they become due until ctx is done. Each job runs in
+// its own goroutine so a slow one doesn't hold back the others.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		var timer clock.Timer
+		var fire <-chan time.Time
+
+		s.mu.Lock()
+		if len(s.jobs) > 0 {
+			timer = s.clock.NewTimer(s.jobs[0].at.Sub(s.clock.Now()))
+			fire = timer.C()
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-s.wake:
+		case <-fire:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		s.runDue()
+	}
+}
+
+// runDue starts the jobs due by now and drops them from the schedule.
+func (s *Scheduler) runDue() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+
+	for len(s.jobs) > 0 && !s.jobs[0].at.After(now) {
+		j := heap.Pop(&s.jobs).(*job)
+		delete(s.byID, j.id)
+
+		go j.fn(now)
+	}
+}
+
+// notify wakes Run to pick up a changed earliest job.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// jobHeap orders jobs by their moment, the earliest first.
+type jobHeap []*job
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *jobHeap) Push(x interface{}) {
+	j := x.(*job)
+	j.index = len(*h)
+	*h = append(*h, j)
+}
+
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	j := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return j
+}
@@ -3,12 +3,29 @@ package schema
 import (
 	"github.com/dimiro1/darwin"
 	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/database"
 )
 
 // Migrate attempts to bring the schema for db up to date with the migrations
 // defined in this package.
+//
+// Migrations are written in SQL understood by both Postgres and SQLite.
+// SQLite accepts Postgres type names such as uuid or jsonb and applies its
+// own type affinity to them.
 func Migrate(db *sqlx.DB) error {
-	driver := darwin.NewGenericDriver(db.DB, darwin.PostgresDialect{})
+	var dialect darwin.Dialect
+	switch db.DriverName() {
+	case database.Postgres:
+		dialect = darwin.PostgresDialect{}
+	case database.SQLite:
+		dialect = darwin.SqliteDialect{}
+	default:
+		return errors.Errorf("unsupported database driver: %s", db.DriverName())
+	}
+
+	driver := darwin.NewGenericDriver(db.DB, dialect)
 
 	d := darwin.New(driver, migrations, nil)
 
@@ -48,6 +65,194 @@ create table participants (
 	added_at 		timestamp,
 	updated_at 		timestamp,
 	primary key 	(participant_id)
+);`,
+	},
+	{
+		Version:     3,
+		Description: "Create outbox table",
+		Script: `
+create table outbox (
+	message_id 		uuid,
+	chat_id 		text,
+	text 			text,
+	reply_to 		integer default 0,
+	status 			text,
+	attempts 		integer,
+	next_attempt_at timestamp,
+	last_error 		text,
+	created_at 		timestamp,
+	updated_at 		timestamp,
+	primary key 	(message_id)
+);
+
+create index outbox_pending on outbox (status, next_attempt_at);
+create index outbox_chat on outbox (chat_id, status);`,
+	},
+	{
+		Version:     4,
+		Description: "Move reminder settings from config to reminders table",
+		Script: `
+create table reminders (
+	reminder_id 		uuid,
+	remind_time 		text,
+	weekdays_to_skip 	text,
+	message 			text,
+	created_at 			timestamp,
+	updated_at 			timestamp,
+	primary key 		(reminder_id)
+);
+
+insert into reminders
+	(reminder_id, remind_time, weekdays_to_skip, message, created_at, updated_at)
+select
+	'00000000-0000-0000-0000-000000000001',
+	coalesce((select trim(cast(value as text), '"') from config where name = 'RemindTime'), ''),
+	coalesce((select trim(cast(value as text), '"') from config where name = 'WeekdaysToSkip'), ''),
+	coalesce((select trim(cast(value as text), '"') from config where name = 'RemindMessage'), ''),
+	current_timestamp,
+	current_timestamp
+where exists (select 1 from config where name in ('RemindTime', 'WeekdaysToSkip', 'RemindMessage'));
+
+delete from config where name in ('RemindTime', 'WeekdaysToSkip', 'RemindMessage');`,
+	},
+	{
+		Version:     5,
+		Description: "Create setup sessions table",
+		Script: `
+create table setup_sessions (
+	user_id 			bigint,
+	chat_id 			text,
+	step 				text,
+	remind_time 		text,
+	weekdays_to_skip 	text,
+	message 			text,
+	participants 		text,
+	message_id 			integer default 0,
+	updated_at 			timestamp,
+	primary key 		(user_id)
+);`,
+	},
+	{
+		Version:     6,
+		Description: "Add pause and snooze to reminders",
+		Script: `
+alter table reminders add column paused_until timestamp;
+alter table reminders add column snoozed_until timestamp;`,
+	},
+	{
+		Version:     7,
+		Description: "Create leases table",
+		Script: `
+create table leases (
+	name 		text,
+	holder 		text,
+	expire_at 	timestamp,
+	updated_at 	timestamp,
+	primary key (name)
+);`,
+	},
+	{
+		Version:     8,
+		Description: "Create occurrences table",
+		Script: `
+create table occurrences (
+	reminder_id uuid,
+	due_at 		timestamp,
+	status 		text,
+	attempts 	integer,
+	last_error 	text,
+	created_at 	timestamp,
+	updated_at 	timestamp,
+	primary key (reminder_id, due_at)
+);`,
+	},
+	{
+		Version:     9,
+		Description: "Add participant groups",
+		Script: `
+alter table participants add column group_names text default '';
+alter table reminders add column group_names text default '';`,
+	},
+	{
+		Version:     10,
+		Description: "Add participant absences",
+		Script: `
+alter table participants add column away_from timestamp;
+alter table participants add column away_until timestamp;`,
+	},
+	{
+		Version:     11,
+		Description: "Add participant user and time zone",
+		Script: `
+alter table participants add column user_id bigint default 0;
+alter table participants add column timezone text default '';`,
+	},
+	{
+		Version:     12,
+		Description: "Create acks table and add escalation",
+		Script: `
+create table acks (
+	reminder_id 	uuid,
+	due_at 			timestamp,
+	participant_id 	uuid,
+	acked_at 		timestamp,
+	escalated 		integer,
+	created_at 		timestamp,
+	updated_at 		timestamp,
+	primary key 	(reminder_id, due_at, participant_id)
+);
+
+alter table reminders add column escalation text default '';
+alter table participants add column lead_id text default '';`,
+	},
+	{
+		Version:     13,
+		Description: "Create webhook tables",
+		Script: `
+create table webhook_deliveries (
+	delivery_id 	uuid,
+	url 			text,
+	event 			text,
+	payload 		text,
+	status 			text,
+	attempts 		integer,
+	next_attempt_at timestamp,
+	last_error 		text,
+	created_at 		timestamp,
+	updated_at 		timestamp,
+	primary key 	(delivery_id)
+);
+
+create index webhook_deliveries_pending on webhook_deliveries (status, next_attempt_at);
+
+create table webhook_attempts (
+	delivery_id 	uuid,
+	attempt 		integer,
+	url 			text,
+	event 			text,
+	status_code 	integer,
+	error 			text,
+	attempted_at 	timestamp,
+	primary key 	(delivery_id, attempt)
+);`,
+	},
+	{
+		Version:     14,
+		Description: "Create personal_reminders",
+		Script: `
+create table personal_reminders (
+	personal_reminder_id 	uuid,
+	chat_id 				text,
+	user_id 				bigint,
+	message_id 				integer,
+	text 					text,
+	due_at 					timestamp,
+	status 					text check (status in ('pending', 'sent', 'cancelled', 'failed')),
+	attempts 				integer,
+	last_error 				text,
+	created_at 				timestamp,
+	updated_at 				timestamp,
+	primary key 			(personal_reminder_id)
 );`,
 	},
 }
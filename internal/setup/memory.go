@@ -0,0 +1,50 @@
+package setup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryStore keeps sessions in process memory. It is meant for tests and
+// short-lived deployments, nothing survives a restart.
+type memoryStore struct {
+	mu       sync.RWMutex
+	sessions map[int]Session
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() Store {
+	return &memoryStore{sessions: make(map[int]Session)}
+}
+
+func (s *memoryStore) Get(ctx context.Context, userID int) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ses, ok := s.sessions[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &ses, nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, ses Session, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ses.UpdatedAt = now.UTC()
+	s.sessions[ses.UserID] = ses
+
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, userID)
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package setup
+
+import "time"
+
+// Step of the setup wizard.
+type Step string
+
+const (
+	StepTime         Step = "time"
+	StepDays         Step = "days"
+	StepMessage      Step = "message"
+	StepParticipants Step = "participants"
+	StepConfirm      Step = "confirm"
+)
+
+// Session is the progress of a user through the setup wizard. The draft
+// fields are applied to the team reminder only when the user confirms.
+type Session struct {
+	UserID         int       `db:"user_id" json:"user_id"`                   // Telegram user going through the wizard.
+	ChatID         string    `db:"chat_id" json:"chat_id"`                   // Chat the wizard was started in.
+	Step           Step      `db:"step" json:"step"`                         // Current step.
+	RemindTime     string    `db:"remind_time" json:"remind_time"`           // Draft remind time in format "HH:MM".
+	WeekdaysToSkip string    `db:"weekdays_to_skip" json:"weekdays_to_skip"` // Draft weekdays to skip in format "0,1,2".
+	Message        string    `db:"message" json:"message"`                   // Draft remind message.
+	Participants   string    `db:"participants" json:"participants"`         // Draft comma separated participant names.
+	MessageID      int       `db:"message_id" json:"message_id"`             // Message showing the current step, typed answers reply to it.
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`             // When the session was last modified.
+}
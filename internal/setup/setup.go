@@ -0,0 +1,104 @@
+package setup
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when the user has no setup session in progress.
+	ErrNotFound = errors.New("Setup session not found")
+)
+
+// Store is the repository of setup wizard sessions.
+type Store interface {
+	Get(ctx context.Context, userID int) (*Session, error)
+	Save(ctx context.Context, s Session, now time.Time) error
+	Delete(ctx context.Context, userID int) error
+}
+
+// dbStore keeps sessions in the setup_sessions table of a Postgres or SQLite
+// database.
+type dbStore struct {
+	db sqlx.ExtContext
+}
+
+// NewDBStore returns a Store backed by the setup_sessions table of db.
+func NewDBStore(db sqlx.ExtContext) Store {
+	return &dbStore{db: db}
+}
+
+func (s *dbStore) Get(ctx context.Context, userID int) (*Session, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.setup.Get")
+	defer span.End()
+
+	var ses Session
+	const q = `select * from setup_sessions
+		where user_id = $1`
+
+	if err := sqlx.GetContext(ctx, s.db, &ses, q, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrapf(err, "selecting setup session of user %d", userID)
+	}
+
+	return &ses, nil
+}
+
+func (s *dbStore) Save(ctx context.Context, ses Session, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.setup.Save")
+	defer span.End()
+
+	const updateQ = `update setup_sessions
+		set chat_id = $1, step = $2, remind_time = $3, weekdays_to_skip = $4, message = $5, participants = $6, message_id = $7, updated_at = $8
+		where user_id = $9`
+	const insertQ = `insert into setup_sessions
+		(user_id, chat_id, step, remind_time, weekdays_to_skip, message, participants, message_id, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	res, err := s.db.ExecContext(ctx, updateQ,
+		ses.ChatID, ses.Step, ses.RemindTime, ses.WeekdaysToSkip, ses.Message, ses.Participants, ses.MessageID, now.UTC(), ses.UserID,
+	)
+	if err != nil {
+		return errors.Wrap(err, "updating setup session")
+	}
+
+	upd, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "updating setup session")
+	}
+	if upd > 0 {
+		return nil
+	}
+
+	_, err = s.db.ExecContext(ctx, insertQ,
+		ses.UserID, ses.ChatID, ses.Step, ses.RemindTime, ses.WeekdaysToSkip, ses.Message, ses.Participants, ses.MessageID, now.UTC(),
+	)
+	if err != nil {
+		return errors.Wrap(err, "inserting setup session")
+	}
+
+	return nil
+}
+
+func (s *dbStore) Delete(ctx context.Context, userID int) error {
+	ctx, span := trace.StartSpan(ctx, "internal.setup.Delete")
+	defer span.End()
+
+	const q = `delete from setup_sessions
+		where user_id = $1`
+
+	if _, err := s.db.ExecContext(ctx, q, userID); err != nil {
+		return errors.Wrapf(err, "deleting setup session of user %d", userID)
+	}
+
+	return nil
+}
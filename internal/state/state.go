@@ -0,0 +1,278 @@
+// Package state exports and imports everything the bot keeps in storage so
+// it can be moved between environments.
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/storage"
+)
+
+// Version of the documents written by Encode. Bump it together with an
+// upgrade step in Decode whenever the layout of State changes, so documents
+// exported before a schema migration can still be imported after it.
+const Version = 1
+
+// State is a full snapshot of the bot configuration. Undelivered outbox
+// messages are not part of it.
+type State struct {
+	Version      int                         `json:"version"`
+	Config       map[config.Name]interface{} `json:"config"`
+	Participants []participant.Participant   `json:"participants"`
+	Reminders    []reminder.Schedule         `json:"reminders"`
+}
+
+// Encode serializes s as a JSON document of the current Version.
+func Encode(s *State) ([]byte, error) {
+	s.Version = Version
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding state")
+	}
+
+	return data, nil
+}
+
+// Decode parses a JSON document written by Encode of this or any earlier
+// version. Documents without a version or without any content are rejected,
+// as importing them would wipe the storage out.
+func Decode(data []byte) (*State, error) {
+	var head struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, errors.Wrap(err, "decoding state version")
+	}
+	if head.Version < 1 {
+		return nil, errors.New("not a state document, the version is missing")
+	}
+	if head.Version > Version {
+		return nil, errors.Errorf("state version %d is newer than the supported version %d", head.Version, Version)
+	}
+
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, errors.Wrap(err, "decoding state")
+	}
+	if len(s.Config) == 0 && len(s.Participants) == 0 && len(s.Reminders) == 0 {
+		return nil, errors.New("empty state document")
+	}
+
+	s.Version = Version
+
+	return &s, nil
+}
+
+// Validate checks s before it is imported.
+func Validate(s *State) error {
+	for name, val := range s.Config {
+		if err := config.Validate(name, val); err != nil {
+			return errors.Wrap(err, "validating config")
+		}
+	}
+
+	ids := make(map[string]bool, len(s.Participants))
+	for _, p := range s.Participants {
+		ids[p.ID] = true
+	}
+
+	for _, p := range s.Participants {
+		if p.Name == "" {
+			return errors.New("participant without a name")
+		}
+		if p.LeadID != "" && (!ids[p.LeadID] || p.LeadID == p.ID) {
+			return errors.Errorf("participant %s has an unknown lead", p.Name)
+		}
+		if _, err := participant.ParseGroups(p.Groups); err != nil {
+			return errors.Wrapf(err, "participant %s", p.Name)
+		}
+		if (p.AwayFrom == nil) != (p.AwayUntil == nil) || p.AwayFrom != nil && !p.AwayUntil.After(*p.AwayFrom) {
+			return errors.Errorf("participant %s has an invalid absence", p.Name)
+		}
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return errors.Wrapf(err, "participant %s", p.Name)
+		}
+	}
+
+	for _, sch := range s.Reminders {
+		if sch.ID == "" {
+			return errors.New("reminder without an id")
+		}
+		// The team reminder has no remind time until one is set.
+		if sch.RemindTime != "" || sch.ID != reminder.DefaultID {
+			if _, _, err := reminder.ParseRemindTime(sch.RemindTime); err != nil {
+				return errors.Wrapf(err, "reminder %s", sch.ID)
+			}
+		}
+		if _, err := reminder.ParseWeekdays(sch.WeekdaysToSkip); err != nil {
+			return errors.Wrapf(err, "reminder %s", sch.ID)
+		}
+		if _, err := participant.ParseGroups(sch.Groups); err != nil {
+			return errors.Wrapf(err, "reminder %s", sch.ID)
+		}
+		if _, err := reminder.ParseEscalation(sch.Escalation); err != nil {
+			return errors.Wrapf(err, "reminder %s", sch.ID)
+		}
+	}
+
+	return nil
+}
+
+func Export(ctx context.Context, st *storage.Storage) (*State, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.state.Export")
+	defer span.End()
+
+	s := State{
+		Config: make(map[config.Name]interface{}),
+	}
+
+	for _, name := range config.Names {
+		val, err := st.Config.GetByName(ctx, name)
+		if err == config.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "exporting config %s", name)
+		}
+		s.Config[name] = val
+	}
+
+	participants, err := st.Participant.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "exporting participants")
+	}
+	s.Participants = participants
+
+	reminders, err := st.Reminder.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "exporting reminders")
+	}
+	s.Reminders = reminders
+
+	return &s, nil
+}
+
+// Import replaces the stored configuration, participants and reminders with
+// the ones of s within a single transaction. Configs missing from s are
+// removed, so their defaults apply.
+func Import(ctx context.Context, st *storage.Storage, s State, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.state.Import")
+	defer span.End()
+
+	if err := Validate(&s); err != nil {
+		return err
+	}
+
+	return st.WithinTx(ctx, func(tx *storage.Storage) error {
+		return replace(ctx, tx, s, now)
+	})
+}
+
+func replace(ctx context.Context, st *storage.Storage, s State, now time.Time) error {
+	for _, name := range config.Names {
+		val, ok := s.Config[name]
+		if !ok {
+			if err := st.Config.Delete(ctx, name); err != nil {
+				return errors.Wrapf(err, "removing config %s", name)
+			}
+			continue
+		}
+		if err := st.Config.Save(ctx, name, val, now); err != nil {
+			return errors.Wrapf(err, "importing config %s", name)
+		}
+	}
+
+	current, err := st.Participant.List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "listing participants")
+	}
+
+	keep := make(map[string]bool)
+	for _, p := range s.Participants {
+		keep[p.Name] = true
+		np := participant.NewParticipant{
+			Name: p.Name,
+		}
+		if _, err := st.Participant.CreateOrUpdate(ctx, np, now); err != nil {
+			return errors.Wrapf(err, "importing participant %s", p.Name)
+		}
+		if err := st.Participant.SetGroups(ctx, p.Name, p.GroupList(), now); err != nil {
+			return errors.Wrapf(err, "importing groups of participant %s", p.Name)
+		}
+		if err := st.Participant.SetAbsence(ctx, p.Name, p.AwayFrom, p.AwayUntil, now); err != nil {
+			return errors.Wrapf(err, "importing absence of participant %s", p.Name)
+		}
+		if err := st.Participant.SetTimezone(ctx, p.Name, p.Timezone, now); err != nil {
+			return errors.Wrapf(err, "importing time zone of participant %s", p.Name)
+		}
+		if err := st.Participant.Link(ctx, p.Name, p.UserID, p.Name, now); err != nil {
+			return errors.Wrapf(err, "importing user of participant %s", p.Name)
+		}
+	}
+	for _, p := range current {
+		if keep[p.Name] {
+			continue
+		}
+		if err := st.Participant.DeleteByName(ctx, p.Name); err != nil {
+			return errors.Wrapf(err, "removing participant %s", p.Name)
+		}
+	}
+
+	// The stored participants keep their IDs, the leads are set once they all
+	// exist, by the IDs they are stored under.
+	imported, err := st.Participant.List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "listing participants")
+	}
+
+	names := make(map[string]string, len(s.Participants))
+	for _, p := range s.Participants {
+		names[p.ID] = p.Name
+	}
+
+	for _, p := range s.Participants {
+		var leadID string
+		if p.LeadID != "" {
+			lead, ok := participant.Find(imported, names[p.LeadID])
+			if !ok {
+				return errors.Errorf("lead of participant %s is not imported", p.Name)
+			}
+			leadID = lead.ID
+		}
+		if err := st.Participant.SetLead(ctx, p.Name, leadID, now); err != nil {
+			return errors.Wrapf(err, "importing lead of participant %s", p.Name)
+		}
+	}
+
+	schedules, err := st.Reminder.List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "listing reminders")
+	}
+
+	keep = make(map[string]bool)
+	for _, sch := range s.Reminders {
+		keep[sch.ID] = true
+		if err := st.Reminder.Save(ctx, sch, now); err != nil {
+			return errors.Wrapf(err, "importing reminder %s", sch.ID)
+		}
+	}
+	for _, sch := range schedules {
+		if keep[sch.ID] {
+			continue
+		}
+		if err := st.Reminder.Delete(ctx, sch.ID); err != nil {
+			return errors.Wrapf(err, "removing reminder %s", sch.ID)
+		}
+	}
+
+	return nil
+}
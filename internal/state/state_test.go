@@ -0,0 +1,217 @@
+package state_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/state"
+	"github.com/tmowka/telegram-reminder-bot/internal/storage"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+)
+
+var now = time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC)
+
+// TestImport validates an import replaces the stored state, removing what
+// the document doesn't have.
+func TestImport(t *testing.T) {
+	for _, backend := range tests.Backends {
+		backend := backend
+		t.Run(backend, func(t *testing.T) {
+			st, teardown := tests.NewStorage(t, backend)
+			defer teardown()
+
+			ctx := context.Background()
+
+			t.Log("Given a stored state with a location and holidays.")
+			{
+				if err := st.Config.Save(ctx, config.Location, "Europe/Berlin", now); err != nil {
+					t.Fatalf("\t%s\tShould be able to save the location : %s.", tests.Failed, err)
+				}
+				if err := st.Config.Save(ctx, config.Holidays, "2020-12-25", now); err != nil {
+					t.Fatalf("\t%s\tShould be able to save holidays : %s.", tests.Failed, err)
+				}
+				if _, err := st.Participant.CreateOrUpdate(ctx, participant.NewParticipant{Name: "@bob"}, now); err != nil {
+					t.Fatalf("\t%s\tShould be able to add a participant : %s.", tests.Failed, err)
+				}
+
+				s := state.State{
+					Config:       map[config.Name]interface{}{config.Location: "Asia/Tokyo"},
+					Participants: []participant.Participant{{Name: "@alice"}},
+					Reminders:    []reminder.Schedule{{ID: reminder.DefaultID, RemindTime: "10:00"}},
+				}
+				if err := state.Import(ctx, st, s, now); err != nil {
+					t.Fatalf("\t%s\tShould be able to import a state : %s.", tests.Failed, err)
+				}
+
+				got, err := state.Export(ctx, st)
+				if err != nil {
+					t.Fatalf("\t%s\tShould be able to export the state : %s.", tests.Failed, err)
+				}
+				if len(got.Config) != 1 || got.Config[config.Location] != "Asia/Tokyo" {
+					t.Fatalf("\t%s\tShould replace the configs : got %v.", tests.Failed, got.Config)
+				}
+				if len(got.Participants) != 1 || got.Participants[0].Name != "@alice" {
+					t.Fatalf("\t%s\tShould replace the participants : got %+v.", tests.Failed, got.Participants)
+				}
+				if len(got.Reminders) != 1 || got.Reminders[0].RemindTime != "10:00" {
+					t.Fatalf("\t%s\tShould replace the reminders : got %+v.", tests.Failed, got.Reminders)
+				}
+				t.Logf("\t%s\tShould replace the state and remove configs missing from the document.", tests.Success)
+			}
+		})
+	}
+}
+
+// TestValidate validates documents with invalid values are rejected.
+func TestValidate(t *testing.T) {
+	tt := []struct {
+		name string
+		s    state.State
+		err  string
+	}{
+		{
+			name: "empty",
+			s:    state.State{},
+		},
+		{
+			name: "team reminder without a remind time",
+			s:    state.State{Reminders: []reminder.Schedule{{ID: reminder.DefaultID, Message: "Stand up"}}},
+		},
+		{
+			name: "remind time out of range",
+			s:    state.State{Reminders: []reminder.Schedule{{ID: reminder.DefaultID, RemindTime: "25:00"}}},
+			err:  "out of range",
+		},
+		{
+			name: "malformed remind time",
+			s:    state.State{Reminders: []reminder.Schedule{{ID: reminder.DefaultID, RemindTime: "noon"}}},
+			err:  "invalid remind time format",
+		},
+		{
+			name: "other reminder without a remind time",
+			s:    state.State{Reminders: []reminder.Schedule{{ID: "00000000-0000-0000-0000-000000000002"}}},
+			err:  "invalid remind time format",
+		},
+		{
+			name: "unknown location",
+			s:    state.State{Config: map[config.Name]interface{}{config.Location: "Mars/Olympus"}},
+			err:  "config Location",
+		},
+		{
+			name: "participant without a name",
+			s:    state.State{Participants: []participant.Participant{{}}},
+			err:  "participant without a name",
+		},
+	}
+
+	t.Log("Given the need to validate documents before an import.")
+	{
+		for _, tc := range tt {
+			err := state.Validate(&tc.s)
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("\t%s\tShould accept a document with %s : %s.", tests.Failed, tc.name, err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("\t%s\tShould reject a document with %s : got %v, want %q.", tests.Failed, tc.name, err, tc.err)
+			}
+			t.Logf("\t%s\tShould validate a document with %s.", tests.Success, tc.name)
+		}
+	}
+}
+
+// TestDecode validates only documents written by Encode are decoded, so an
+// unrelated JSON file can't wipe the storage out on import.
+func TestDecode(t *testing.T) {
+	tt := []struct {
+		name string
+		doc  string
+		err  string
+	}{
+		{name: "no version", doc: `{"participants": [{"name": "@alice"}]}`, err: "version is missing"},
+		{name: "an unrelated object", doc: `{"name": "package.json"}`, err: "version is missing"},
+		{name: "an empty object", doc: `{}`, err: "version is missing"},
+		{name: "a newer version", doc: `{"version": 2, "participants": [{"name": "@alice"}]}`, err: "newer than the supported"},
+		{name: "no content", doc: `{"version": 1, "participants": []}`, err: "empty state document"},
+		{name: "not JSON", doc: `participants: alice`, err: "decoding state version"},
+		{name: "participants", doc: `{"version": 1, "participants": [{"name": "@alice"}]}`},
+	}
+
+	t.Log("Given the need to decode documents before an import.")
+	{
+		for _, tc := range tt {
+			s, err := state.Decode([]byte(tc.doc))
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("\t%s\tShould decode a document with %s : %s.", tests.Failed, tc.name, err)
+			case tc.err == "" && (len(s.Participants) != 1 || s.Version != state.Version):
+				t.Fatalf("\t%s\tShould decode a document with %s : got %+v.", tests.Failed, tc.name, s)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("\t%s\tShould reject a document with %s : got %v, want %q.", tests.Failed, tc.name, err, tc.err)
+			}
+			t.Logf("\t%s\tShould handle a document with %s.", tests.Success, tc.name)
+		}
+	}
+}
+
+// TestLeads validates leads are imported by the IDs the participants are
+// stored under rather than the IDs in the document.
+func TestLeads(t *testing.T) {
+	st, teardown := tests.NewStorage(t, storage.SQLite)
+	defer teardown()
+
+	ctx := context.Background()
+
+	t.Log("Given a document with the lead of a participant.")
+	{
+		doc := `{
+			"version": 1,
+			"participants": [
+				{"id": "00000000-0000-0000-0000-00000000000a", "name": "@alice"},
+				{"id": "00000000-0000-0000-0000-00000000000b", "name": "@bob", "lead_id": "00000000-0000-0000-0000-00000000000a"}
+			]
+		}`
+
+		s, err := state.Decode([]byte(doc))
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to decode the document : %s.", tests.Failed, err)
+		}
+		if err := state.Validate(s); err != nil {
+			t.Fatalf("\t%s\tShould accept the document : %s.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould accept the document.", tests.Success)
+
+		// Alice is stored under another ID already.
+		alice, err := st.Participant.CreateOrUpdate(ctx, participant.NewParticipant{Name: "@alice"}, now)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to add a participant : %s.", tests.Failed, err)
+		}
+
+		if err := state.Import(ctx, st, *s, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to import the document : %s.", tests.Failed, err)
+		}
+
+		ps, err := st.Participant.List(ctx)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list participants : %s.", tests.Failed, err)
+		}
+		bob, ok := participant.Find(ps, "@bob")
+		if !ok || bob.LeadID != alice.ID {
+			t.Fatalf("\t%s\tShould import the lead by its stored ID : got %+v, want %q.", tests.Failed, bob, alice.ID)
+		}
+		t.Logf("\t%s\tShould import the lead by its stored ID.", tests.Success)
+	}
+
+	t.Log("Given a document with an unknown lead.")
+	{
+		s := state.State{Participants: []participant.Participant{{ID: "b", Name: "@bob", LeadID: "x"}}}
+		if err := state.Validate(&s); err == nil || !strings.Contains(err.Error(), "unknown lead") {
+			t.Fatalf("\t%s\tShould reject the document : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould reject the document.", tests.Success)
+	}
+}
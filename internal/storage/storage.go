@@ -0,0 +1,109 @@
+// Package storage bundles the repositories of the domain packages so the bot
+// can run on top of any supported backend.
+package storage
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/ack"
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/leader"
+	"github.com/tmowka/telegram-reminder-bot/internal/occurrence"
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/personal"
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/setup"
+	"github.com/tmowka/telegram-reminder-bot/internal/webhook"
+)
+
+// Supported storage backends.
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+	Memory   = "memory"
+)
+
+type Storage struct {
+	Config      config.Store
+	Participant participant.Store
+	Reminder    reminder.Store
+	Outbox      outbox.Store
+	Setup       setup.Store
+	Lease       leader.Store
+	Occurrence  occurrence.Store
+	Ack         ack.Store
+	Webhook     webhook.Store
+	Personal    personal.Store
+
+	db *sqlx.DB
+}
+
+// NewDB returns repositories backed by a Postgres or SQLite database.
+func NewDB(db *sqlx.DB) *Storage {
+	st := newDB(db)
+	st.db = db
+
+	return st
+}
+
+// newDB builds the repositories on top of a database handle or transaction.
+func newDB(db sqlx.ExtContext) *Storage {
+	return &Storage{
+		Config:      config.NewDBStore(db),
+		Participant: participant.NewDBStore(db),
+		Reminder:    reminder.NewDBStore(db),
+		Outbox:      outbox.NewDBStore(db),
+		Setup:       setup.NewDBStore(db),
+		Lease:       leader.NewDBStore(db),
+		Occurrence:  occurrence.NewDBStore(db),
+		Ack:         ack.NewDBStore(db),
+		Webhook:     webhook.NewDBStore(db),
+		Personal:    personal.NewDBStore(db),
+	}
+}
+
+// NewMemory returns repositories that keep everything in process memory.
+func NewMemory() *Storage {
+	return &Storage{
+		Config:      config.NewMemoryStore(),
+		Participant: participant.NewMemoryStore(),
+		Reminder:    reminder.NewMemoryStore(),
+		Outbox:      outbox.NewMemoryStore(),
+		Setup:       setup.NewMemoryStore(),
+		Lease:       leader.NewMemoryStore(),
+		Occurrence:  occurrence.NewMemoryStore(),
+		Ack:         ack.NewMemoryStore(),
+		Webhook:     webhook.NewMemoryStore(),
+		Personal:    personal.NewMemoryStore(),
+	}
+}
+
+// WithinTx runs fn with repositories bound to a single database transaction
+// that is committed only if fn succeeds. The in-memory repositories have no
+// transactions, so callers should validate their input before making
+// changes.
+func (s *Storage) WithinTx(ctx context.Context, fn func(tx *Storage) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+
+	if err := fn(newDB(tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "committing transaction")
+	}
+
+	return nil
+}
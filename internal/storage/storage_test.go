@@ -0,0 +1,759 @@
+package storage_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/ack"
+	"github.com/tmowka/telegram-reminder-bot/internal/config"
+	"github.com/tmowka/telegram-reminder-bot/internal/occurrence"
+	"github.com/tmowka/telegram-reminder-bot/internal/outbox"
+	"github.com/tmowka/telegram-reminder-bot/internal/participant"
+	"github.com/tmowka/telegram-reminder-bot/internal/personal"
+	"github.com/tmowka/telegram-reminder-bot/internal/reminder"
+	"github.com/tmowka/telegram-reminder-bot/internal/setup"
+	"github.com/tmowka/telegram-reminder-bot/internal/storage"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+	"github.com/tmowka/telegram-reminder-bot/internal/webhook"
+)
+
+// now is the moment the tests happen at, whole seconds survive every
+// backend.
+var now = time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC)
+
+// TestStores runs the same checks against every storage backend.
+func TestStores(t *testing.T) {
+	checks := []struct {
+		name string
+		fn   func(t *testing.T, st *storage.Storage)
+	}{
+		{"config", testConfig},
+		{"participant", testParticipant},
+		{"reminder", testReminder},
+		{"outbox", testOutbox},
+		{"personal", testPersonal},
+		{"occurrence", testOccurrence},
+		{"ack", testAck},
+		{"lease", testLease},
+		{"webhook", testWebhook},
+		{"setup", testSetup},
+	}
+
+	for _, backend := range tests.Backends {
+		backend := backend
+		t.Run(backend, func(t *testing.T) {
+			for _, c := range checks {
+				c := c
+				t.Run(c.name, func(t *testing.T) {
+					st, teardown := tests.NewStorage(t, backend)
+					defer teardown()
+
+					c.fn(t, st)
+				})
+			}
+		})
+	}
+}
+
+func testConfig(t *testing.T, st *storage.Storage) {
+	ctx := context.Background()
+
+	t.Log("Given the need to work with the configuration.")
+	{
+		if _, err := st.Config.GetByName(ctx, config.Location); err != config.ErrNotFound {
+			t.Fatalf("\t%s\tShould get ErrNotFound for a config never saved : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould get ErrNotFound for a config never saved.", tests.Success)
+
+		values := []struct {
+			name config.Name
+			val  interface{}
+		}{
+			{config.BotStarted, true},
+			{config.ParticipantsLocked, false},
+			{config.Location, "Europe/Berlin"},
+			{config.Holidays, "2020-12-25,2021-01-01"},
+		}
+
+		for _, v := range values {
+			if err := st.Config.Save(ctx, v.name, v.val, now); err != nil {
+				t.Fatalf("\t%s\tShould be able to save %s : %s.", tests.Failed, v.name, err)
+			}
+
+			got, err := st.Config.GetByName(ctx, v.name)
+			if err != nil {
+				t.Fatalf("\t%s\tShould be able to get %s : %s.", tests.Failed, v.name, err)
+			}
+			if got != v.val {
+				t.Fatalf("\t%s\tShould get back the saved %s : got %v, want %v.", tests.Failed, v.name, got, v.val)
+			}
+		}
+		t.Logf("\t%s\tShould get back the saved boolean and string configs.", tests.Success)
+
+		if err := st.Config.Save(ctx, config.Location, "Asia/Tokyo", now.Add(time.Hour)); err != nil {
+			t.Fatalf("\t%s\tShould be able to save a config again : %s.", tests.Failed, err)
+		}
+		got, err := st.Config.GetByName(ctx, config.Location)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to get %s : %s.", tests.Failed, config.Location, err)
+		}
+		if got != "Asia/Tokyo" {
+			t.Fatalf("\t%s\tShould overwrite the saved config : got %v.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould overwrite the saved config.", tests.Success)
+
+		if _, err := st.Config.GetByName(ctx, "Unknown"); err == nil {
+			t.Fatalf("\t%s\tShould not get an unknown config.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould not get an unknown config.", tests.Success)
+	}
+}
+
+func testParticipant(t *testing.T, st *storage.Storage) {
+	ctx := context.Background()
+
+	t.Log("Given the need to work with participants.")
+	{
+		for i, name := range []string{"bob", "alice"} {
+			if _, err := st.Participant.CreateOrUpdate(ctx, participant.NewParticipant{Name: name}, now.Add(time.Duration(i)*time.Minute)); err != nil {
+				t.Fatalf("\t%s\tShould be able to add %s : %s.", tests.Failed, name, err)
+			}
+		}
+		if _, err := st.Participant.CreateOrUpdate(ctx, participant.NewParticipant{Name: "bob"}, now.Add(time.Hour)); err != nil {
+			t.Fatalf("\t%s\tShould be able to add bob again : %s.", tests.Failed, err)
+		}
+
+		ps := list(t, st)
+		if len(ps) != 2 || ps[0].Name != "bob" || ps[1].Name != "alice" {
+			t.Fatalf("\t%s\tShould list the participants once, in the order they were added : got %v.", tests.Failed, names(ps))
+		}
+		if !ps[0].AddedAt.Equal(now) || !ps[0].UpdatedAt.Equal(now.Add(time.Hour)) {
+			t.Fatalf("\t%s\tShould keep when bob was added : got %s, updated %s.", tests.Failed, ps[0].AddedAt, ps[0].UpdatedAt)
+		}
+		t.Logf("\t%s\tShould list the participants once, in the order they were added.", tests.Success)
+
+		from, until := now, now.Add(48*time.Hour)
+		if err := st.Participant.SetGroups(ctx, "bob", []string{"qa", "backend"}, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to set groups : %s.", tests.Failed, err)
+		}
+		if err := st.Participant.SetAbsence(ctx, "bob", &from, &until, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to set an absence : %s.", tests.Failed, err)
+		}
+		if err := st.Participant.SetTimezone(ctx, "bob", "Asia/Tokyo", now); err != nil {
+			t.Fatalf("\t%s\tShould be able to set a time zone : %s.", tests.Failed, err)
+		}
+
+		bob := list(t, st)[0]
+		if bob.Groups != participant.FormatGroups([]string{"qa", "backend"}) {
+			t.Fatalf("\t%s\tShould save the groups : got %q.", tests.Failed, bob.Groups)
+		}
+		if bob.AwayFrom == nil || bob.AwayUntil == nil || !bob.AwayFrom.Equal(from) || !bob.AwayUntil.Equal(until) {
+			t.Fatalf("\t%s\tShould save the absence : got %v - %v.", tests.Failed, bob.AwayFrom, bob.AwayUntil)
+		}
+		if bob.Timezone != "Asia/Tokyo" {
+			t.Fatalf("\t%s\tShould save the time zone : got %q.", tests.Failed, bob.Timezone)
+		}
+		t.Logf("\t%s\tShould save the groups, absence and time zone.", tests.Success)
+
+		if err := st.Participant.SetAbsence(ctx, "bob", nil, nil, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to clear an absence : %s.", tests.Failed, err)
+		}
+		if bob := list(t, st)[0]; bob.AwayFrom != nil || bob.AwayUntil != nil {
+			t.Fatalf("\t%s\tShould clear the absence : got %v - %v.", tests.Failed, bob.AwayFrom, bob.AwayUntil)
+		}
+		t.Logf("\t%s\tShould clear the absence.", tests.Success)
+
+		if err := st.Participant.SetLead(ctx, "alice", bob.ID, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to set a lead : %s.", tests.Failed, err)
+		}
+
+		if err := st.Participant.Link(ctx, "bob", 42, "robert", now); err != nil {
+			t.Fatalf("\t%s\tShould be able to link a participant : %s.", tests.Failed, err)
+		}
+		ps = list(t, st)
+		if ps[0].Name != "robert" || ps[0].UserID != 42 || ps[0].Timezone != "Asia/Tokyo" {
+			t.Fatalf("\t%s\tShould rename and link the participant : got %+v.", tests.Failed, ps[0])
+		}
+		if ps[1].LeadID != ps[0].ID {
+			t.Fatalf("\t%s\tShould keep the renamed lead : got %q, want %q.", tests.Failed, ps[1].LeadID, ps[0].ID)
+		}
+		t.Logf("\t%s\tShould rename and link the participant.", tests.Success)
+
+		for _, err := range []error{
+			st.Participant.SetGroups(ctx, "bob", nil, now),
+			st.Participant.SetAbsence(ctx, "bob", nil, nil, now),
+			st.Participant.SetTimezone(ctx, "bob", "", now),
+			st.Participant.Link(ctx, "bob", 42, "bob", now),
+			st.Participant.SetLead(ctx, "bob", "", now),
+		} {
+			if err != participant.ErrNotFound {
+				t.Fatalf("\t%s\tShould get ErrNotFound changing a missing participant : got %v.", tests.Failed, err)
+			}
+		}
+		t.Logf("\t%s\tShould get ErrNotFound changing a missing participant.", tests.Success)
+
+		if err := st.Participant.DeleteByName(ctx, "robert"); err != nil {
+			t.Fatalf("\t%s\tShould be able to delete a participant : %s.", tests.Failed, err)
+		}
+		if ps := list(t, st); len(ps) != 1 || ps[0].Name != "alice" {
+			t.Fatalf("\t%s\tShould delete the participant : got %v.", tests.Failed, names(ps))
+		}
+		if alice := list(t, st)[0]; alice.LeadID != "" {
+			t.Fatalf("\t%s\tShould clear the lead of the deleted participant : got %q.", tests.Failed, alice.LeadID)
+		}
+		t.Logf("\t%s\tShould delete the participant.", tests.Success)
+	}
+}
+
+func list(t *testing.T, st *storage.Storage) []participant.Participant {
+	t.Helper()
+
+	ps, err := st.Participant.List(context.Background())
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to list participants : %s.", tests.Failed, err)
+	}
+
+	return ps
+}
+
+func names(ps []participant.Participant) []string {
+	var names []string
+	for _, p := range ps {
+		names = append(names, p.Name)
+	}
+
+	return names
+}
+
+func testReminder(t *testing.T, st *storage.Storage) {
+	ctx := context.Background()
+
+	t.Log("Given the need to work with reminder schedules.")
+	{
+		if _, err := st.Reminder.Get(ctx, reminder.DefaultID); err != reminder.ErrNotFound {
+			t.Fatalf("\t%s\tShould get ErrNotFound for a missing schedule : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould get ErrNotFound for a missing schedule.", tests.Success)
+
+		paused := now.Add(24 * time.Hour)
+		sch := reminder.Schedule{
+			ID:             reminder.DefaultID,
+			RemindTime:     "10:00",
+			WeekdaysToSkip: "0,6",
+			Message:        "Stand up",
+			Groups:         "backend",
+			Escalation:     "2h dm",
+			PausedUntil:    &paused,
+		}
+		if err := st.Reminder.Save(ctx, sch, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to save a schedule : %s.", tests.Failed, err)
+		}
+
+		got, err := st.Reminder.Get(ctx, reminder.DefaultID)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to get the schedule : %s.", tests.Failed, err)
+		}
+		if got.RemindTime != sch.RemindTime || got.WeekdaysToSkip != sch.WeekdaysToSkip || got.Message != sch.Message ||
+			got.Groups != sch.Groups || got.Escalation != sch.Escalation || got.SnoozedUntil != nil ||
+			got.PausedUntil == nil || !got.PausedUntil.Equal(paused) || !got.CreatedAt.Equal(now) {
+			t.Fatalf("\t%s\tShould get back the saved schedule : got %+v.", tests.Failed, got)
+		}
+		t.Logf("\t%s\tShould get back the saved schedule.", tests.Success)
+
+		sch.RemindTime = "11:30"
+		sch.PausedUntil = nil
+		if err := st.Reminder.Save(ctx, sch, now.Add(time.Hour)); err != nil {
+			t.Fatalf("\t%s\tShould be able to save the schedule again : %s.", tests.Failed, err)
+		}
+		second := reminder.Schedule{ID: "00000000-0000-0000-0000-000000000002", RemindTime: "15:00"}
+		if err := st.Reminder.Save(ctx, second, now.Add(2*time.Hour)); err != nil {
+			t.Fatalf("\t%s\tShould be able to save another schedule : %s.", tests.Failed, err)
+		}
+
+		schedules, err := st.Reminder.List(ctx)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list schedules : %s.", tests.Failed, err)
+		}
+		if len(schedules) != 2 || schedules[0].ID != reminder.DefaultID || schedules[1].ID != second.ID {
+			t.Fatalf("\t%s\tShould list the schedules in the order they were created : got %+v.", tests.Failed, schedules)
+		}
+		first := schedules[0]
+		if first.RemindTime != "11:30" || first.PausedUntil != nil || !first.CreatedAt.Equal(now) || !first.UpdatedAt.Equal(now.Add(time.Hour)) {
+			t.Fatalf("\t%s\tShould update the schedule in place : got %+v.", tests.Failed, first)
+		}
+		t.Logf("\t%s\tShould update the schedule in place.", tests.Success)
+
+		if err := st.Reminder.Delete(ctx, second.ID); err != nil {
+			t.Fatalf("\t%s\tShould be able to delete a schedule : %s.", tests.Failed, err)
+		}
+		if _, err := st.Reminder.Get(ctx, second.ID); err != reminder.ErrNotFound {
+			t.Fatalf("\t%s\tShould delete the schedule : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould delete the schedule.", tests.Success)
+	}
+}
+
+func testOutbox(t *testing.T, st *storage.Storage) {
+	ctx := context.Background()
+
+	t.Log("Given the need to work with the outbox.")
+	{
+		var ids []string
+		for i, text := range []string{"first", "second", "third"} {
+			m, err := st.Outbox.Enqueue(ctx, outbox.NewMessage{ChatID: "1", Text: text}, now.Add(time.Duration(i)*time.Second))
+			if err != nil {
+				t.Fatalf("\t%s\tShould be able to enqueue a message : %s.", tests.Failed, err)
+			}
+			if m.Status != outbox.StatusPending || !m.NextAttemptAt.Equal(m.CreatedAt) {
+				t.Fatalf("\t%s\tShould enqueue a message due right away : got %+v.", tests.Failed, m)
+			}
+			ids = append(ids, m.ID)
+		}
+
+		pending := func() []outbox.Message {
+			ms, err := st.Outbox.ListPending(ctx, now.Add(time.Hour), 10)
+			if err != nil {
+				t.Fatalf("\t%s\tShould be able to list pending messages : %s.", tests.Failed, err)
+			}
+			return ms
+		}
+
+		ms := pending()
+		if len(ms) != 3 || ms[0].Text != "first" || ms[1].Text != "second" || ms[2].Text != "third" {
+			t.Fatalf("\t%s\tShould list the messages in the order they were enqueued : got %+v.", tests.Failed, ms)
+		}
+		t.Logf("\t%s\tShould list the messages in the order they were enqueued.", tests.Success)
+
+		limited, err := st.Outbox.ListPending(ctx, now.Add(time.Hour), 2)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list pending messages : %s.", tests.Failed, err)
+		}
+		if len(limited) != 2 {
+			t.Fatalf("\t%s\tShould respect the limit : got %d messages.", tests.Failed, len(limited))
+		}
+		t.Logf("\t%s\tShould respect the limit.", tests.Success)
+
+		next := now.Add(time.Minute)
+		if err := st.Outbox.Retry(ctx, ids[0], next, "unavailable", now); err != nil {
+			t.Fatalf("\t%s\tShould be able to record a retry : %s.", tests.Failed, err)
+		}
+		if m := pending()[0]; m.Attempts != 1 || m.LastError != "unavailable" || !m.NextAttemptAt.Equal(next) {
+			t.Fatalf("\t%s\tShould record the failed attempt : got %+v.", tests.Failed, m)
+		}
+		t.Logf("\t%s\tShould record the failed attempt.", tests.Success)
+
+		other, err := st.Outbox.Enqueue(ctx, outbox.NewMessage{ChatID: "2", Text: "other"}, now.Add(3*time.Second))
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to enqueue a message : %s.", tests.Failed, err)
+		}
+		due, err := st.Outbox.ListPending(ctx, now.Add(30*time.Second), 10)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list pending messages : %s.", tests.Failed, err)
+		}
+		if len(due) != 1 || due[0].ID != other.ID {
+			t.Fatalf("\t%s\tShould hold back the chat of a message backing off : got %+v.", tests.Failed, due)
+		}
+		t.Logf("\t%s\tShould hold back only the chat of a message backing off.", tests.Success)
+
+		if err := st.Outbox.Delete(ctx, other.ID); err != nil {
+			t.Fatalf("\t%s\tShould be able to delete a message : %s.", tests.Failed, err)
+		}
+
+		if err := st.Outbox.Fail(ctx, ids[1], "chat not found", now); err != nil {
+			t.Fatalf("\t%s\tShould be able to fail a message : %s.", tests.Failed, err)
+		}
+		if err := st.Outbox.Delete(ctx, ids[2]); err != nil {
+			t.Fatalf("\t%s\tShould be able to delete a message : %s.", tests.Failed, err)
+		}
+		if ms := pending(); len(ms) != 1 || ms[0].ID != ids[0] {
+			t.Fatalf("\t%s\tShould no longer list failed and delivered messages : got %+v.", tests.Failed, ms)
+		}
+		t.Logf("\t%s\tShould no longer list failed and delivered messages.", tests.Success)
+
+		reply, err := st.Outbox.Enqueue(ctx, outbox.NewMessage{ChatID: "1", Text: "reply", ReplyTo: 42}, now.Add(4*time.Second))
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to enqueue a reply : %s.", tests.Failed, err)
+		}
+		if ms := pending(); len(ms) != 2 || ms[1].ID != reply.ID || ms[1].ReplyTo != 42 {
+			t.Fatalf("\t%s\tShould keep the message a reply is to : got %+v.", tests.Failed, ms)
+		}
+		t.Logf("\t%s\tShould keep the message a reply is to.", tests.Success)
+	}
+}
+
+func testPersonal(t *testing.T, st *storage.Storage) {
+	ctx := context.Background()
+
+	t.Log("Given the need to work with personal reminders.")
+	{
+		nr := personal.NewReminder{
+			ChatID:    "1",
+			UserID:    7,
+			MessageID: 42,
+			Text:      "call vendor",
+			DueAt:     now.Add(time.Hour),
+		}
+		r, err := st.Personal.Create(ctx, nr, now)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to create a reminder : %s.", tests.Failed, err)
+		}
+
+		pending, err := st.Personal.ListByUser(ctx, 7)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list the reminders of the user : %s.", tests.Failed, err)
+		}
+		if len(pending) != 1 || pending[0].ID != r.ID || pending[0].MessageID != 42 || !pending[0].DueAt.Equal(nr.DueAt) {
+			t.Fatalf("\t%s\tShould list the pending reminder : got %+v.", tests.Failed, pending)
+		}
+		t.Logf("\t%s\tShould list the pending reminder.", tests.Success)
+
+		sent, err := st.Personal.MarkSent(ctx, r.ID, now)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to mark the reminder as sent : %s.", tests.Failed, err)
+		}
+		if !sent {
+			t.Fatalf("\t%s\tShould mark a pending reminder as sent.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould mark a pending reminder as sent.", tests.Success)
+
+		sent, err = st.Personal.MarkSent(ctx, r.ID, now)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to mark the reminder as sent : %s.", tests.Failed, err)
+		}
+		if cancelled, err := st.Personal.Cancel(ctx, r.ID, now); err != nil || cancelled || sent {
+			t.Fatalf("\t%s\tShould not send or cancel a sent reminder : got sent %t, cancelled %t, %v.", tests.Failed, sent, cancelled, err)
+		}
+		got, err := st.Personal.Get(ctx, r.ID)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to get the reminder : %s.", tests.Failed, err)
+		}
+		if got.Status != personal.StatusSent {
+			t.Fatalf("\t%s\tShould keep the reminder sent : got %s.", tests.Failed, got.Status)
+		}
+		t.Logf("\t%s\tShould not send or cancel a sent reminder.", tests.Success)
+
+		if pending, err := st.Personal.ListPending(ctx, 10); err != nil || len(pending) != 0 {
+			t.Fatalf("\t%s\tShould no longer list a sent reminder : got %+v, %v.", tests.Failed, pending, err)
+		}
+		t.Logf("\t%s\tShould no longer list a sent reminder.", tests.Success)
+	}
+}
+
+func testOccurrence(t *testing.T, st *storage.Storage) {
+	ctx := context.Background()
+
+	pending := func() []occurrence.Occurrence {
+		occs, err := st.Occurrence.ListPending(ctx, 10)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list pending occurrences : %s.", tests.Failed, err)
+		}
+		return occs
+	}
+
+	t.Log("Given the need to record every remind once.")
+	{
+		dueAt := now.Add(time.Hour)
+		for i := 0; i < 2; i++ {
+			if err := st.Occurrence.Create(ctx, reminder.DefaultID, dueAt, now.Add(time.Duration(i)*time.Second)); err != nil {
+				t.Fatalf("\t%s\tShould be able to record an occurrence : %s.", tests.Failed, err)
+			}
+		}
+		if occs := pending(); len(occs) != 1 || !occs[0].DueAt.Equal(dueAt) || occs[0].Status != occurrence.StatusPending || !occs[0].CreatedAt.Equal(now) {
+			t.Fatalf("\t%s\tShould record a remind notified twice once : got %+v.", tests.Failed, occs)
+		}
+		t.Logf("\t%s\tShould record a remind notified twice once.", tests.Success)
+
+		if err := st.Occurrence.Retry(ctx, reminder.DefaultID, dueAt, "unavailable", now); err != nil {
+			t.Fatalf("\t%s\tShould be able to record a retry : %s.", tests.Failed, err)
+		}
+		if occs := pending(); len(occs) != 1 || occs[0].Attempts != 1 || occs[0].LastError != "unavailable" {
+			t.Fatalf("\t%s\tShould keep a retried occurrence pending : got %+v.", tests.Failed, occs)
+		}
+		t.Logf("\t%s\tShould keep a retried occurrence pending.", tests.Success)
+
+		// Replicas racing for the remind, e.g. an old leader that didn't
+		// notice it lost the lease, mark it in a transaction each.
+		const replicas = 8
+		results := make(chan bool, replicas)
+		var wg sync.WaitGroup
+		for i := 0; i < replicas; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := st.WithinTx(ctx, func(tx *storage.Storage) error {
+					sent, err := tx.Occurrence.MarkSent(ctx, reminder.DefaultID, dueAt, now)
+					results <- sent
+					return err
+				})
+				if err != nil {
+					t.Errorf("\t%s\tShould be able to mark the occurrence as sent : %s.", tests.Failed, err)
+				}
+			}()
+		}
+		wg.Wait()
+		close(results)
+
+		var sent int
+		for ok := range results {
+			if ok {
+				sent++
+			}
+		}
+		if sent != 1 {
+			t.Fatalf("\t%s\tShould let a single replica mark the occurrence as sent : got %d.", tests.Failed, sent)
+		}
+		if occs := pending(); len(occs) != 0 {
+			t.Fatalf("\t%s\tShould no longer list a sent occurrence : got %+v.", tests.Failed, occs)
+		}
+		t.Logf("\t%s\tShould let a single replica mark the occurrence as sent.", tests.Success)
+
+		if err := st.Occurrence.Create(ctx, reminder.DefaultID, dueAt, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to record an occurrence : %s.", tests.Failed, err)
+		}
+		if occs := pending(); len(occs) != 0 {
+			t.Fatalf("\t%s\tShould not record a sent remind again : got %+v.", tests.Failed, occs)
+		}
+		t.Logf("\t%s\tShould not record a sent remind again.", tests.Success)
+
+		later := dueAt.Add(24 * time.Hour)
+		if err := st.Occurrence.Create(ctx, reminder.DefaultID, later, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to record an occurrence : %s.", tests.Failed, err)
+		}
+		if err := st.Occurrence.Fail(ctx, reminder.DefaultID, later, "expired", now); err != nil {
+			t.Fatalf("\t%s\tShould be able to fail an occurrence : %s.", tests.Failed, err)
+		}
+		if sent, err := st.Occurrence.MarkSent(ctx, reminder.DefaultID, later, now); err != nil || sent {
+			t.Fatalf("\t%s\tShould not send a failed occurrence : got %t, %v.", tests.Failed, sent, err)
+		}
+		if occs := pending(); len(occs) != 0 {
+			t.Fatalf("\t%s\tShould no longer list a failed occurrence : got %+v.", tests.Failed, occs)
+		}
+		t.Logf("\t%s\tShould give a failed occurrence up.", tests.Success)
+	}
+}
+
+func testAck(t *testing.T, st *storage.Storage) {
+	ctx := context.Background()
+
+	open := func(since time.Time) []ack.Ack {
+		as, err := st.Ack.ListOpen(ctx, since)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list open acknowledgements : %s.", tests.Failed, err)
+		}
+		return as
+	}
+
+	t.Log("Given the need to track the acknowledgements of reminds.")
+	{
+		yesterday, today := now.Add(-24*time.Hour), now
+		for _, dueAt := range []time.Time{yesterday, today, today} {
+			if err := st.Ack.Expect(ctx, reminder.DefaultID, dueAt, []string{"bob", "carol"}, now); err != nil {
+				t.Fatalf("\t%s\tShould be able to expect acknowledgements : %s.", tests.Failed, err)
+			}
+		}
+		as := open(yesterday)
+		if len(as) != 4 || !as[0].DueAt.Equal(yesterday) || !as[3].DueAt.Equal(today) || as[0].AckedAt != nil {
+			t.Fatalf("\t%s\tShould expect an acknowledgement per participant and remind once : got %+v.", tests.Failed, as)
+		}
+		if as := open(today); len(as) != 2 {
+			t.Fatalf("\t%s\tShould list the reminds due since the moment only : got %+v.", tests.Failed, as)
+		}
+		t.Logf("\t%s\tShould expect an acknowledgement per participant and remind once.", tests.Success)
+
+		if err := st.Ack.SetEscalated(ctx, reminder.DefaultID, today, "carol", 2, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to record the escalation : %s.", tests.Failed, err)
+		}
+		for _, a := range open(today) {
+			if want := map[string]int{"bob": 0, "carol": 2}[a.ParticipantID]; a.Escalated != want {
+				t.Fatalf("\t%s\tShould record the escalation of %s : got %d, want %d.", tests.Failed, a.ParticipantID, a.Escalated, want)
+			}
+		}
+		t.Logf("\t%s\tShould record the escalation steps taken.", tests.Success)
+
+		acked, err := st.Ack.Acknowledge(ctx, reminder.DefaultID, "bob", now)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to acknowledge : %s.", tests.Failed, err)
+		}
+		if acked != 2 {
+			t.Fatalf("\t%s\tShould close every open acknowledgement of the participant : got %d.", tests.Failed, acked)
+		}
+		if as := open(yesterday); len(as) != 2 || as[0].ParticipantID != "carol" || as[1].ParticipantID != "carol" {
+			t.Fatalf("\t%s\tShould no longer list closed acknowledgements : got %+v.", tests.Failed, as)
+		}
+		if acked, err := st.Ack.Acknowledge(ctx, reminder.DefaultID, "bob", now); err != nil || acked != 0 {
+			t.Fatalf("\t%s\tShould have nothing left to acknowledge : got %d, %v.", tests.Failed, acked, err)
+		}
+		t.Logf("\t%s\tShould close every open acknowledgement of the participant.", tests.Success)
+	}
+}
+
+func testLease(t *testing.T, st *storage.Storage) {
+	ctx := context.Background()
+
+	acquire := func(holder string, at time.Time) bool {
+		held, err := st.Lease.Acquire(ctx, "scheduler", holder, at.Add(15*time.Second), at)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to acquire the lease : %s.", tests.Failed, err)
+		}
+		return held
+	}
+
+	t.Log("Given two replicas competing for a lease.")
+	{
+		if !acquire("a", now) {
+			t.Fatalf("\t%s\tShould grant a free lease.", tests.Failed)
+		}
+		if acquire("b", now.Add(5*time.Second)) {
+			t.Fatalf("\t%s\tShould not grant a lease held by another replica.", tests.Failed)
+		}
+		if !acquire("a", now.Add(10*time.Second)) {
+			t.Fatalf("\t%s\tShould renew the lease of its holder.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould keep the lease with its holder while renewed.", tests.Success)
+
+		if acquire("b", now.Add(25*time.Second)) {
+			t.Fatalf("\t%s\tShould not grant a lease before it expires.", tests.Failed)
+		}
+		if !acquire("b", now.Add(26*time.Second)) {
+			t.Fatalf("\t%s\tShould grant an expired lease.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould grant the lease once it expires.", tests.Success)
+
+		if err := st.Lease.Release(ctx, "scheduler", "a"); err != nil {
+			t.Fatalf("\t%s\tShould be able to release the lease : %s.", tests.Failed, err)
+		}
+		if acquire("a", now.Add(27*time.Second)) {
+			t.Fatalf("\t%s\tShould not release the lease of another holder.", tests.Failed)
+		}
+		if err := st.Lease.Release(ctx, "scheduler", "b"); err != nil {
+			t.Fatalf("\t%s\tShould be able to release the lease : %s.", tests.Failed, err)
+		}
+		if !acquire("a", now.Add(28*time.Second)) {
+			t.Fatalf("\t%s\tShould grant a released lease.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould grant the lease once its holder releases it.", tests.Success)
+	}
+}
+
+func testWebhook(t *testing.T, st *storage.Storage) {
+	ctx := context.Background()
+
+	pending := func(at time.Time) []webhook.Delivery {
+		ds, err := st.Webhook.ListPending(ctx, at, 10)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list pending deliveries : %s.", tests.Failed, err)
+		}
+		return ds
+	}
+
+	t.Log("Given the need to work with webhook deliveries.")
+	{
+		var ids []string
+		for i, url := range []string{"http://a", "http://b", "http://c"} {
+			nd := webhook.NewDelivery{URL: url, Event: webhook.EventFired, Payload: `{"reminder_id":"1"}`}
+			d, err := st.Webhook.Enqueue(ctx, nd, now.Add(time.Duration(i)*time.Second))
+			if err != nil {
+				t.Fatalf("\t%s\tShould be able to enqueue a delivery : %s.", tests.Failed, err)
+			}
+			ids = append(ids, d.ID)
+		}
+
+		ds := pending(now.Add(time.Hour))
+		if len(ds) != 3 || ds[0].ID != ids[0] || ds[2].ID != ids[2] || ds[0].Payload != `{"reminder_id":"1"}` || ds[0].Event != webhook.EventFired {
+			t.Fatalf("\t%s\tShould list the deliveries in the order they were enqueued : got %+v.", tests.Failed, ds)
+		}
+		if ds := pending(now.Add(time.Second)); len(ds) != 2 {
+			t.Fatalf("\t%s\tShould list only the deliveries due : got %+v.", tests.Failed, ds)
+		}
+		t.Logf("\t%s\tShould list the deliveries due in the order they were enqueued.", tests.Success)
+
+		next := now.Add(time.Minute)
+		if err := st.Webhook.Retry(ctx, ids[0], next, "status 500", now); err != nil {
+			t.Fatalf("\t%s\tShould be able to record a retry : %s.", tests.Failed, err)
+		}
+		if ds := pending(now.Add(30 * time.Second)); len(ds) != 2 || ds[0].ID != ids[1] {
+			t.Fatalf("\t%s\tShould not list a delivery backing off : got %+v.", tests.Failed, ds)
+		}
+		if ds := pending(next); len(ds) != 3 || ds[2].ID != ids[0] || ds[2].Attempts != 1 || ds[2].LastError != "status 500" {
+			t.Fatalf("\t%s\tShould list a retried delivery once it is due : got %+v.", tests.Failed, ds)
+		}
+		t.Logf("\t%s\tShould record the failed attempt.", tests.Success)
+
+		if err := st.Webhook.Delivered(ctx, ids[1], now); err != nil {
+			t.Fatalf("\t%s\tShould be able to mark a delivery delivered : %s.", tests.Failed, err)
+		}
+		if err := st.Webhook.Fail(ctx, ids[2], "status 404", now); err != nil {
+			t.Fatalf("\t%s\tShould be able to fail a delivery : %s.", tests.Failed, err)
+		}
+		if ds := pending(next); len(ds) != 1 || ds[0].ID != ids[0] {
+			t.Fatalf("\t%s\tShould no longer list failed and delivered deliveries : got %+v.", tests.Failed, ds)
+		}
+		t.Logf("\t%s\tShould no longer list failed and delivered deliveries.", tests.Success)
+
+		attempts := []webhook.Attempt{
+			{DeliveryID: ids[0], Attempt: 1, URL: "http://a", Event: webhook.EventFired, StatusCode: 500, Error: "status 500", AttemptedAt: now},
+			{DeliveryID: ids[1], Attempt: 1, URL: "http://b", Event: webhook.EventFired, StatusCode: 200, AttemptedAt: now},
+			{DeliveryID: ids[2], Attempt: 1, URL: "http://c", Event: webhook.EventFired, StatusCode: 404, Error: "status 404", AttemptedAt: now.Add(time.Second)},
+		}
+		for _, a := range attempts {
+			if err := st.Webhook.RecordAttempt(ctx, a); err != nil {
+				t.Fatalf("\t%s\tShould be able to record an attempt : %s.", tests.Failed, err)
+			}
+		}
+		failed, err := st.Webhook.ListFailedAttempts(ctx, 10)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list failed attempts : %s.", tests.Failed, err)
+		}
+		if len(failed) != 2 || failed[0].DeliveryID != ids[2] || failed[1].DeliveryID != ids[0] || failed[0].StatusCode != 404 {
+			t.Fatalf("\t%s\tShould list the failed attempts, the latest first : got %+v.", tests.Failed, failed)
+		}
+		t.Logf("\t%s\tShould list the failed attempts, the latest first.", tests.Success)
+	}
+}
+
+func testSetup(t *testing.T, st *storage.Storage) {
+	ctx := context.Background()
+
+	t.Log("Given the need to work with setup sessions.")
+	{
+		if _, err := st.Setup.Get(ctx, 7); err != setup.ErrNotFound {
+			t.Fatalf("\t%s\tShould get ErrNotFound for a session never saved : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould get ErrNotFound for a session never saved.", tests.Success)
+
+		ses := setup.Session{UserID: 7, ChatID: "-100", Step: setup.StepTime, MessageID: 42}
+		if err := st.Setup.Save(ctx, ses, now); err != nil {
+			t.Fatalf("\t%s\tShould be able to save a session : %s.", tests.Failed, err)
+		}
+
+		ses.Step = setup.StepConfirm
+		ses.RemindTime = "10:30"
+		ses.WeekdaysToSkip = "0,6"
+		ses.Message = "Standup!"
+		ses.Participants = "@bob, @carol"
+		if err := st.Setup.Save(ctx, ses, now.Add(time.Minute)); err != nil {
+			t.Fatalf("\t%s\tShould be able to save a session : %s.", tests.Failed, err)
+		}
+
+		got, err := st.Setup.Get(ctx, 7)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to get the session : %s.", tests.Failed, err)
+		}
+		ses.UpdatedAt = now.Add(time.Minute)
+		got.UpdatedAt = got.UpdatedAt.UTC()
+		if *got != ses {
+			t.Fatalf("\t%s\tShould get the session as last saved : got %+v, want %+v.", tests.Failed, *got, ses)
+		}
+		t.Logf("\t%s\tShould get the session as last saved.", tests.Success)
+
+		if err := st.Setup.Delete(ctx, 7); err != nil {
+			t.Fatalf("\t%s\tShould be able to delete the session : %s.", tests.Failed, err)
+		}
+		if _, err := st.Setup.Get(ctx, 7); err != setup.ErrNotFound {
+			t.Fatalf("\t%s\tShould get ErrNotFound for a deleted session : got %v.", tests.Failed, err)
+		}
+		t.Logf("\t%s\tShould delete the session.", tests.Success)
+	}
+}
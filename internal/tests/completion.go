@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Completion is a fake completion API answering whether a user completed
+// the task of a date. Point an HTTP checker at it with
+// c.URL() + "/completed?user={{.Username}}&date={{.Date}}".
+type Completion struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	completed map[string]bool
+	failing   map[string]bool
+	asked     []string
+}
+
+// NewCompletion starts a fake completion API.
+func NewCompletion() *Completion {
+	c := Completion{
+		completed: make(map[string]bool),
+		failing:   make(map[string]bool),
+	}
+	c.server = httptest.NewServer(http.HandlerFunc(c.serve))
+
+	return &c
+}
+
+// URL returns the base address of the server.
+func (c *Completion) URL() string {
+	return c.server.URL
+}
+
+// Close shuts the server down.
+func (c *Completion) Close() {
+	c.server.Close()
+}
+
+// Complete marks the task of the date as completed by the user.
+func (c *Completion) Complete(user string, date string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.completed[user+"|"+date] = true
+}
+
+// Fail makes the server answer with an error about the user.
+func (c *Completion) Fail(user string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failing[user] = true
+}
+
+// Asked returns the users the server was asked about, in order.
+func (c *Completion) Asked() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.asked...)
+}
+
+func (c *Completion) serve(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/completed") {
+		http.NotFound(w, r)
+		return
+	}
+
+	user, date := r.URL.Query().Get("user"), r.URL.Query().Get("date")
+
+	c.mu.Lock()
+	c.asked = append(c.asked, user)
+	completed, failing := c.completed[user+"|"+date], c.failing[user]
+	c.mu.Unlock()
+
+	if failing {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"completed": completed})
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// SlackMessage is a message posted to the fake Slack webhook.
+type SlackMessage struct {
+	Channel string `json:"channel"`
+	Text    string `json:"text"`
+}
+
+// Slack is a fake Slack incoming webhook. Point a Slack notifier at
+// s.URL().
+type Slack struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	messages []SlackMessage
+	status   int
+}
+
+// NewSlack starts a fake Slack incoming webhook.
+func NewSlack() *Slack {
+	s := Slack{
+		status: http.StatusOK,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+
+	return &s
+}
+
+// URL returns the address of the webhook.
+func (s *Slack) URL() string {
+	return s.server.URL
+}
+
+// Close shuts the server down.
+func (s *Slack) Close() {
+	s.server.Close()
+}
+
+// Fail makes the webhook answer with the status, http.StatusOK restores it.
+func (s *Slack) Fail(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = status
+}
+
+// Messages returns the messages accepted by the webhook, in order.
+func (s *Slack) Messages() []SlackMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]SlackMessage(nil), s.messages...)
+}
+
+func (s *Slack) serve(w http.ResponseWriter, r *http.Request) {
+	var m SlackMessage
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil || m.Text == "" {
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	status := s.status
+	if status == http.StatusOK {
+		s.messages = append(s.messages, m)
+	}
+	s.mu.Unlock()
+
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	w.Write([]byte("ok"))
+}
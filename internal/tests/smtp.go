@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Mail is an email accepted by the fake SMTP server.
+type Mail struct {
+	From string
+	To   []string
+	Data string // Headers and body, with the dot-stuffing removed.
+}
+
+// SMTP is a fake mail server speaking plain SMTP without TLS and
+// authentication. Point an SMTP notifier at s.Addr().
+type SMTP struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	mails    []Mail
+	rejected map[string]bool
+}
+
+// NewSMTP starts a fake mail server.
+func NewSMTP() *SMTP {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("tests: failed to listen: %v", err))
+	}
+
+	s := SMTP{
+		listener: l,
+		rejected: make(map[string]bool),
+	}
+	go s.accept()
+
+	return &s
+}
+
+// Addr returns the host and port of the server.
+func (s *SMTP) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close shuts the server down.
+func (s *SMTP) Close() {
+	s.listener.Close()
+}
+
+// Reject makes the server refuse the recipient as an unknown mailbox.
+func (s *SMTP) Reject(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rejected[addr] = true
+}
+
+// Mails returns the emails accepted by the server, in order.
+func (s *SMTP) Mails() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Mail(nil), s.mails...)
+}
+
+func (s *SMTP) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+func (s *SMTP) serve(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost fake SMTP")
+
+	var mail Mail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			tp.PrintfLine("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			mail = Mail{From: address(line[len("MAIL FROM:"):])}
+			tp.PrintfLine("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			to := address(line[len("RCPT TO:"):])
+
+			s.mu.Lock()
+			rejected := s.rejected[to]
+			s.mu.Unlock()
+
+			if rejected {
+				tp.PrintfLine("550 No such user %s", to)
+				continue
+			}
+			mail.To = append(mail.To, to)
+			tp.PrintfLine("250 OK")
+		case cmd == "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			lines, err := tp.ReadDotLines()
+			if err != nil {
+				return
+			}
+			mail.Data = strings.Join(lines, "\n")
+
+			s.mu.Lock()
+			s.mails = append(s.mails, mail)
+			s.mu.Unlock()
+
+			tp.PrintfLine("250 OK")
+		case cmd == "RSET", cmd == "NOOP":
+			tp.PrintfLine("250 OK")
+		case cmd == "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// address strips the angle brackets and parameters of a MAIL or RCPT one.
+func address(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if i := strings.Index(raw, ">"); i >= 0 {
+		raw = raw[:i]
+	}
+
+	return strings.TrimPrefix(raw, "<")
+}
@@ -0,0 +1,385 @@
+// Package tests contains supporting code for running tests against the bot.
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Token is accepted by the fake Telegram server.
+const Token = "123456:TEST"
+
+// Request is a Bot API call received by the fake Telegram server.
+type Request struct {
+	Method    string
+	Params    map[string]string
+	MessageID int // Message sent or edited by the call, 0 for other calls.
+}
+
+// Telegram is a fake Telegram Bot API server. Point telebot at it with
+// tb.Settings{URL: t.URL(), Token: tests.Token}.
+type Telegram struct {
+	server *httptest.Server
+	done   chan struct{}
+
+	mu        sync.Mutex
+	requests  []Request
+	updates   []tb.Update
+	files     map[string][]byte
+	admins    map[string][]tb.ChatMember
+	nextID    int
+	nextMsgID int
+	arrived   chan struct{}
+}
+
+// NewTelegram starts a fake Telegram Bot API server.
+func NewTelegram() *Telegram {
+	t := Telegram{
+		nextID:    1,
+		nextMsgID: 1,
+		arrived:   make(chan struct{}),
+		done:      make(chan struct{}),
+		files:     make(map[string][]byte),
+		admins:    make(map[string][]tb.ChatMember),
+	}
+	t.server = httptest.NewServer(http.HandlerFunc(t.serve))
+
+	return &t
+}
+
+// URL returns the base address to pass as tb.Settings.URL.
+func (t *Telegram) URL() string {
+	return t.server.URL
+}
+
+// Close releases pending long polls and shuts the server down.
+func (t *Telegram) Close() {
+	close(t.done)
+	t.server.Close()
+}
+
+// Inject queues an update to be returned by the next getUpdates call. The
+// update ID is assigned automatically.
+func (t *Telegram) Inject(u tb.Update) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u.ID = t.nextID
+	t.nextID++
+	t.updates = append(t.updates, u)
+
+	close(t.arrived)
+	t.arrived = make(chan struct{})
+}
+
+// Command injects a text message from user to chat.
+func (t *Telegram) Command(chat *tb.Chat, user *tb.User, text string) {
+	t.Reply(chat, user, 0, text)
+}
+
+// Reply injects a text message from user to chat in reply to the message
+// with the given ID, a plain message if it is 0.
+func (t *Telegram) Reply(chat *tb.Chat, user *tb.User, replyTo int, text string) {
+	t.mu.Lock()
+	id := t.nextMsgID
+	t.nextMsgID++
+	t.mu.Unlock()
+
+	m := tb.Message{
+		ID:       id,
+		Sender:   user,
+		Chat:     chat,
+		Text:     text,
+		Unixtime: time.Now().Unix(),
+	}
+	if replyTo != 0 {
+		m.ReplyTo = &tb.Message{ID: replyTo, Chat: chat}
+	}
+
+	t.Inject(tb.Update{Message: &m})
+}
+
+// Join injects the service message of users joining chat.
+func (t *Telegram) Join(chat *tb.Chat, users ...*tb.User) {
+	joined := make([]tb.User, len(users))
+	for i, u := range users {
+		joined[i] = *u
+	}
+
+	t.Inject(tb.Update{Message: t.service(chat, users[0], func(m *tb.Message) {
+		m.UserJoined = users[0]
+		m.UsersJoined = joined
+	})})
+}
+
+// Leave injects the service message of user leaving chat.
+func (t *Telegram) Leave(chat *tb.Chat, user *tb.User) {
+	t.Inject(tb.Update{Message: t.service(chat, user, func(m *tb.Message) {
+		m.UserLeft = user
+	})})
+}
+
+// service returns a new service message from user to chat, set up by fill.
+func (t *Telegram) service(chat *tb.Chat, user *tb.User, fill func(m *tb.Message)) *tb.Message {
+	t.mu.Lock()
+	id := t.nextMsgID
+	t.nextMsgID++
+	t.mu.Unlock()
+
+	m := tb.Message{
+		ID:       id,
+		Sender:   user,
+		Chat:     chat,
+		Unixtime: time.Now().Unix(),
+	}
+	fill(&m)
+
+	return &m
+}
+
+// Press injects a press of an inline button with the given callback data,
+// as sent by telebot for tb.InlineButton{Unique: unique, Data: data}.
+func (t *Telegram) Press(msg *tb.Message, user *tb.User, unique string, data string) {
+	if data != "" {
+		data = "|" + data
+	}
+
+	t.Inject(tb.Update{
+		Callback: &tb.Callback{
+			ID:      fmt.Sprintf("cb%d", time.Now().UnixNano()),
+			Sender:  user,
+			Message: msg,
+			Data:    "\f" + unique + data,
+		},
+	})
+}
+
+// AddFile makes data downloadable through getFile with the given file ID.
+func (t *Telegram) AddFile(fileID string, data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.files[fileID] = data
+}
+
+// SetAdmins sets the users returned by getChatAdministrators for the chat.
+func (t *Telegram) SetAdmins(chatID int64, users ...*tb.User) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	members := make([]tb.ChatMember, len(users))
+	for i, u := range users {
+		members[i] = tb.ChatMember{User: u, Role: tb.Administrator}
+	}
+	t.admins[strconv.FormatInt(chatID, 10)] = members
+}
+
+// Requests returns the recorded calls of the given method, or every call
+// if method is empty.
+func (t *Telegram) Requests(method string) []Request {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var requests []Request
+	for _, r := range t.requests {
+		if method == "" || r.Method == method {
+			requests = append(requests, r)
+		}
+	}
+
+	return requests
+}
+
+// Sent returns the texts of the recorded sendMessage calls.
+func (t *Telegram) Sent() []string {
+	var texts []string
+	for _, r := range t.Requests("sendMessage") {
+		texts = append(texts, r.Params["text"])
+	}
+
+	return texts
+}
+
+// WaitSent blocks until at least n messages were sent or the timeout
+// expires, and returns the sent texts.
+func (t *Telegram) WaitSent(n int, timeout time.Duration) []string {
+	deadline := time.Now().Add(timeout)
+	for {
+		sent := t.Sent()
+		if len(sent) >= n || time.Now().After(deadline) {
+			return sent
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (t *Telegram) serve(w http.ResponseWriter, r *http.Request) {
+	if files := "/file/bot" + Token + "/"; strings.HasPrefix(r.URL.Path, files) {
+		t.mu.Lock()
+		data, ok := t.files[strings.TrimPrefix(r.URL.Path, files)]
+		t.mu.Unlock()
+
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+		return
+	}
+
+	prefix := "/bot" + Token + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	method := strings.TrimPrefix(r.URL.Path, prefix)
+
+	params := make(map[string]string)
+	var upload []byte
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err == nil {
+			for k, v := range r.MultipartForm.Value {
+				params[k] = v[0]
+			}
+			for _, fh := range r.MultipartForm.File {
+				if f, err := fh[0].Open(); err == nil {
+					upload, _ = ioutil.ReadAll(f)
+					f.Close()
+				}
+			}
+		}
+	} else if r.Body != nil {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		for k, v := range body {
+			if str, ok := v.(string); ok {
+				params[k] = str
+				continue
+			}
+			data, _ := json.Marshal(v)
+			params[k] = string(data)
+		}
+	}
+
+	if method == "getUpdates" {
+		t.getUpdates(w, r, params)
+		return
+	}
+
+	t.mu.Lock()
+	t.requests = append(t.requests, Request{Method: method, Params: params})
+	index := len(t.requests) - 1
+	t.mu.Unlock()
+
+	switch method {
+	case "getMe":
+		writeResult(w, tb.User{ID: 1, FirstName: "Reminder", Username: "reminder_test_bot"})
+	case "sendMessage", "sendDocument", "editMessageText", "editMessageReplyMarkup":
+		// Edited messages keep their ID.
+		t.mu.Lock()
+		id, _ := strconv.Atoi(params["message_id"])
+		if !strings.HasPrefix(method, "edit") || id == 0 {
+			id = t.nextMsgID
+			t.nextMsgID++
+		}
+		t.requests[index].MessageID = id
+		t.mu.Unlock()
+
+		chatID, _ := strconv.ParseInt(params["chat_id"], 10, 64)
+		msg := tb.Message{
+			ID:       id,
+			Chat:     &tb.Chat{ID: chatID},
+			Text:     params["text"],
+			Caption:  params["caption"],
+			Unixtime: time.Now().Unix(),
+		}
+
+		// Uploaded documents become downloadable by a file ID equal to
+		// the message ID, so they can be fed back to the bot.
+		if method == "sendDocument" {
+			fileID := strconv.Itoa(id)
+			t.AddFile(fileID, upload)
+			msg.Document = &tb.Document{
+				File:     tb.File{FileID: fileID, FileSize: len(upload)},
+				FileName: params["file_name"],
+			}
+		}
+
+		writeResult(w, msg)
+	case "getFile":
+		writeResult(w, tb.File{FileID: params["file_id"], FilePath: params["file_id"]})
+	case "getChatAdministrators":
+		t.mu.Lock()
+		admins := t.admins[params["chat_id"]]
+		t.mu.Unlock()
+
+		if admins == nil {
+			admins = []tb.ChatMember{}
+		}
+		writeResult(w, admins)
+	default:
+		writeResult(w, true)
+	}
+}
+
+func (t *Telegram) getUpdates(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	offset, _ := strconv.Atoi(params["offset"])
+	timeout, _ := strconv.Atoi(params["timeout"])
+
+	deadline := time.After(time.Duration(timeout) * time.Second)
+	for {
+		t.mu.Lock()
+		var updates []tb.Update
+		for _, u := range t.updates {
+			if u.ID >= offset {
+				updates = append(updates, u)
+			}
+		}
+		arrived := t.arrived
+		t.mu.Unlock()
+
+		if len(updates) > 0 || timeout == 0 {
+			writeResult(w, updates)
+			return
+		}
+
+		select {
+		case <-arrived:
+		case <-deadline:
+			writeResult(w, []tb.Update{})
+			return
+		case <-t.done:
+			writeResult(w, []tb.Update{})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeResult(w http.ResponseWriter, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ok":     true,
+		"result": result,
+	})
+}
+
+func writeError(w http.ResponseWriter, code int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ok":          false,
+		"error_code":  code,
+		"description": description,
+	})
+}
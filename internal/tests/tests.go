@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+)
+
+// Success and failure markers.
+const (
+	Success = "✓"
+	Failed  = "✗"
+)
+
+// Wait polls cond until it holds or the timeout passes, and reports whether
+// it held. Goroutines driven by a fake clock need a moment of real time to
+// react once it is advanced.
+func Wait(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if cond() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Advance moves the fake clock forward by d in steps, giving the goroutines
+// waiting on its timers a moment to react after each of them.
+func Advance(clk *clock.Fake, d time.Duration, step time.Duration) {
+	for d > 0 {
+		if step > d {
+			step = d
+		}
+		clk.Advance(step)
+		d -= step
+		time.Sleep(2 * time.Millisecond)
+	}
+}
@@ -0,0 +1,317 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+)
+
+// Endpoint is a URL the events are posted to.
+type Endpoint struct {
+	URL    string  // Address the events are posted to.
+	Secret string  // Key of the HMAC-SHA256 signature in the X-Webhook-Signature header.
+	Events []Event // Events posted to the endpoint, all of them if empty.
+}
+
+// subscribed reports whether the endpoint wants the event.
+func (e Endpoint) subscribed(event Event) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+
+	for _, ev := range e.Events {
+		if ev == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DispatcherConfig configures a Dispatcher, zero fields take the defaults.
+type DispatcherConfig struct {
+	Clock        clock.Clock   // Source of the current time, the wall clock by default.
+	Client       *http.Client  // Client posting the events, one with Timeout by default.
+	Timeout      time.Duration // Timeout of a single attempt.
+	PollInterval time.Duration // How often pending deliveries are checked without being woken up.
+	MinBackoff   time.Duration // Delay before the first retry.
+	MaxBackoff   time.Duration // Upper bound of the exponential backoff.
+	MaxAttempts  int           // Attempts after which a delivery is marked as failed.
+	BatchSize    int           // Deliveries loaded at once.
+}
+
+// Dispatcher posts the events to the endpoints. Emit persists an event for
+// every endpoint subscribed to it, and Run posts them, retrying failures
+// with exponential backoff.
+type Dispatcher struct {
+	store     Store
+	endpoints []Endpoint
+	cfg       DispatcherConfig
+	wake      chan struct{}
+}
+
+// Payload is the JSON document posted to the endpoints.
+type Payload struct {
+	ID        string      `json:"id"`         // Unique identifier of the event.
+	Event     Event       `json:"event"`      // Event type.
+	CreatedAt time.Time   `json:"created_at"` // When the event happened.
+	Data      interface{} `json:"data"`       // Details of the event.
+}
+
+// NewDispatcher creates a Dispatcher posting the events to the endpoints.
+func NewDispatcher(store Store, endpoints []Endpoint, cfg DispatcherConfig) *Dispatcher {
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real{}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: cfg.Timeout}
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 10 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Hour
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	return &Dispatcher{
+		store:     store,
+		endpoints: endpoints,
+		cfg:       cfg,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Emit persists the event for delivery to every endpoint subscribed to it
+// and wakes the delivery loop up. It does nothing without endpoints.
+func (d *Dispatcher) Emit(ctx context.Context, event Event, data interface{}) error {
+	ctx, span := trace.StartSpan(ctx, "internal.webhook.Dispatcher.Emit")
+	defer span.End()
+
+	now := d.cfg.Clock.Now()
+
+	var payload []byte
+	for _, e := range d.endpoints {
+		if !e.subscribed(event) {
+			continue
+		}
+
+		if payload == nil {
+			p := Payload{
+				ID:        uuid.New().String(),
+				Event:     event,
+				CreatedAt: now.UTC(),
+				Data:      data,
+			}
+
+			var err error
+			if payload, err = json.Marshal(p); err != nil {
+				return errors.Wrap(err, "marshalling webhook payload")
+			}
+		}
+
+		nd := NewDelivery{
+			URL:     e.URL,
+			Event:   event,
+			Payload: string(payload),
+		}
+		if _, err := d.store.Enqueue(ctx, nd, now); err != nil {
+			return errors.Wrapf(err, "enqueuing %s for %s", event, e.URL)
+		}
+	}
+
+	if payload != nil {
+		d.Wake()
+	}
+
+	return nil
+}
+
+// Wake makes the delivery loop check the pending deliveries right away.
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run posts pending deliveries until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	timer := d.cfg.Clock.NewTimer(0)
+	defer func() { timer.Stop() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-timer.C():
+		}
+
+		timer.Stop()
+		d.flush(ctx)
+		timer = d.cfg.Clock.NewTimer(d.cfg.PollInterval)
+	}
+}
+
+func (d *Dispatcher) flush(ctx context.Context) {
+	deliveries, err := d.store.ListPending(ctx, d.cfg.Clock.Now(), d.cfg.BatchSize)
+	if err != nil {
+		log.Println("webhook.Dispatcher.flush : error :", err)
+		return
+	}
+
+	for _, dl := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		e, ok := d.endpoint(dl.URL)
+		if !ok {
+			// Removed from the configuration since the event was emitted.
+			if err := d.store.Fail(ctx, dl.ID, "endpoint is no longer configured", d.cfg.Clock.Now()); err != nil {
+				log.Println("webhook.Dispatcher.flush : error :", err)
+			}
+			continue
+		}
+
+		d.deliver(ctx, e, dl)
+	}
+}
+
+// deliver makes a single delivery attempt to the endpoint and records it.
+func (d *Dispatcher) deliver(ctx context.Context, e Endpoint, dl Delivery) {
+	code, err := d.post(ctx, e, dl)
+
+	now := d.cfg.Clock.Now()
+
+	a := Attempt{
+		DeliveryID:  dl.ID,
+		Attempt:     dl.Attempts + 1,
+		URL:         dl.URL,
+		Event:       dl.Event,
+		StatusCode:  code,
+		AttemptedAt: now,
+	}
+	if err != nil {
+		a.Error = err.Error()
+	}
+	if err := d.store.RecordAttempt(ctx, a); err != nil {
+		log.Println("webhook.Dispatcher.deliver : error :", err)
+	}
+
+	if err == nil {
+		if err := d.store.Delivered(ctx, dl.ID, now); err != nil {
+			log.Println("webhook.Dispatcher.deliver : error :", err)
+		}
+		return
+	}
+
+	log.Printf("webhook.Dispatcher.deliver : error : attempt %d of %s to %s : %v", a.Attempt, dl.Event, dl.URL, err)
+
+	if a.Attempt >= d.cfg.MaxAttempts {
+		if err := d.store.Fail(ctx, dl.ID, err.Error(), now); err != nil {
+			log.Println("webhook.Dispatcher.deliver : error :", err)
+		}
+		return
+	}
+
+	if err := d.store.Retry(ctx, dl.ID, now.Add(d.backoff(dl.Attempts)), err.Error(), now); err != nil {
+		log.Println("webhook.Dispatcher.deliver : error :", err)
+	}
+}
+
+// post sends the payload of the delivery signed with the secret of the
+// endpoint and returns the status code of the response, any status other
+// than 2xx is an error.
+func (d *Dispatcher) post(ctx context.Context, e Endpoint, dl Delivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, dl.URL, bytes.NewReader([]byte(dl.Payload)))
+	if err != nil {
+		return 0, errors.Wrap(err, "creating request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", string(dl.Event))
+	req.Header.Set("X-Webhook-Delivery", dl.ID)
+	if e.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", Sign(e.Secret, []byte(dl.Payload)))
+	}
+
+	resp, err := d.cfg.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// endpoint returns the configured endpoint with the URL.
+func (d *Dispatcher) endpoint(url string) (Endpoint, bool) {
+	for _, e := range d.endpoints {
+		if e.URL == url {
+			return e, true
+		}
+	}
+
+	return Endpoint{}, false
+}
+
+// backoff returns the delay before the retry that follows the given number
+// of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	b := d.cfg.MinBackoff
+	for i := 0; i < attempts; i++ {
+		b *= 2
+		if b >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+
+	return b
+}
+
+// Sign returns the signature of a payload in format "sha256=<hex>", the
+// HMAC-SHA256 of the payload keyed with the secret. Receivers verify it by
+// computing the same over the raw request body.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
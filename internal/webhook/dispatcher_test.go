@@ -0,0 +1,300 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmowka/telegram-reminder-bot/internal/platform/clock"
+	"github.com/tmowka/telegram-reminder-bot/internal/tests"
+	"github.com/tmowka/telegram-reminder-bot/internal/webhook"
+)
+
+// request is a request received by the endpoint.
+type request struct {
+	Event     string
+	Delivery  string
+	Signature string
+	Body      []byte
+}
+
+// endpoint is an HTTP server answering the first failures requests with 500.
+type endpoint struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	failures int
+	requests []request
+}
+
+func newEndpoint(failures int) *endpoint {
+	e := endpoint{failures: failures}
+	e.Server = httptest.NewServer(http.HandlerFunc(e.serve))
+
+	return &e
+}
+
+func (e *endpoint) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.requests = append(e.requests, request{
+		Event:     r.Header.Get("X-Webhook-Event"),
+		Delivery:  r.Header.Get("X-Webhook-Delivery"),
+		Signature: r.Header.Get("X-Webhook-Signature"),
+		Body:      body,
+	})
+	if len(e.requests) <= e.failures {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (e *endpoint) received() []request {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]request(nil), e.requests...)
+}
+
+// TestDispatcher validates the events are signed and retried with backoff
+// and every attempt is recorded.
+func TestDispatcher(t *testing.T) {
+	srv := newEndpoint(2)
+	defer srv.Close()
+
+	clk := clock.NewFake(time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC))
+	store := webhook.NewMemoryStore()
+
+	endpoints := []webhook.Endpoint{
+		{URL: srv.URL, Secret: "s3cret", Events: []webhook.Event{webhook.EventFired}},
+	}
+	d := webhook.NewDispatcher(store, endpoints, webhook.DispatcherConfig{
+		Clock:        clk,
+		PollInterval: time.Second,
+		MinBackoff:   10 * time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	t.Log("Given an endpoint that fails twice.")
+	{
+		if err := d.Emit(ctx, webhook.EventAcknowledged, map[string]string{"participant": "alice"}); err != nil {
+			t.Fatalf("\t%s\tShould be able to emit an event : %s.", tests.Failed, err)
+		}
+		if err := d.Emit(ctx, webhook.EventFired, map[string]string{"participant": "alice"}); err != nil {
+			t.Fatalf("\t%s\tShould be able to emit an event : %s.", tests.Failed, err)
+		}
+
+		if !tests.Wait(time.Second, func() bool { return len(srv.received()) == 1 }) {
+			t.Fatalf("\t%s\tShould post the event right away : got %d requests.", tests.Failed, len(srv.received()))
+		}
+		t.Logf("\t%s\tShould post the event right away.", tests.Success)
+
+		r := srv.received()[0]
+		if r.Event != string(webhook.EventFired) {
+			t.Fatalf("\t%s\tShould post only the subscribed events : got %q.", tests.Failed, r.Event)
+		}
+		t.Logf("\t%s\tShould post only the subscribed events.", tests.Success)
+
+		if want := webhook.Sign("s3cret", r.Body); r.Signature != want {
+			t.Fatalf("\t%s\tShould sign the payload : got %q, want %q.", tests.Failed, r.Signature, want)
+		}
+		t.Logf("\t%s\tShould sign the payload.", tests.Success)
+
+		var p webhook.Payload
+		if err := json.Unmarshal(r.Body, &p); err != nil {
+			t.Fatalf("\t%s\tShould post a JSON payload : %s.", tests.Failed, err)
+		}
+		if p.Event != webhook.EventFired || p.ID == "" {
+			t.Fatalf("\t%s\tShould post the event in the payload : got %+v.", tests.Failed, p)
+		}
+		t.Logf("\t%s\tShould post the event in the payload.", tests.Success)
+
+		tests.Advance(clk, 9*time.Second, time.Second)
+		if got := len(srv.received()); got != 1 {
+			t.Fatalf("\t%s\tShould wait for the first backoff : got %d requests.", tests.Failed, got)
+		}
+		tests.Advance(clk, time.Second, time.Second)
+		if !tests.Wait(time.Second, func() bool { return len(srv.received()) == 2 }) {
+			t.Fatalf("\t%s\tShould retry after the first backoff : got %d requests.", tests.Failed, len(srv.received()))
+		}
+		t.Logf("\t%s\tShould retry after the first backoff.", tests.Success)
+
+		tests.Advance(clk, 19*time.Second, time.Second)
+		if got := len(srv.received()); got != 2 {
+			t.Fatalf("\t%s\tShould double the backoff : got %d requests.", tests.Failed, got)
+		}
+		tests.Advance(clk, time.Second, time.Second)
+		if !tests.Wait(time.Second, func() bool { return len(srv.received()) == 3 }) {
+			t.Fatalf("\t%s\tShould retry after the doubled backoff : got %d requests.", tests.Failed, len(srv.received()))
+		}
+		t.Logf("\t%s\tShould retry after the doubled backoff.", tests.Success)
+
+		for _, r := range srv.received() {
+			if r.Delivery != srv.received()[0].Delivery {
+				t.Fatalf("\t%s\tShould keep the delivery ID across retries.", tests.Failed)
+			}
+		}
+		t.Logf("\t%s\tShould keep the delivery ID across retries.", tests.Success)
+
+		delivered := func() bool {
+			ds, err := store.ListPending(ctx, clk.Now().Add(time.Hour), 10)
+			return err == nil && len(ds) == 0
+		}
+		if !tests.Wait(time.Second, delivered) {
+			t.Fatalf("\t%s\tShould mark the delivery as delivered.", tests.Failed)
+		}
+		t.Logf("\t%s\tShould mark the delivery as delivered.", tests.Success)
+
+		attempts, err := store.ListFailedAttempts(ctx, 10)
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to list failed attempts : %s.", tests.Failed, err)
+		}
+		if len(attempts) != 2 {
+			t.Fatalf("\t%s\tShould record the failed attempts : got %d.", tests.Failed, len(attempts))
+		}
+		for _, a := range attempts {
+			if a.StatusCode != http.StatusInternalServerError || a.Error == "" || a.URL != srv.URL {
+				t.Fatalf("\t%s\tShould record the status and error of the attempt : got %+v.", tests.Failed, a)
+			}
+		}
+		t.Logf("\t%s\tShould record the failed attempts.", tests.Success)
+	}
+}
+
+// TestDispatcherGivesUp validates a delivery fails after MaxAttempts.
+func TestDispatcherGivesUp(t *testing.T) {
+	srv := newEndpoint(100)
+	defer srv.Close()
+
+	clk := clock.NewFake(time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC))
+	store := webhook.NewMemoryStore()
+
+	d := webhook.NewDispatcher(store, []webhook.Endpoint{{URL: srv.URL}}, webhook.DispatcherConfig{
+		Clock:        clk,
+		PollInterval: time.Second,
+		MinBackoff:   time.Second,
+		MaxBackoff:   time.Second,
+		MaxAttempts:  3,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	t.Log("Given an endpoint that always fails.")
+	{
+		if err := d.Emit(ctx, webhook.EventFired, nil); err != nil {
+			t.Fatalf("\t%s\tShould be able to emit an event : %s.", tests.Failed, err)
+		}
+
+		tests.Advance(clk, time.Minute, time.Second)
+
+		failed := func() bool {
+			ds, err := store.ListPending(ctx, clk.Now().Add(time.Hour), 10)
+			return err == nil && len(ds) == 0
+		}
+		if !tests.Wait(time.Second, failed) {
+			t.Fatalf("\t%s\tShould give the delivery up.", tests.Failed)
+		}
+		if got := len(srv.received()); got != 3 {
+			t.Fatalf("\t%s\tShould give up after MaxAttempts : got %d requests.", tests.Failed, got)
+		}
+		if r := srv.received()[0]; r.Signature != "" {
+			t.Fatalf("\t%s\tShould not sign without a secret : got %q.", tests.Failed, r.Signature)
+		}
+		t.Logf("\t%s\tShould give the delivery up after MaxAttempts.", tests.Success)
+	}
+}
+
+// TestDispatcherStarvation validates deliveries backing off for an endpoint
+// that is down don't hold back the events of the other endpoints.
+func TestDispatcherStarvation(t *testing.T) {
+	for _, backend := range tests.Backends {
+		backend := backend
+		t.Run(backend, func(t *testing.T) {
+			st, teardown := tests.NewStorage(t, backend)
+			defer teardown()
+
+			down := newEndpoint(1000)
+			defer down.Close()
+			up := newEndpoint(0)
+			defer up.Close()
+
+			clk := clock.NewFake(time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC))
+			ctx := context.Background()
+
+			// More deliveries than a batch backing off for the endpoint that
+			// is down.
+			for i := 0; i < 10; i++ {
+				nd := webhook.NewDelivery{URL: down.URL, Event: webhook.EventEscalated, Payload: "{}"}
+				dl, err := st.Webhook.Enqueue(ctx, nd, clk.Now().Add(-time.Minute))
+				if err != nil {
+					t.Fatalf("enqueuing delivery: %s", err)
+				}
+				if err := st.Webhook.Retry(ctx, dl.ID, clk.Now().Add(time.Hour), "unavailable", clk.Now()); err != nil {
+					t.Fatalf("retrying delivery: %s", err)
+				}
+			}
+
+			endpoints := []webhook.Endpoint{
+				{URL: down.URL, Events: []webhook.Event{webhook.EventEscalated}},
+				{URL: up.URL, Events: []webhook.Event{webhook.EventFired}},
+			}
+			d := webhook.NewDispatcher(st.Webhook, endpoints, webhook.DispatcherConfig{
+				Clock:        clk,
+				PollInterval: time.Second,
+				BatchSize:    5,
+			})
+
+			runCtx, cancel := context.WithCancel(ctx)
+			done := make(chan struct{})
+			go func() {
+				d.Run(runCtx)
+				close(done)
+			}()
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			t.Log("Given more deliveries than a batch backing off for an endpoint that is down.")
+			{
+				if err := d.Emit(ctx, webhook.EventFired, nil); err != nil {
+					t.Fatalf("\t%s\tShould be able to emit an event : %s.", tests.Failed, err)
+				}
+
+				if !tests.Wait(time.Second, func() bool { return len(up.received()) == 1 }) {
+					t.Fatalf("\t%s\tShould post the event to the healthy endpoint.", tests.Failed)
+				}
+				if got := len(down.received()); got != 0 {
+					t.Fatalf("\t%s\tShould not retry before the backoff : got %d requests.", tests.Failed, got)
+				}
+				t.Logf("\t%s\tShould post the event to the healthy endpoint.", tests.Success)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps deliveries and attempts in process memory. It is meant
+// for tests and short-lived deployments, nothing survives a restart.
+type memoryStore struct {
+	mu         sync.Mutex
+	deliveries map[string]Delivery
+	attempts   []Attempt
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() Store {
+	return &memoryStore{deliveries: make(map[string]Delivery)}
+}
+
+func (s *memoryStore) Enqueue(ctx context.Context, nd NewDelivery, now time.Time) (*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := Delivery{
+		ID:            uuid.New().String(),
+		URL:           nd.URL,
+		Event:         nd.Event,
+		Payload:       nd.Payload,
+		Status:        StatusPending,
+		NextAttemptAt: now.UTC(),
+		CreatedAt:     now.UTC(),
+		UpdatedAt:     now.UTC(),
+	}
+	s.deliveries[d.ID] = d
+
+	return &d, nil
+}
+
+func (s *memoryStore) ListPending(ctx context.Context, now time.Time, limit int) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deliveries []Delivery
+	for _, d := range s.deliveries {
+		if d.Status == StatusPending && !d.NextAttemptAt.After(now) {
+			deliveries = append(deliveries, d)
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		a, b := deliveries[i], deliveries[j]
+		if !a.NextAttemptAt.Equal(b.NextAttemptAt) {
+			return a.NextAttemptAt.Before(b.NextAttemptAt)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
+
+func (s *memoryStore) Delivered(ctx context.Context, id string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[id]
+	if !ok {
+		return nil
+	}
+
+	d.Status = StatusDelivered
+	d.UpdatedAt = now.UTC()
+	s.deliveries[id] = d
+
+	return nil
+}
+
+func (s *memoryStore) Retry(ctx context.Context, id string, nextAttemptAt time.Time, lastError string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[id]
+	if !ok {
+		return nil
+	}
+
+	d.Attempts++
+	d.NextAttemptAt = nextAttemptAt.UTC()
+	d.LastError = lastError
+	d.UpdatedAt = now.UTC()
+	s.deliveries[id] = d
+
+	return nil
+}
+
+func (s *memoryStore) Fail(ctx context.Context, id string, lastError string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[id]
+	if !ok {
+		return nil
+	}
+
+	d.Status = StatusFailed
+	d.Attempts++
+	d.LastError = lastError
+	d.UpdatedAt = now.UTC()
+	s.deliveries[id] = d
+
+	return nil
+}
+
+func (s *memoryStore) RecordAttempt(ctx context.Context, a Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a.AttemptedAt = a.AttemptedAt.UTC()
+	s.attempts = append(s.attempts, a)
+
+	return nil
+}
+
+func (s *memoryStore) ListFailedAttempts(ctx context.Context, limit int) ([]Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var attempts []Attempt
+	for i := len(s.attempts) - 1; i >= 0 && len(attempts) < limit; i-- {
+		if s.attempts[i].Error != "" {
+			attempts = append(attempts, s.attempts[i])
+		}
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,54 @@
+package webhook
+
+import "time"
+
+// Event is what happened to a reminder.
+type Event string
+
+const (
+	EventFired        Event = "reminder.fired"
+	EventAcknowledged Event = "reminder.acknowledged"
+	EventEscalated    Event = "reminder.escalated"
+)
+
+// Events lists every event, e.g. for the endpoints subscribed to all of them.
+var Events = []Event{EventFired, EventAcknowledged, EventEscalated}
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	StatusFailed    Status = "failed"
+)
+
+// Delivery is an event to post to a webhook endpoint.
+type Delivery struct {
+	ID            string    `db:"delivery_id" json:"id"`                  // Unique identifier, sent in the X-Webhook-Delivery header.
+	URL           string    `db:"url" json:"url"`                         // Endpoint the event is posted to.
+	Event         Event     `db:"event" json:"event"`                     // Event type.
+	Payload       string    `db:"payload" json:"payload"`                 // JSON document posted to the endpoint.
+	Status        Status    `db:"status" json:"status"`                   // Delivery status.
+	Attempts      int       `db:"attempts" json:"attempts"`               // Number of failed delivery attempts.
+	NextAttemptAt time.Time `db:"next_attempt_at" json:"next_attempt_at"` // When the next delivery attempt is allowed.
+	LastError     string    `db:"last_error" json:"last_error"`           // Error of the last failed attempt.
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`           // When the event happened.
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`           // When the delivery record was last modified.
+}
+
+type NewDelivery struct {
+	URL     string `json:"url" validate:"required"`
+	Event   Event  `json:"event" validate:"required"`
+	Payload string `json:"payload" validate:"required"`
+}
+
+// Attempt is a single attempt to post a delivery.
+type Attempt struct {
+	DeliveryID  string    `db:"delivery_id" json:"delivery_id"`   // Delivery the attempt was made for.
+	Attempt     int       `db:"attempt" json:"attempt"`           // Number of the attempt, starting from 1.
+	URL         string    `db:"url" json:"url"`                   // Endpoint the event was posted to.
+	Event       Event     `db:"event" json:"event"`               // Event type.
+	StatusCode  int       `db:"status_code" json:"status_code"`   // HTTP status of the response, 0 if there was none.
+	Error       string    `db:"error" json:"error"`               // Why the attempt failed, empty if it succeeded.
+	AttemptedAt time.Time `db:"attempted_at" json:"attempted_at"` // When the attempt was made.
+}
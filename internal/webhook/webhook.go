@@ -0,0 +1,171 @@
+// Package webhook posts the events of the reminders to the configured HTTP
+// endpoints. The events are persisted as deliveries first, so they survive
+// restarts and are retried with backoff, and every attempt is recorded.
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Store is the repository of webhook deliveries and their attempts.
+type Store interface {
+	Enqueue(ctx context.Context, nd NewDelivery, now time.Time) (*Delivery, error)
+	ListPending(ctx context.Context, now time.Time, limit int) ([]Delivery, error)
+	Delivered(ctx context.Context, id string, now time.Time) error
+	Retry(ctx context.Context, id string, nextAttemptAt time.Time, lastError string, now time.Time) error
+	Fail(ctx context.Context, id string, lastError string, now time.Time) error
+	RecordAttempt(ctx context.Context, a Attempt) error
+	ListFailedAttempts(ctx context.Context, limit int) ([]Attempt, error)
+}
+
+// dbStore keeps deliveries in the webhook_deliveries table and their
+// attempts in the webhook_attempts table of a Postgres or SQLite database.
+type dbStore struct {
+	db sqlx.ExtContext
+}
+
+// NewDBStore returns a Store backed by the webhook tables of db.
+func NewDBStore(db sqlx.ExtContext) Store {
+	return &dbStore{db: db}
+}
+
+func (s *dbStore) Enqueue(ctx context.Context, nd NewDelivery, now time.Time) (*Delivery, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.webhook.Enqueue")
+	defer span.End()
+
+	d := Delivery{
+		ID:            uuid.New().String(),
+		URL:           nd.URL,
+		Event:         nd.Event,
+		Payload:       nd.Payload,
+		Status:        StatusPending,
+		NextAttemptAt: now.UTC(),
+		CreatedAt:     now.UTC(),
+		UpdatedAt:     now.UTC(),
+	}
+
+	const q = `insert into webhook_deliveries
+		(delivery_id, url, event, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := s.db.ExecContext(ctx, q,
+		d.ID, d.URL, d.Event, d.Payload, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.CreatedAt, d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting webhook delivery")
+	}
+
+	return &d, nil
+}
+
+// ListPending returns undelivered deliveries due by now, the longest waiting
+// first. Deliveries backing off are left out, so they don't hold back the
+// events of the other endpoints.
+func (s *dbStore) ListPending(ctx context.Context, now time.Time, limit int) ([]Delivery, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.webhook.ListPending")
+	defer span.End()
+
+	var deliveries []Delivery
+	const q = `select * from webhook_deliveries
+		where status = $1 and next_attempt_at <= $2
+		order by next_attempt_at, created_at
+		limit $3`
+
+	if err := sqlx.SelectContext(ctx, s.db, &deliveries, q, StatusPending, now.UTC(), limit); err != nil {
+		return nil, errors.Wrap(err, "selecting pending webhook deliveries")
+	}
+
+	return deliveries, nil
+}
+
+// Delivered marks the delivery as posted.
+func (s *dbStore) Delivered(ctx context.Context, id string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.webhook.Delivered")
+	defer span.End()
+
+	const q = `update webhook_deliveries
+		set status = $1, updated_at = $2
+		where delivery_id = $3`
+
+	if _, err := s.db.ExecContext(ctx, q, StatusDelivered, now.UTC(), id); err != nil {
+		return errors.Wrapf(err, "completing webhook delivery %s", id)
+	}
+
+	return nil
+}
+
+// Retry records a failed attempt and postpones the delivery until
+// nextAttemptAt.
+func (s *dbStore) Retry(ctx context.Context, id string, nextAttemptAt time.Time, lastError string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.webhook.Retry")
+	defer span.End()
+
+	const q = `update webhook_deliveries
+		set attempts = attempts + 1, next_attempt_at = $1, last_error = $2, updated_at = $3
+		where delivery_id = $4`
+
+	if _, err := s.db.ExecContext(ctx, q, nextAttemptAt.UTC(), lastError, now.UTC(), id); err != nil {
+		return errors.Wrapf(err, "rescheduling webhook delivery %s", id)
+	}
+
+	return nil
+}
+
+// Fail marks the delivery as undeliverable so it is not attempted again.
+func (s *dbStore) Fail(ctx context.Context, id string, lastError string, now time.Time) error {
+	ctx, span := trace.StartSpan(ctx, "internal.webhook.Fail")
+	defer span.End()
+
+	const q = `update webhook_deliveries
+		set status = $1, attempts = attempts + 1, last_error = $2, updated_at = $3
+		where delivery_id = $4`
+
+	if _, err := s.db.ExecContext(ctx, q, StatusFailed, lastError, now.UTC(), id); err != nil {
+		return errors.Wrapf(err, "failing webhook delivery %s", id)
+	}
+
+	return nil
+}
+
+func (s *dbStore) RecordAttempt(ctx context.Context, a Attempt) error {
+	ctx, span := trace.StartSpan(ctx, "internal.webhook.RecordAttempt")
+	defer span.End()
+
+	const q = `insert into webhook_attempts
+		(delivery_id, attempt, url, event, status_code, error, attempted_at)
+		values ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := s.db.ExecContext(ctx, q,
+		a.DeliveryID, a.Attempt, a.URL, a.Event, a.StatusCode, a.Error, a.AttemptedAt.UTC(),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "inserting attempt of webhook delivery %s", a.DeliveryID)
+	}
+
+	return nil
+}
+
+// ListFailedAttempts returns the latest failed attempts, the most recent
+// first.
+func (s *dbStore) ListFailedAttempts(ctx context.Context, limit int) ([]Attempt, error) {
+	ctx, span := trace.StartSpan(ctx, "internal.webhook.ListFailedAttempts")
+	defer span.End()
+
+	var attempts []Attempt
+	const q = `select * from webhook_attempts
+		where error != ''
+		order by attempted_at desc, attempt desc
+		limit $1`
+
+	if err := sqlx.SelectContext(ctx, s.db, &attempts, q, limit); err != nil {
+		return nil, errors.Wrap(err, "selecting failed webhook attempts")
+	}
+
+	return attempts, nil
+}